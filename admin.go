@@ -0,0 +1,164 @@
+package sonos
+
+import (
+	"errors"
+
+	dev "github.com/caglar10ur/sonos/services/DeviceProperties"
+)
+
+var (
+	// ErrConfigMode is returned when changing a player that is being set up
+	// by a controller.
+	ErrConfigMode = errors.New("player is in config mode")
+	// ErrSatellite is returned when changing room settings through a
+	// bonded satellite instead of the primary player of the room.
+	ErrSatellite = errors.New("player is a bonded satellite")
+)
+
+// checkAdministrable returns an error if the room settings of the player
+// cannot be changed. Config mode is only known once a DeviceProperties
+// event has been received.
+func (z *ZonePlayer) checkAdministrable() error {
+	if z.GetState().Snapshot().ConfigMode != "" {
+		return ErrConfigMode
+	}
+
+	zoneGroupState, err := z.GetZoneGroupState()
+	if err != nil {
+		return err
+	}
+	if zoneGroupState.isSatellite(z.UUID()) {
+		return ErrSatellite
+	}
+	if member := zoneGroupState.member(z.UUID()); member != nil && member.Invisible == "1" {
+		return ErrSatellite
+	}
+	return nil
+}
+
+// setZoneAttributes reads the current zone attributes, lets change modify
+// them and writes them back; SetZoneAttributes clears what is not passed.
+func (z *ZonePlayer) setZoneAttributes(change func(*dev.SetZoneAttributesArgs)) error {
+	if err := z.checkAdministrable(); err != nil {
+		return err
+	}
+
+	res, err := z.DeviceProperties().GetZoneAttributes(&dev.GetZoneAttributesArgs{})
+	if err != nil {
+		return err
+	}
+	args := &dev.SetZoneAttributesArgs{
+		DesiredZoneName:       res.CurrentZoneName,
+		DesiredIcon:           res.CurrentIcon,
+		DesiredConfiguration:  res.CurrentConfiguration,
+		DesiredTargetRoomName: res.CurrentTargetRoomName,
+	}
+	change(args)
+
+	_, err = z.DeviceProperties().SetZoneAttributes(args)
+	return err
+}
+
+// Rename changes the name of the player's room. The volume cap of the room,
+// if any, moves along.
+func (z *ZonePlayer) Rename(room string) error {
+	if room == "" {
+		return errors.New("empty room name")
+	}
+
+	old := z.RoomName()
+	err := z.setZoneAttributes(func(args *dev.SetZoneAttributesArgs) {
+		args.DesiredZoneName = room
+	})
+	if err != nil {
+		return err
+	}
+
+	// The description may be shared with other players and caches, so
+	// replace it rather than modifying it.
+	root := *z.Root()
+	root.Device.RoomName = room
+	z.setRoot(&root)
+
+	if max, ok := z.volumeLimits.Get(old); ok {
+		z.volumeLimits.Remove(old)
+		z.volumeLimits.Set(room, max)
+	}
+	return nil
+}
+
+// Icon returns the icon of the player's room, e.g.
+// x-rincon-roomicon:living.
+func (z *ZonePlayer) Icon() (string, error) {
+	res, err := z.DeviceProperties().GetZoneAttributes(&dev.GetZoneAttributesArgs{})
+	if err != nil {
+		return "", err
+	}
+	return res.CurrentIcon, nil
+}
+
+// SetIcon changes the icon of the player's room.
+func (z *ZonePlayer) SetIcon(icon string) error {
+	return z.setZoneAttributes(func(args *dev.SetZoneAttributesArgs) {
+		args.DesiredIcon = icon
+	})
+}
+
+// LED reports whether the status light of the player is on.
+func (z *ZonePlayer) LED() (bool, error) {
+	res, err := z.DeviceProperties().GetLEDState(&dev.GetLEDStateArgs{})
+	if err != nil {
+		return false, err
+	}
+	return res.CurrentLEDState == "On", nil
+}
+
+// SetLED turns the status light of the player on or off.
+func (z *ZonePlayer) SetLED(on bool) error {
+	if err := z.checkAdministrable(); err != nil {
+		return err
+	}
+	if current, err := z.LED(); err != nil {
+		return err
+	} else if current == on {
+		return nil
+	}
+
+	_, err := z.DeviceProperties().SetLEDState(&dev.SetLEDStateArgs{
+		DesiredLEDState: onOff(on),
+	})
+	return err
+}
+
+// ButtonLock reports whether the buttons of the player are locked.
+func (z *ZonePlayer) ButtonLock() (bool, error) {
+	res, err := z.DeviceProperties().GetButtonLockState(&dev.GetButtonLockStateArgs{})
+	if err != nil {
+		return false, err
+	}
+	return res.CurrentButtonLockState == "On", nil
+}
+
+// SetButtonLock locks or unlocks the buttons of the player.
+func (z *ZonePlayer) SetButtonLock(locked bool) error {
+	if err := z.checkAdministrable(); err != nil {
+		return err
+	}
+	if current, err := z.ButtonLock(); err != nil {
+		return err
+	} else if current == locked {
+		return nil
+	}
+
+	_, err := z.DeviceProperties().SetButtonLockState(&dev.SetButtonLockStateArgs{
+		DesiredButtonLockState: onOff(locked),
+	})
+	return err
+}
+
+func onOff(b bool) string {
+	if b {
+		return "On"
+	}
+	return "Off"
+}
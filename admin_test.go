@@ -0,0 +1,53 @@
+package sonos
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRenameSharedRoot(t *testing.T) {
+	p := newTestPlayer(func(action, _ string) (string, error) {
+		if action == "GetZoneGroupState" {
+			return testZoneGroupState, nil
+		}
+		return "", nil
+	})
+	defer p.Close()
+
+	shared := &Root{Device: Device{UDN: "uuid:" + testPlayerUUID, RoomName: "Kitchen"}}
+	descriptions := NewMemoryDescriptionCache()
+	descriptions.Put(shared)
+	u, _ := url.Parse(p.URL + "/xml/device_description.xml")
+	zp, err := NewZonePlayer(WithLocation(u), WithDescriptionCache(descriptions, testPlayerUUID, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the cached description to be refreshed in the background,
+	// then share the refreshed one.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if root := zp.Root(); root != shared {
+			shared = root
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("description not refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := zp.Rename("Den"); err != nil {
+		t.Fatal(err)
+	}
+	if room := zp.RoomName(); room != "Den" {
+		t.Errorf("RoomName = %q, want Den", room)
+	}
+	if shared.Device.RoomName != "Kitchen" {
+		t.Errorf("shared description renamed to %q", shared.Device.RoomName)
+	}
+	if root, ok := descriptions.Get(testPlayerUUID, ""); !ok || root.Device.RoomName != "Den" {
+		t.Errorf("cached description %+v, want room Den", root)
+	}
+}
@@ -0,0 +1,359 @@
+package sonos
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	clk "github.com/caglar10ur/sonos/services/AlarmClock"
+)
+
+// DefaultAlarmURI is the program URI of the built-in alarm chime.
+const DefaultAlarmURI = "x-rincon-buzzer:0"
+
+// Recurrence is the set of weekdays an alarm goes off on. The zero value
+// means the alarm only goes off once.
+type Recurrence uint8
+
+const (
+	RecurrenceOnce     Recurrence = 0
+	RecurrenceDaily    Recurrence = 1<<7 - 1
+	RecurrenceWeekdays Recurrence = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+	RecurrenceWeekends Recurrence = 1<<time.Saturday | 1<<time.Sunday
+)
+
+// OnDays returns the recurrence for the given set of weekdays.
+func OnDays(days ...time.Weekday) Recurrence {
+	var r Recurrence
+	for _, d := range days {
+		r |= 1 << uint(d)
+	}
+	return r
+}
+
+// Has reports whether the alarm goes off on the given weekday.
+func (r Recurrence) Has(d time.Weekday) bool {
+	return r&(1<<uint(d)) != 0
+}
+
+// Days returns the weekdays of the recurrence, starting with Sunday.
+func (r Recurrence) Days() []time.Weekday {
+	var days []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if r.Has(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// String returns the recurrence as understood by the AlarmClock service:
+// ONCE, DAILY, WEEKDAYS, WEEKENDS or ON_ followed by the weekday digits
+// (0 is Sunday), e.g. ON_135.
+func (r Recurrence) String() string {
+	switch r {
+	case RecurrenceOnce:
+		return "ONCE"
+	case RecurrenceDaily:
+		return "DAILY"
+	case RecurrenceWeekdays:
+		return "WEEKDAYS"
+	case RecurrenceWeekends:
+		return "WEEKENDS"
+	}
+
+	var b strings.Builder
+	b.WriteString("ON_")
+	for _, d := range r.Days() {
+		fmt.Fprintf(&b, "%d", d)
+	}
+	return b.String()
+}
+
+// ParseRecurrence parses a recurrence as returned by the AlarmClock service.
+func ParseRecurrence(s string) (Recurrence, error) {
+	switch s {
+	case "ONCE":
+		return RecurrenceOnce, nil
+	case "DAILY":
+		return RecurrenceDaily, nil
+	case "WEEKDAYS":
+		return RecurrenceWeekdays, nil
+	case "WEEKENDS":
+		return RecurrenceWeekends, nil
+	}
+
+	if !strings.HasPrefix(s, "ON_") || len(s) == len("ON_") {
+		return 0, fmt.Errorf("invalid recurrence %q", s)
+	}
+
+	var r Recurrence
+	for _, c := range s[len("ON_"):] {
+		if c < '0' || c > '6' {
+			return 0, fmt.Errorf("invalid recurrence %q", s)
+		}
+		r |= 1 << uint(c-'0')
+	}
+	return r, nil
+}
+
+// Alarm is a household alarm.
+type Alarm struct {
+	ID uint32
+	// StartTime is the local time of day the alarm goes off at, as an
+	// offset from midnight.
+	StartTime time.Duration
+	// Duration is how long the alarm plays for; zero plays until stopped.
+	Duration   time.Duration
+	Recurrence Recurrence
+	Enabled    bool
+	// RoomUUID is the RINCON_ identifier of the room the alarm plays in.
+	RoomUUID string
+	// Room is the name of the room, resolved from RoomUUID when listing.
+	Room               string
+	ProgramURI         string
+	ProgramMetaData    string
+	PlayMode           PlayMode
+	Volume             int
+	IncludeLinkedZones bool
+}
+
+// alarmList is the document returned in CurrentAlarmList.
+type alarmList struct {
+	XMLName xml.Name `xml:"Alarms"`
+	Alarms  []struct {
+		ID                 uint32 `xml:"ID,attr"`
+		StartTime          string `xml:"StartTime,attr"`
+		Duration           string `xml:"Duration,attr"`
+		Recurrence         string `xml:"Recurrence,attr"`
+		Enabled            bool   `xml:"Enabled,attr"`
+		RoomUUID           string `xml:"RoomUUID,attr"`
+		ProgramURI         string `xml:"ProgramURI,attr"`
+		ProgramMetaData    string `xml:"ProgramMetaData,attr"`
+		PlayMode           string `xml:"PlayMode,attr"`
+		Volume             int    `xml:"Volume,attr"`
+		IncludeLinkedZones bool   `xml:"IncludeLinkedZones,attr"`
+	} `xml:"Alarm"`
+}
+
+// alarmCache holds the last alarm list together with its version so that it
+// is only parsed and resolved again once the version changes.
+type alarmCache struct {
+	mu      sync.Mutex
+	version string
+	alarms  []Alarm
+	valid   bool
+}
+
+func (c *alarmCache) handleVersion(version string) {
+	c.mu.Lock()
+	if version != c.version {
+		c.valid = false
+	}
+	c.mu.Unlock()
+}
+
+func (c *alarmCache) invalidate() {
+	c.mu.Lock()
+	c.valid = false
+	c.mu.Unlock()
+}
+
+// ParseAlarms parses the CurrentAlarmList document of ListAlarms.
+func ParseAlarms(raw string) ([]Alarm, error) {
+	var list alarmList
+	if err := xml.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, err
+	}
+
+	alarms := make([]Alarm, 0, len(list.Alarms))
+	for _, a := range list.Alarms {
+		start, err := ParseDuration(a.StartTime)
+		if err != nil {
+			return nil, err
+		}
+		duration, err := ParseDuration(a.Duration)
+		if err != nil {
+			return nil, err
+		}
+		recurrence, err := ParseRecurrence(a.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		alarms = append(alarms, Alarm{
+			ID:                 a.ID,
+			StartTime:          start,
+			Duration:           duration,
+			Recurrence:         recurrence,
+			Enabled:            a.Enabled,
+			RoomUUID:           a.RoomUUID,
+			ProgramURI:         a.ProgramURI,
+			ProgramMetaData:    a.ProgramMetaData,
+			PlayMode:           PlayMode(a.PlayMode),
+			Volume:             a.Volume,
+			IncludeLinkedZones: a.IncludeLinkedZones,
+		})
+	}
+	return alarms, nil
+}
+
+// Alarms lists the alarms of the household. The list is checked against the
+// CurrentAlarmListVersion of the player on every call, so changes made by
+// other controllers are seen without an event subscription; it is only
+// parsed and its rooms resolved again when the version changed.
+func (z *ZonePlayer) Alarms() ([]Alarm, error) {
+	z.alarms.mu.Lock()
+	defer z.alarms.mu.Unlock()
+
+	res, err := z.AlarmClock().ListAlarms(&clk.ListAlarmsArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if z.alarms.valid && res.CurrentAlarmListVersion == z.alarms.version {
+		return append([]Alarm(nil), z.alarms.alarms...), nil
+	}
+
+	alarms, err := ParseAlarms(res.CurrentAlarmList)
+	if err != nil {
+		return nil, err
+	}
+
+	if rooms, err := z.roomNames(); err == nil {
+		for i := range alarms {
+			alarms[i].Room = rooms[alarms[i].RoomUUID]
+		}
+	}
+
+	z.alarms.alarms = alarms
+	z.alarms.version = res.CurrentAlarmListVersion
+	z.alarms.valid = true
+
+	return append([]Alarm(nil), alarms...), nil
+}
+
+// Alarm returns the alarm with the given ID.
+func (z *ZonePlayer) Alarm(id uint32) (*Alarm, error) {
+	alarms, err := z.Alarms()
+	if err != nil {
+		return nil, err
+	}
+	for i := range alarms {
+		if alarms[i].ID == id {
+			return &alarms[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// CreateAlarm creates a new alarm and sets its ID. Unset fields default to
+// this player's room, the built-in chime and normal play mode. The volume is
+// clamped to 0-MaxVolume.
+func (z *ZonePlayer) CreateAlarm(a *Alarm) error {
+	z.alarmDefaults(a)
+
+	res, err := z.AlarmClock().CreateAlarm(&clk.CreateAlarmArgs{
+		StartLocalTime:     FormatDuration(a.StartTime),
+		Duration:           FormatDuration(a.Duration),
+		Recurrence:         a.Recurrence.String(),
+		Enabled:            a.Enabled,
+		RoomUUID:           a.RoomUUID,
+		ProgramURI:         a.ProgramURI,
+		ProgramMetaData:    a.ProgramMetaData,
+		PlayMode:           string(a.PlayMode),
+		Volume:             uint16(a.Volume),
+		IncludeLinkedZones: a.IncludeLinkedZones,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.ID = res.AssignedID
+	z.alarms.invalidate()
+	return nil
+}
+
+// UpdateAlarm replaces the alarm with the same ID.
+func (z *ZonePlayer) UpdateAlarm(a *Alarm) error {
+	if a.ID == 0 {
+		return errors.New("alarm has no ID")
+	}
+	z.alarmDefaults(a)
+
+	_, err := z.AlarmClock().UpdateAlarm(&clk.UpdateAlarmArgs{
+		ID:                 a.ID,
+		StartLocalTime:     FormatDuration(a.StartTime),
+		Duration:           FormatDuration(a.Duration),
+		Recurrence:         a.Recurrence.String(),
+		Enabled:            a.Enabled,
+		RoomUUID:           a.RoomUUID,
+		ProgramURI:         a.ProgramURI,
+		ProgramMetaData:    a.ProgramMetaData,
+		PlayMode:           string(a.PlayMode),
+		Volume:             uint16(a.Volume),
+		IncludeLinkedZones: a.IncludeLinkedZones,
+	})
+	if err != nil {
+		return err
+	}
+
+	z.alarms.invalidate()
+	return nil
+}
+
+// DeleteAlarm removes the alarm with the given ID.
+func (z *ZonePlayer) DeleteAlarm(id uint32) error {
+	_, err := z.AlarmClock().DestroyAlarm(&clk.DestroyAlarmArgs{
+		ID: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	z.alarms.invalidate()
+	return nil
+}
+
+// EnableAlarm enables or disables the alarm with the given ID.
+func (z *ZonePlayer) EnableAlarm(id uint32, enabled bool) error {
+	a, err := z.Alarm(id)
+	if err != nil {
+		return err
+	}
+	a.Enabled = enabled
+	return z.UpdateAlarm(a)
+}
+
+func (z *ZonePlayer) alarmDefaults(a *Alarm) {
+	if a.RoomUUID == "" {
+		a.RoomUUID = z.UUID()
+	}
+	if a.ProgramURI == "" {
+		a.ProgramURI = DefaultAlarmURI
+	}
+	if a.PlayMode == "" {
+		a.PlayMode = PlayModeNormal
+	}
+	a.Volume = clamp(a.Volume, 0, MaxVolume)
+}
+
+// roomNames maps the UUID of every player of the household to its room.
+func (z *ZonePlayer) roomNames() (map[string]string, error) {
+	zoneGroupState, err := z.GetZoneGroupState()
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make(map[string]string)
+	for _, group := range zoneGroupState.ZoneGroups {
+		for _, member := range group.ZoneGroupMember {
+			rooms[member.UUID] = member.ZoneName
+			for _, satellite := range member.Satellite {
+				rooms[satellite.UUID] = satellite.ZoneName
+			}
+		}
+	}
+	return rooms, nil
+}
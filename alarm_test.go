@@ -0,0 +1,235 @@
+package sonos
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecurrence(t *testing.T) {
+	for _, tt := range []struct {
+		r    Recurrence
+		s    string
+		days []time.Weekday
+	}{
+		{RecurrenceOnce, "ONCE", nil},
+		{RecurrenceDaily, "DAILY", []time.Weekday{0, 1, 2, 3, 4, 5, 6}},
+		{RecurrenceWeekdays, "WEEKDAYS", []time.Weekday{1, 2, 3, 4, 5}},
+		{RecurrenceWeekends, "WEEKENDS", []time.Weekday{0, 6}},
+		{OnDays(time.Monday, time.Wednesday, time.Friday), "ON_135", []time.Weekday{1, 3, 5}},
+		{OnDays(time.Sunday), "ON_0", []time.Weekday{0}},
+		{OnDays(time.Saturday, time.Tuesday), "ON_26", []time.Weekday{2, 6}},
+	} {
+		if s := tt.r.String(); s != tt.s {
+			t.Errorf("%v.String() = %q, want %q", tt.days, s, tt.s)
+		}
+		if days := tt.r.Days(); !reflect.DeepEqual(days, tt.days) {
+			t.Errorf("%s.Days() = %v, want %v", tt.s, days, tt.days)
+		}
+		r, err := ParseRecurrence(tt.s)
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q): %v", tt.s, err)
+		} else if r != tt.r {
+			t.Errorf("ParseRecurrence(%q) = %v, want %v", tt.s, r.Days(), tt.days)
+		}
+	}
+}
+
+func TestParseRecurrenceEquivalents(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want Recurrence
+	}{
+		{"ON_0123456", RecurrenceDaily},
+		{"ON_12345", RecurrenceWeekdays},
+		{"ON_60", RecurrenceWeekends},
+		{"ON_11", OnDays(time.Monday)},
+	} {
+		r, err := ParseRecurrence(tt.s)
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q): %v", tt.s, err)
+			continue
+		}
+		if r != tt.want {
+			t.Errorf("ParseRecurrence(%q) = %s, want %s", tt.s, r, tt.want)
+		}
+	}
+}
+
+func TestParseRecurrenceInvalid(t *testing.T) {
+	for _, s := range []string{"", "ON_", "ON_7", "ON_1a", "on_1", "MONTHLY"} {
+		if _, err := ParseRecurrence(s); err == nil {
+			t.Errorf("ParseRecurrence(%q) succeeded", s)
+		}
+	}
+}
+
+func TestParseAlarms(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		raw  string
+		want []Alarm
+		err  bool
+	}{
+		{
+			name: "empty",
+			raw:  `<Alarms></Alarms>`,
+			want: []Alarm{},
+		},
+		{
+			name: "alarms",
+			raw: `<Alarms>` +
+				`<Alarm ID="12" StartTime="07:30:00" Duration="01:00:00" Recurrence="WEEKDAYS" Enabled="1" ` +
+				`RoomUUID="RINCON_1" ProgramURI="x-rincon-buzzer:0" ProgramMetaData="" PlayMode="SHUFFLE" ` +
+				`Volume="25" IncludeLinkedZones="0"/>` +
+				`<Alarm ID="13" StartTime="22:05:09" Duration="00:15:00" Recurrence="ON_06" Enabled="0" ` +
+				`RoomUUID="RINCON_2" ProgramURI="x-sonosapi-stream:s1" ProgramMetaData="&lt;DIDL-Lite/&gt;" ` +
+				`PlayMode="NORMAL" Volume="40" IncludeLinkedZones="1"/>` +
+				`</Alarms>`,
+			want: []Alarm{
+				{
+					ID:         12,
+					StartTime:  7*time.Hour + 30*time.Minute,
+					Duration:   time.Hour,
+					Recurrence: RecurrenceWeekdays,
+					Enabled:    true,
+					RoomUUID:   "RINCON_1",
+					ProgramURI: DefaultAlarmURI,
+					PlayMode:   PlayModeShuffle,
+					Volume:     25,
+				},
+				{
+					ID:                 13,
+					StartTime:          22*time.Hour + 5*time.Minute + 9*time.Second,
+					Duration:           15 * time.Minute,
+					Recurrence:         RecurrenceWeekends,
+					RoomUUID:           "RINCON_2",
+					ProgramURI:         "x-sonosapi-stream:s1",
+					ProgramMetaData:    "<DIDL-Lite/>",
+					PlayMode:           PlayModeNormal,
+					Volume:             40,
+					IncludeLinkedZones: true,
+				},
+			},
+		},
+		{
+			name: "invalid start time",
+			raw:  `<Alarms><Alarm ID="1" StartTime="7:30" Duration="01:00:00" Recurrence="DAILY"/></Alarms>`,
+			err:  true,
+		},
+		{
+			name: "invalid recurrence",
+			raw:  `<Alarms><Alarm ID="1" StartTime="07:30:00" Duration="01:00:00" Recurrence="ON_9"/></Alarms>`,
+			err:  true,
+		},
+		{
+			name: "not a list",
+			raw:  `<Alarm/>`,
+			err:  true,
+		},
+	} {
+		alarms, err := ParseAlarms(tt.raw)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: ParseAlarms succeeded", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(alarms, tt.want) {
+			t.Errorf("%s: ParseAlarms = %+v, want %+v", tt.name, alarms, tt.want)
+		}
+	}
+}
+
+func TestAlarmsVersion(t *testing.T) {
+	var version, list string
+	p := newTestPlayer(func(action, _ string) (string, error) {
+		switch action {
+		case "ListAlarms":
+			return "<CurrentAlarmList>" + escapeXML(list) + "</CurrentAlarmList>" +
+				"<CurrentAlarmListVersion>" + version + "</CurrentAlarmListVersion>", nil
+		case "GetZoneGroupState":
+			return testZoneGroupState, nil
+		}
+		return "", nil
+	})
+	defer p.Close()
+	zp := p.zonePlayer(t)
+
+	const one = `<Alarms><Alarm ID="1" StartTime="07:00:00" Duration="01:00:00" Recurrence="DAILY" RoomUUID="RINCON_1"/></Alarms>`
+	const two = `<Alarms><Alarm ID="1" StartTime="07:00:00" Duration="01:00:00" Recurrence="DAILY" RoomUUID="RINCON_1"/>` +
+		`<Alarm ID="2" StartTime="08:00:00" Duration="01:00:00" Recurrence="ONCE" RoomUUID="RINCON_1"/></Alarms>`
+
+	for _, tt := range []struct {
+		name    string
+		version string
+		alarms  string
+		want    []uint32
+	}{
+		{"first list", "RINCON_1:1", one, []uint32{1}},
+		// The list changed without a version change, so the cached one is
+		// still returned.
+		{"same version", "RINCON_1:1", two, []uint32{1}},
+		// Another controller added an alarm; no event was received.
+		{"new version", "RINCON_1:2", two, []uint32{1, 2}},
+		{"removed", "RINCON_1:3", `<Alarms></Alarms>`, nil},
+	} {
+		p.mu.Lock()
+		version, list = tt.version, tt.alarms
+		p.mu.Unlock()
+		alarms, err := zp.Alarms()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var ids []uint32
+		for _, a := range alarms {
+			ids = append(ids, a.ID)
+			if a.Room != "Kitchen" {
+				t.Errorf("%s: alarm %d room %q, want Kitchen", tt.name, a.ID, a.Room)
+			}
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("%s: alarms %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+}
+
+func TestCreateAlarmVolume(t *testing.T) {
+	p := newTestPlayer(func(action, _ string) (string, error) {
+		if action == "CreateAlarm" {
+			return "<AssignedID>7</AssignedID>", nil
+		}
+		return "", nil
+	})
+	defer p.Close()
+	zp := p.zonePlayer(t)
+
+	for _, tt := range []struct {
+		volume int
+		want   int
+	}{
+		{20, 20},
+		{-5, 0},
+		{70000, MaxVolume},
+	} {
+		a := &Alarm{StartTime: 7 * time.Hour, Volume: tt.volume}
+		if err := zp.CreateAlarm(a); err != nil {
+			t.Fatal(err)
+		}
+		if a.Volume != tt.want || a.ID != 7 {
+			t.Errorf("volume %d: alarm %+v, want volume %d", tt.volume, a, tt.want)
+		}
+
+		requests := p.actions("CreateAlarm")
+		request := requests[len(requests)-1]
+		if want := fmt.Sprintf("<Volume>%d</Volume>", tt.want); !strings.Contains(request, want) {
+			t.Errorf("volume %d: request %s, want %s", tt.volume, request, want)
+		}
+	}
+}
@@ -0,0 +1,857 @@
+// Package api serves a JSON REST API for controlling a Sonos household, in
+// the spirit of node-sonos-http-api. Rooms are addressed by name, matched
+// the way sonos.FindRoom does. The API is described by the OpenAPI document
+// served at /openapi.json.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caglar10ur/sonos"
+)
+
+// DefaultTimeout bounds the discovery done to resolve a room.
+const DefaultTimeout = 5 * time.Second
+
+// Handler is the http.Handler of the API.
+type Handler struct {
+	sonos   *sonos.Sonos
+	timeout time.Duration
+	routes  []route
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithTimeout sets how long a request may wait for discovery to find the
+// room it addresses.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = d
+	}
+}
+
+// NewHandler returns the API for the players found by s. Mount it under a
+// prefix with http.StripPrefix.
+func NewHandler(s *sonos.Sonos, opts ...Option) *Handler {
+	h := &Handler{
+		sonos:   s,
+		timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.routes = []route{
+		{"GET", "/openapi.json", h.openAPI},
+		{"GET", "/rooms", h.listRooms},
+		{"GET", "/groups", h.listGroups},
+		{"GET", "/rooms/{room}", h.getRoom},
+		{"GET", "/rooms/{room}/now-playing", h.getNowPlaying},
+		{"POST", "/rooms/{room}/play", h.transport((*sonos.ZonePlayer).Play)},
+		{"POST", "/rooms/{room}/pause", h.transport((*sonos.ZonePlayer).Pause)},
+		{"POST", "/rooms/{room}/stop", h.transport((*sonos.ZonePlayer).Stop)},
+		{"POST", "/rooms/{room}/next", h.transport((*sonos.ZonePlayer).Next)},
+		{"POST", "/rooms/{room}/previous", h.transport((*sonos.ZonePlayer).Previous)},
+		{"PUT", "/rooms/{room}/play-mode", h.setPlayMode},
+		{"GET", "/rooms/{room}/volume", h.getVolume},
+		{"PUT", "/rooms/{room}/volume", h.setVolume},
+		{"GET", "/rooms/{room}/mute", h.getMute},
+		{"PUT", "/rooms/{room}/mute", h.setMute},
+		{"GET", "/rooms/{room}/group-volume", h.getGroupVolume},
+		{"PUT", "/rooms/{room}/group-volume", h.setGroupVolume},
+		{"POST", "/rooms/{room}/join", h.join},
+		{"POST", "/rooms/{room}/leave", h.leave},
+		{"GET", "/rooms/{room}/queue", h.getQueue},
+		{"DELETE", "/rooms/{room}/queue", h.clearQueue},
+		{"POST", "/rooms/{room}/queue/{index}/play", h.playFromQueue},
+		{"GET", "/rooms/{room}/favorites", h.listFavorites},
+		{"POST", "/rooms/{room}/favorites/{name}/play", h.playFavorite},
+		{"GET", "/alarms", h.listAlarms},
+		{"POST", "/alarms", h.createAlarm},
+		{"GET", "/alarms/{id}", h.getAlarm},
+		{"PUT", "/alarms/{id}", h.updateAlarm},
+		{"DELETE", "/alarms/{id}", h.deleteAlarm},
+	}
+	return h
+}
+
+// params are the values of the {name} segments of a route.
+type params map[string]string
+
+type route struct {
+	method  string
+	pattern string
+	handle  func(w http.ResponseWriter, r *http.Request, p params) error
+}
+
+// match reports whether path matches the route and returns its params. path
+// is the escaped path of the request so that an escaped slash in a room or
+// favorite name stays part of its segment; segments are unescaped after
+// splitting.
+func (rt *route) match(path string) (params, bool) {
+	want := strings.Split(strings.Trim(rt.pattern, "/"), "/")
+	have := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(have) {
+		return nil, false
+	}
+
+	p := make(params)
+	for i, segment := range want {
+		value, err := url.PathUnescape(have[i])
+		if err != nil {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, "{") {
+			p[strings.Trim(segment, "{}")] = value
+		} else if segment != value {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	allowed := false
+	for i := range h.routes {
+		rt := &h.routes[i]
+		p, ok := rt.match(r.URL.EscapedPath())
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = true
+			continue
+		}
+		if err := rt.handle(w, r, p); err != nil {
+			writeError(w, err)
+		}
+		return
+	}
+
+	if allowed {
+		writeError(w, &Error{
+			Status:  http.StatusMethodNotAllowed,
+			Code:    "method_not_allowed",
+			Message: r.Method + " is not allowed on " + r.URL.Path,
+		})
+		return
+	}
+	writeError(w, &Error{
+		Status:  http.StatusNotFound,
+		Code:    "not_found",
+		Message: r.URL.Path + " not found",
+	})
+}
+
+// player resolves the room of the request. Transport and queue actions go
+// to the coordinator of the group, rendering actions to the room itself.
+func (h *Handler) player(r *http.Request, p params, opts ...sonos.RoomOption) (*sonos.ZonePlayer, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+	return h.sonos.FindRoom(ctx, p["room"], opts...)
+}
+
+func (h *Handler) member(r *http.Request, p params) (*sonos.ZonePlayer, error) {
+	return h.player(r, p, sonos.WithRoomMember())
+}
+
+// household returns the first household found, searching if there is none
+// yet.
+func (h *Handler) household(r *http.Request) (*sonos.Household, error) {
+	if households := h.sonos.Households(); len(households) > 0 {
+		return households[0], nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	found := make(chan struct{}, 1)
+	err := h.sonos.Search(ctx, func(*sonos.Sonos, *sonos.ZonePlayer) {
+		select {
+		case found <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-found:
+			if households := h.sonos.Households(); len(households) > 0 {
+				return households[0], nil
+			}
+		}
+	}
+}
+
+// anyPlayer returns a player for household wide actions such as alarms.
+func (h *Handler) anyPlayer(r *http.Request) (*sonos.ZonePlayer, error) {
+	household, err := h.household(r)
+	if err != nil {
+		return nil, err
+	}
+	players := household.Players()
+	if len(players) == 0 {
+		return nil, sonos.ErrNotFound
+	}
+	return players[0], nil
+}
+
+// decode decodes the JSON body of r into v. Unknown fields are rejected so
+// that a misspelled field is not mistaken for a missing one.
+func decode(r *http.Request, v interface{}) error {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func noContent(w http.ResponseWriter) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *Handler) openAPI(w http.ResponseWriter, r *http.Request, p params) error {
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write([]byte(openAPIDocument))
+	return err
+}
+
+// Room is a room of the household.
+type Room struct {
+	Name          string `json:"name"`
+	UUID          string `json:"uuid"`
+	Icon          string `json:"icon"`
+	GroupID       string `json:"group_id"`
+	Coordinator   string `json:"coordinator"`
+	IsCoordinator bool   `json:"is_coordinator"`
+}
+
+// Group is a set of rooms playing in sync.
+type Group struct {
+	ID          string   `json:"id"`
+	Coordinator string   `json:"coordinator"`
+	Members     []string `json:"members"`
+}
+
+// topology returns the groups of the household, leaving out the invisible
+// members of bonded rooms.
+func (h *Handler) topology(r *http.Request) ([]sonos.ZoneGroup, error) {
+	household, err := h.household(r)
+	if err != nil {
+		return nil, err
+	}
+	zoneGroupState, err := household.ZoneGroupState()
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []sonos.ZoneGroup
+	for _, group := range zoneGroupState.ZoneGroups {
+		visible := group.ZoneGroupMember[:0:0]
+		for _, member := range group.ZoneGroupMember {
+			if member.Invisible != "1" {
+				visible = append(visible, member)
+			}
+		}
+		if len(visible) > 0 {
+			group.ZoneGroupMember = visible
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+func coordinatorName(group *sonos.ZoneGroup) string {
+	for _, member := range group.ZoneGroupMember {
+		if member.UUID == group.Coordinator {
+			return member.ZoneName
+		}
+	}
+	return ""
+}
+
+func (h *Handler) rooms(r *http.Request) ([]Room, error) {
+	groups, err := h.topology(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := []Room{}
+	for i := range groups {
+		group := &groups[i]
+		for _, member := range group.ZoneGroupMember {
+			rooms = append(rooms, Room{
+				Name:          member.ZoneName,
+				UUID:          member.UUID,
+				Icon:          member.Icon,
+				GroupID:       group.ID,
+				Coordinator:   coordinatorName(group),
+				IsCoordinator: member.UUID == group.Coordinator,
+			})
+		}
+	}
+	return rooms, nil
+}
+
+func (h *Handler) listRooms(w http.ResponseWriter, r *http.Request, p params) error {
+	rooms, err := h.rooms(r)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, rooms)
+	return nil
+}
+
+func (h *Handler) listGroups(w http.ResponseWriter, r *http.Request, p params) error {
+	zoneGroups, err := h.topology(r)
+	if err != nil {
+		return err
+	}
+
+	groups := []Group{}
+	for i := range zoneGroups {
+		group := &zoneGroups[i]
+		g := Group{ID: group.ID, Coordinator: coordinatorName(group), Members: []string{}}
+		for _, member := range group.ZoneGroupMember {
+			g.Members = append(g.Members, member.ZoneName)
+		}
+		groups = append(groups, g)
+	}
+	writeJSON(w, http.StatusOK, groups)
+	return nil
+}
+
+func (h *Handler) getRoom(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.member(r, p)
+	if err != nil {
+		return err
+	}
+	rooms, err := h.rooms(r)
+	if err != nil {
+		return err
+	}
+	for _, room := range rooms {
+		if room.UUID == zp.UUID() {
+			writeJSON(w, http.StatusOK, room)
+			return nil
+		}
+	}
+	return sonos.ErrRoomNotFound
+}
+
+// Media is a track, favorite or queue entry.
+type Media struct {
+	Title       string `json:"title"`
+	Artist      string `json:"artist,omitempty"`
+	Album       string `json:"album,omitempty"`
+	AlbumArtURI string `json:"album_art_uri,omitempty"`
+	URI         string `json:"uri,omitempty"`
+}
+
+func newMedia(m *sonos.MediaObject) Media {
+	return Media{
+		Title:       m.Title,
+		Artist:      m.Creator,
+		Album:       m.Album,
+		AlbumArtURI: m.AlbumArtURI,
+		URI:         m.URI,
+	}
+}
+
+// NowPlaying is the current track of a room. Position and Duration are in
+// seconds.
+type NowPlaying struct {
+	State       string `json:"state"`
+	PlayMode    string `json:"play_mode"`
+	TrackNumber int    `json:"track_number"`
+	Track       Media  `json:"track"`
+	Position    int    `json:"position"`
+	Duration    int    `json:"duration"`
+}
+
+func (h *Handler) getNowPlaying(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.player(r, p)
+	if err != nil {
+		return err
+	}
+	n, err := zp.NowPlaying()
+	if err != nil {
+		return err
+	}
+
+	track := newMedia(&n.Track)
+	track.URI = n.TrackURI
+	writeJSON(w, http.StatusOK, NowPlaying{
+		State:       n.TransportState,
+		PlayMode:    string(n.PlayMode),
+		TrackNumber: n.TrackNumber,
+		Track:       track,
+		Position:    int(n.Position / time.Second),
+		Duration:    int(n.Duration / time.Second),
+	})
+	return nil
+}
+
+func (h *Handler) transport(action func(*sonos.ZonePlayer) error) func(http.ResponseWriter, *http.Request, params) error {
+	return func(w http.ResponseWriter, r *http.Request, p params) error {
+		zp, err := h.player(r, p)
+		if err != nil {
+			return err
+		}
+		if err := action(zp); err != nil {
+			return err
+		}
+		return noContent(w)
+	}
+}
+
+func (h *Handler) setPlayMode(w http.ResponseWriter, r *http.Request, p params) error {
+	var body struct {
+		PlayMode string `json:"play_mode"`
+	}
+	if err := decode(r, &body); err != nil {
+		return err
+	}
+	zp, err := h.player(r, p)
+	if err != nil {
+		return err
+	}
+	if err := zp.SetPlayMode(sonos.PlayMode(strings.ToUpper(body.PlayMode))); err != nil {
+		return err
+	}
+	return noContent(w)
+}
+
+// Volume is the body of the volume endpoints. On PUT, exactly one of Volume
+// and Delta, which changes the volume relatively, must be set.
+type Volume struct {
+	Volume *int `json:"volume,omitempty"`
+	Delta  *int `json:"delta,omitempty"`
+}
+
+// check validates the body of a volume PUT.
+func (v *Volume) check() error {
+	if (v.Volume == nil) == (v.Delta == nil) {
+		return badRequest("exactly one of volume and delta is required")
+	}
+	return nil
+}
+
+func (h *Handler) getVolume(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.member(r, p)
+	if err != nil {
+		return err
+	}
+	volume, err := zp.GetVolume()
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, Volume{Volume: &volume})
+	return nil
+}
+
+func (h *Handler) setVolume(w http.ResponseWriter, r *http.Request, p params) error {
+	var body Volume
+	if err := decode(r, &body); err != nil {
+		return err
+	}
+	if err := body.check(); err != nil {
+		return err
+	}
+	zp, err := h.member(r, p)
+	if err != nil {
+		return err
+	}
+
+	if body.Delta != nil {
+		volume, err := zp.AdjustVolume(*body.Delta)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, Volume{Volume: &volume})
+		return nil
+	}
+	if err := zp.SetVolume(*body.Volume); err != nil {
+		return err
+	}
+	return h.getVolume(w, r, p)
+}
+
+// Mute is the body of the mute endpoints.
+type Mute struct {
+	Mute bool `json:"mute"`
+}
+
+func (h *Handler) getMute(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.member(r, p)
+	if err != nil {
+		return err
+	}
+	mute, err := zp.GetMute()
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, Mute{Mute: mute})
+	return nil
+}
+
+func (h *Handler) setMute(w http.ResponseWriter, r *http.Request, p params) error {
+	var body Mute
+	if err := decode(r, &body); err != nil {
+		return err
+	}
+	zp, err := h.member(r, p)
+	if err != nil {
+		return err
+	}
+	if err := zp.SetMute(body.Mute); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, body)
+	return nil
+}
+
+func (h *Handler) group(r *http.Request, p params) (*sonos.Group, error) {
+	zp, err := h.player(r, p)
+	if err != nil {
+		return nil, err
+	}
+	return zp.Group()
+}
+
+func (h *Handler) getGroupVolume(w http.ResponseWriter, r *http.Request, p params) error {
+	g, err := h.group(r, p)
+	if err != nil {
+		return err
+	}
+	volume, err := g.GroupVolume()
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, Volume{Volume: &volume})
+	return nil
+}
+
+func (h *Handler) setGroupVolume(w http.ResponseWriter, r *http.Request, p params) error {
+	var body Volume
+	if err := decode(r, &body); err != nil {
+		return err
+	}
+	if err := body.check(); err != nil {
+		return err
+	}
+	g, err := h.group(r, p)
+	if err != nil {
+		return err
+	}
+
+	if body.Delta != nil {
+		volume, err := g.AdjustGroupVolume(*body.Delta)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, Volume{Volume: &volume})
+		return nil
+	}
+	if err := g.SetGroupVolume(*body.Volume); err != nil {
+		return err
+	}
+	return h.getGroupVolume(w, r, p)
+}
+
+func (h *Handler) join(w http.ResponseWriter, r *http.Request, p params) error {
+	var body struct {
+		Room string `json:"room"`
+	}
+	if err := decode(r, &body); err != nil {
+		return err
+	}
+	if body.Room == "" {
+		return badRequest("room is required")
+	}
+
+	zp, err := h.member(r, p)
+	if err != nil {
+		return err
+	}
+	coordinator, err := h.player(r, params{"room": body.Room})
+	if err != nil {
+		return err
+	}
+	if err := zp.JoinGroup(coordinator); err != nil {
+		return err
+	}
+	return noContent(w)
+}
+
+func (h *Handler) leave(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.member(r, p)
+	if err != nil {
+		return err
+	}
+	if err := zp.LeaveGroup(); err != nil {
+		return err
+	}
+	return noContent(w)
+}
+
+func (h *Handler) getQueue(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.player(r, p)
+	if err != nil {
+		return err
+	}
+	tracks, err := zp.GetQueue().List(r.Context())
+	if err != nil {
+		return err
+	}
+
+	queue := make([]Media, 0, len(tracks))
+	for i := range tracks {
+		queue = append(queue, newMedia(&tracks[i]))
+	}
+	writeJSON(w, http.StatusOK, queue)
+	return nil
+}
+
+func (h *Handler) clearQueue(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.player(r, p)
+	if err != nil {
+		return err
+	}
+	if err := zp.GetQueue().Clear(); err != nil {
+		return err
+	}
+	return noContent(w)
+}
+
+func (h *Handler) playFromQueue(w http.ResponseWriter, r *http.Request, p params) error {
+	index, err := strconv.Atoi(p["index"])
+	if err != nil || index < 0 {
+		return badRequest("invalid queue index %q", p["index"])
+	}
+	zp, err := h.player(r, p)
+	if err != nil {
+		return err
+	}
+	if err := zp.GetQueue().PlayFrom(index); err != nil {
+		return err
+	}
+	return noContent(w)
+}
+
+func (h *Handler) listFavorites(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.player(r, p)
+	if err != nil {
+		return err
+	}
+	objects, err := zp.Favorites(r.Context())
+	if err != nil {
+		return err
+	}
+
+	favorites := make([]Media, 0, len(objects))
+	for i := range objects {
+		favorites = append(favorites, newMedia(&objects[i]))
+	}
+	writeJSON(w, http.StatusOK, favorites)
+	return nil
+}
+
+func (h *Handler) playFavorite(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.player(r, p)
+	if err != nil {
+		return err
+	}
+	if err := zp.PlayFavorite(r.Context(), p["name"]); err != nil {
+		return err
+	}
+	return noContent(w)
+}
+
+// Alarm is a household alarm. Times are formatted as HH:MM:SS and the
+// recurrence as understood by sonos.ParseRecurrence, e.g. WEEKDAYS.
+type Alarm struct {
+	ID                 uint32 `json:"id"`
+	StartTime          string `json:"start_time"`
+	Duration           string `json:"duration"`
+	Recurrence         string `json:"recurrence"`
+	Enabled            bool   `json:"enabled"`
+	Room               string `json:"room"`
+	ProgramURI         string `json:"program_uri,omitempty"`
+	PlayMode           string `json:"play_mode,omitempty"`
+	Volume             int    `json:"volume"`
+	IncludeLinkedZones bool   `json:"include_linked_zones"`
+}
+
+func newAlarm(a *sonos.Alarm) Alarm {
+	return Alarm{
+		ID:                 a.ID,
+		StartTime:          sonos.FormatDuration(a.StartTime),
+		Duration:           sonos.FormatDuration(a.Duration),
+		Recurrence:         a.Recurrence.String(),
+		Enabled:            a.Enabled,
+		Room:               a.Room,
+		ProgramURI:         a.ProgramURI,
+		PlayMode:           string(a.PlayMode),
+		Volume:             a.Volume,
+		IncludeLinkedZones: a.IncludeLinkedZones,
+	}
+}
+
+// alarm converts the body of an alarm request, resolving its room.
+func (h *Handler) alarm(r *http.Request, body *Alarm) (*sonos.Alarm, error) {
+	start, err := sonos.ParseDuration(body.StartTime)
+	if err != nil {
+		return nil, badRequest("start_time: %v", err)
+	}
+	duration, err := sonos.ParseDuration(body.Duration)
+	if err != nil {
+		return nil, badRequest("duration: %v", err)
+	}
+	recurrence := sonos.RecurrenceOnce
+	if body.Recurrence != "" {
+		if recurrence, err = sonos.ParseRecurrence(body.Recurrence); err != nil {
+			return nil, badRequest("recurrence: %v", err)
+		}
+	}
+	if body.Room == "" {
+		return nil, badRequest("room is required")
+	}
+	zp, err := h.member(r, params{"room": body.Room})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sonos.Alarm{
+		ID:                 body.ID,
+		StartTime:          start,
+		Duration:           duration,
+		Recurrence:         recurrence,
+		Enabled:            body.Enabled,
+		RoomUUID:           zp.UUID(),
+		Room:               zp.RoomName(),
+		ProgramURI:         body.ProgramURI,
+		PlayMode:           sonos.PlayMode(body.PlayMode),
+		Volume:             body.Volume,
+		IncludeLinkedZones: body.IncludeLinkedZones,
+	}, nil
+}
+
+func alarmID(p params) (uint32, error) {
+	id, err := strconv.ParseUint(p["id"], 10, 32)
+	if err != nil {
+		return 0, badRequest("invalid alarm id %q", p["id"])
+	}
+	return uint32(id), nil
+}
+
+func (h *Handler) listAlarms(w http.ResponseWriter, r *http.Request, p params) error {
+	zp, err := h.anyPlayer(r)
+	if err != nil {
+		return err
+	}
+	alarms, err := zp.Alarms()
+	if err != nil {
+		return err
+	}
+
+	list := make([]Alarm, 0, len(alarms))
+	for i := range alarms {
+		list = append(list, newAlarm(&alarms[i]))
+	}
+	writeJSON(w, http.StatusOK, list)
+	return nil
+}
+
+func (h *Handler) getAlarm(w http.ResponseWriter, r *http.Request, p params) error {
+	id, err := alarmID(p)
+	if err != nil {
+		return err
+	}
+	zp, err := h.anyPlayer(r)
+	if err != nil {
+		return err
+	}
+	a, err := zp.Alarm(id)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, newAlarm(a))
+	return nil
+}
+
+func (h *Handler) createAlarm(w http.ResponseWriter, r *http.Request, p params) error {
+	var body Alarm
+	if err := decode(r, &body); err != nil {
+		return err
+	}
+	a, err := h.alarm(r, &body)
+	if err != nil {
+		return err
+	}
+	zp, err := h.anyPlayer(r)
+	if err != nil {
+		return err
+	}
+	if err := zp.CreateAlarm(a); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, newAlarm(a))
+	return nil
+}
+
+func (h *Handler) updateAlarm(w http.ResponseWriter, r *http.Request, p params) error {
+	id, err := alarmID(p)
+	if err != nil {
+		return err
+	}
+	var body Alarm
+	if err := decode(r, &body); err != nil {
+		return err
+	}
+	body.ID = id
+	a, err := h.alarm(r, &body)
+	if err != nil {
+		return err
+	}
+	zp, err := h.anyPlayer(r)
+	if err != nil {
+		return err
+	}
+	if _, err := zp.Alarm(id); err != nil {
+		return err
+	}
+	if err := zp.UpdateAlarm(a); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, newAlarm(a))
+	return nil
+}
+
+func (h *Handler) deleteAlarm(w http.ResponseWriter, r *http.Request, p params) error {
+	id, err := alarmID(p)
+	if err != nil {
+		return err
+	}
+	zp, err := h.anyPlayer(r)
+	if err != nil {
+		return err
+	}
+	if err := zp.DeleteAlarm(id); err != nil {
+		return err
+	}
+	return noContent(w)
+}
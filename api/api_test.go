@@ -0,0 +1,295 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caglar10ur/sonos"
+)
+
+func TestRouteMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		target  string
+		want    params
+	}{
+		{"/rooms", "/rooms", params{}},
+		{"/rooms/{room}/volume", "/rooms/Kitchen/volume", params{"room": "Kitchen"}},
+		{"/rooms/{room}/volume", "/rooms/Living%20Room/volume", params{"room": "Living Room"}},
+		{"/rooms/{room}/volume", "/rooms/AC%2FDC/volume", params{"room": "AC/DC"}},
+		{"/rooms/{room}/favorites/{name}/play", "/rooms/Kitchen/favorites/Rock%2FPop%20Hits/play",
+			params{"room": "Kitchen", "name": "Rock/Pop Hits"}},
+		{"/rooms/{room}/volume", "/rooms/AC/DC/volume", nil},
+		{"/rooms/{room}/volume", "/rooms/Kitchen/mute", nil},
+		{"/rooms/{room}/volume", "/rooms/Kitchen/%76olume", params{"room": "Kitchen"}},
+		{"/alarms/{id}", "/alarms", nil},
+	} {
+		r := httptest.NewRequest("GET", tt.target, nil)
+		rt := &route{pattern: tt.pattern}
+		p, ok := rt.match(r.URL.EscapedPath())
+		if ok != (tt.want != nil) {
+			t.Errorf("%s %s: match = %v, want %v", tt.pattern, tt.target, ok, tt.want != nil)
+			continue
+		}
+		if ok && !reflect.DeepEqual(p, tt.want) {
+			t.Errorf("%s %s: params %v, want %v", tt.pattern, tt.target, p, tt.want)
+		}
+	}
+}
+
+const testUUID = "RINCON_000E58TEST01400"
+
+// fakePlayer is the only player of a household, Kitchen, served from a
+// loopback HTTP server. It keeps its volume and alarms, answers the actions
+// in faults with a UPnP fault of that code and records the actions it
+// receives.
+type fakePlayer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	volume  int
+	alarms  string
+	faults  map[string]int
+	actions []string
+}
+
+func newFakePlayer() *fakePlayer {
+	f := &fakePlayer{
+		volume: 20,
+		alarms: "<Alarms></Alarms>",
+		faults: make(map[string]int),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	return f
+}
+
+var (
+	desiredVolume = regexp.MustCompile(`<DesiredVolume>(\d+)</DesiredVolume>`)
+	startTime     = regexp.MustCompile(`<StartLocalTime>([^<]*)</StartLocalTime>`)
+)
+
+func (f *fakePlayer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method == http.MethodGet {
+		fmt.Fprintf(w, `<root xmlns="urn:schemas-upnp-org:device-1-0"><device>`+
+			`<modelNumber>S6</modelNumber><serialNum>00-0E-58-TE-ST-01:4</serialNum>`+
+			`<UDN>uuid:%s</UDN><roomName>Kitchen</roomName></device></root>`, testUUID)
+		return
+	}
+
+	soapAction := strings.Trim(r.Header.Get("SOAPAction"), `"`)
+	i := strings.LastIndex(soapAction, "#")
+	urn, action := soapAction[:i], soapAction[i+1:]
+	var body struct {
+		Inner string `xml:",innerxml"`
+	}
+	xml.NewDecoder(r.Body).Decode(&body)
+	f.actions = append(f.actions, action+" "+body.Inner)
+
+	if code, ok := f.faults[action]; ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>`+
+			`<faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`+
+			`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode></UPnPError>`+
+			`</detail></s:Fault></s:Body></s:Envelope>`, code)
+		return
+	}
+
+	var result string
+	switch action {
+	case "GetZoneGroupState":
+		result = "<ZoneGroupState>" + escape(fmt.Sprintf(`<ZoneGroupState><ZoneGroups>`+
+			`<ZoneGroup Coordinator="%s" ID="%s:1"><ZoneGroupMember UUID="%s" ZoneName="Kitchen"/>`+
+			`</ZoneGroup></ZoneGroups></ZoneGroupState>`, testUUID, testUUID, testUUID)) + "</ZoneGroupState>"
+	case "GetHouseholdID":
+		result = "<CurrentHouseholdID>Sonos_1</CurrentHouseholdID>"
+	case "GetVolume":
+		result = fmt.Sprintf("<CurrentVolume>%d</CurrentVolume>", f.volume)
+	case "SetVolume":
+		fmt.Sscan(desiredVolume.FindStringSubmatch(body.Inner)[1], &f.volume)
+	case "ListAlarms":
+		result = "<CurrentAlarmList>" + escape(f.alarms) + "</CurrentAlarmList>" +
+			"<CurrentAlarmListVersion>RINCON_1:1</CurrentAlarmListVersion>"
+	case "CreateAlarm":
+		f.alarms = fmt.Sprintf(`<Alarms><Alarm ID="7" StartTime="%s" Duration="01:00:00" Recurrence="DAILY" `+
+			`Enabled="1" RoomUUID="%s" ProgramURI="x-rincon-buzzer:0" PlayMode="NORMAL" Volume="20"/></Alarms>`,
+			startTime.FindStringSubmatch(body.Inner)[1], testUUID)
+		result = "<AssignedID>7</AssignedID>"
+	}
+	fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+		`<u:%sResponse xmlns:u="%s">%s</u:%sResponse></s:Body></s:Envelope>`, action, urn, result, action)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// lastAction returns the last request received for action.
+func (f *fakePlayer) lastAction(action string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.actions) - 1; i >= 0; i-- {
+		if strings.HasPrefix(f.actions[i], action+" ") {
+			return f.actions[i]
+		}
+	}
+	return ""
+}
+
+// testAPI is the API of a household made of a fakePlayer. Discovery is
+// disabled, so rooms that are not found fail after the timeout.
+type testAPI struct {
+	*Handler
+	player *fakePlayer
+	sonos  *sonos.Sonos
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	f := newFakePlayer()
+	s, err := sonos.NewSonos(sonos.WithDiscovery(0))
+	if err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(f.URL + "/xml/device_description.xml")
+	zp, err := sonos.NewZonePlayer(sonos.WithLocation(u))
+	if err == nil {
+		err = s.Register(zp)
+	}
+	if err != nil {
+		s.Close()
+		f.Close()
+		t.Fatal(err)
+	}
+	return &testAPI{
+		Handler: NewHandler(s, WithTimeout(50*time.Millisecond)),
+		player:  f,
+		sonos:   s,
+	}
+}
+
+func (a *testAPI) close() {
+	a.sonos.Close()
+	a.player.Close()
+}
+
+// do serves a request and decodes the JSON response into v, if not nil.
+func (a *testAPI) do(t *testing.T, method, target, body string, v interface{}) int {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+	if v != nil && w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Errorf("%s %s: %v: %s", method, target, err, w.Body)
+		}
+	}
+	return w.Code
+}
+
+func TestAlarmRequests(t *testing.T) {
+	a := newTestAPI(t)
+	defer a.close()
+
+	for _, tt := range []struct {
+		method string
+		target string
+		body   string
+		status int
+		code   string
+		id     uint32
+	}{
+		{"GET", "/alarms/7", "", http.StatusNotFound, "not_found", 0},
+		{"POST", "/alarms", `{"start_time": "07:30:00", "duration": "01:00:00", "recurrence": "DAILY", "room": "kitchen", "enabled": true}`,
+			http.StatusCreated, "", 7},
+		{"GET", "/alarms/7", "", http.StatusOK, "", 7},
+		{"GET", "/alarms/seven", "", http.StatusBadRequest, "invalid_request", 0},
+		{"POST", "/alarms", `{"start_time": "7:30", "duration": "01:00:00", "room": "kitchen"}`, http.StatusBadRequest, "invalid_request", 0},
+		{"POST", "/alarms", `{"start_time": "07:30:00", "duration": "01:00:00", "recurrence": "HOURLY", "room": "kitchen"}`,
+			http.StatusBadRequest, "invalid_request", 0},
+		{"POST", "/alarms", `{"start_time": "07:30:00", "duration": "01:00:00"}`, http.StatusBadRequest, "invalid_request", 0},
+		{"POST", "/alarms", `{"start_time": "07:30:00", "duration": "01:00:00", "room": "attic"}`, http.StatusNotFound, "room_not_found", 0},
+	} {
+		var body struct {
+			Alarm
+			Error Error `json:"error"`
+		}
+		status := a.do(t, tt.method, tt.target, tt.body, &body)
+		name := tt.method + " " + tt.target + " " + tt.body
+		if status != tt.status || body.Error.Code != tt.code {
+			t.Errorf("%s: %d %q, want %d %q", name, status, body.Error.Code, tt.status, tt.code)
+			continue
+		}
+		if tt.code != "" {
+			continue
+		}
+		if body.ID != tt.id || body.Room != "Kitchen" || body.StartTime != "07:30:00" || body.Recurrence != "DAILY" || !body.Enabled {
+			t.Errorf("%s: alarm %+v", name, body.Alarm)
+		}
+	}
+
+	if request := a.player.lastAction("CreateAlarm"); !strings.Contains(request, "<StartLocalTime>07:30:00</StartLocalTime>") ||
+		!strings.Contains(request, "<RoomUUID>"+testUUID+"</RoomUUID>") {
+		t.Errorf("CreateAlarm request %s", request)
+	}
+
+	var list []Alarm
+	if status := a.do(t, "GET", "/alarms", "", &list); status != http.StatusOK || len(list) != 1 || list[0].ID != 7 {
+		t.Errorf("GET /alarms: %d %+v", status, list)
+	}
+}
+
+func TestVolumeRequests(t *testing.T) {
+	for _, tt := range []struct {
+		target string
+		body   string
+		status int
+		volume int
+	}{
+		{"/rooms/kitchen/volume", `{"volume": 30}`, http.StatusOK, 30},
+		{"/rooms/kitchen/volume", `{"volume": 0}`, http.StatusOK, 0},
+		{"/rooms/kitchen/volume", `{"delta": -5}`, http.StatusOK, 15},
+		{"/rooms/kitchen/volume", `{}`, http.StatusBadRequest, 20},
+		{"/rooms/kitchen/volume", `{"volum": 30}`, http.StatusBadRequest, 20},
+		{"/rooms/kitchen/volume", `{"volume": 30, "delta": 5}`, http.StatusBadRequest, 20},
+		{"/rooms/kitchen/volume", `30`, http.StatusBadRequest, 20},
+		{"/rooms/kitchen/group-volume", `{}`, http.StatusBadRequest, 20},
+		{"/rooms/kitchen/group-volume", `{"volume": 30, "mute": true}`, http.StatusBadRequest, 20},
+	} {
+		a := newTestAPI(t)
+		var body struct {
+			Volume
+			Error Error `json:"error"`
+		}
+		status := a.do(t, "PUT", tt.target, tt.body, &body)
+		a.player.mu.Lock()
+		volume := a.player.volume
+		a.player.mu.Unlock()
+		groupSet := a.player.lastAction("SetGroupVolume") != ""
+		a.close()
+
+		if status != tt.status {
+			t.Errorf("%s %s: status %d, want %d: %+v", tt.target, tt.body, status, tt.status, body.Error)
+		}
+		if volume != tt.volume || groupSet {
+			t.Errorf("%s %s: player volume %d, want %d", tt.target, tt.body, volume, tt.volume)
+		}
+		if status == http.StatusOK && (body.Volume.Volume == nil || *body.Volume.Volume != tt.volume) {
+			t.Errorf("%s %s: response %+v, want volume %d", tt.target, tt.body, body.Volume, tt.volume)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/caglar10ur/sonos"
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+	dir "github.com/caglar10ur/sonos/services/ContentDirectory"
+)
+
+// Error is the body of every error response:
+//
+//	{"error": {"status": 409, "code": "transition_not_available", ...}}
+type Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// UPnPCode and UPnPDescription are set when the player returned a
+	// fault.
+	UPnPCode        int    `json:"upnp_code,omitempty"`
+	UPnPDescription string `json:"upnp_description,omitempty"`
+	// Matches lists the rooms an ambiguous room name matched.
+	Matches []string `json:"matches,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// badRequest returns a 400 error for a malformed request.
+func badRequest(format string, args ...interface{}) *Error {
+	return &Error{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_request",
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// upnpFault describes how a UPnP error code is reported.
+type upnpFault struct {
+	status      int
+	code        string
+	description string
+}
+
+// upnpFaults are the error codes of the UPnP Device Architecture, common to
+// every service.
+var upnpFaults = map[int]upnpFault{
+	401: {http.StatusNotImplemented, "invalid_action", "Invalid Action"},
+	402: {http.StatusBadRequest, "invalid_args", "Invalid Args"},
+	501: {http.StatusBadGateway, "action_failed", "Action Failed"},
+	600: {http.StatusBadRequest, "argument_value_invalid", "Argument Value Invalid"},
+	601: {http.StatusBadRequest, "argument_value_out_of_range", "Argument Value Out of Range"},
+	602: {http.StatusNotImplemented, "optional_action_not_implemented", "Optional Action Not Implemented"},
+	603: {http.StatusServiceUnavailable, "out_of_memory", "Out of Memory"},
+	604: {http.StatusConflict, "human_intervention_required", "Human Intervention Required"},
+	605: {http.StatusBadRequest, "string_argument_too_long", "String Argument Too Long"},
+}
+
+// avTransportFaults are the error codes of the AVTransport service. Codes
+// from 700 on are defined by each service.
+var avTransportFaults = map[int]upnpFault{
+	701: {http.StatusConflict, "transition_not_available", "Transition not available"},
+	702: {http.StatusConflict, "no_contents", "No contents"},
+	703: {http.StatusBadGateway, "read_error", "Read error"},
+	704: {http.StatusUnsupportedMediaType, "format_not_supported", "Format not supported for playback"},
+	705: {http.StatusConflict, "transport_locked", "Transport is locked"},
+	706: {http.StatusBadGateway, "write_error", "Write error"},
+	707: {http.StatusForbidden, "media_protected", "Media is protected or not writeable"},
+	709: {http.StatusInsufficientStorage, "media_full", "Media is full"},
+	710: {http.StatusBadRequest, "seek_mode_not_supported", "Seek mode not supported"},
+	711: {http.StatusBadRequest, "illegal_seek_target", "Illegal seek target"},
+	712: {http.StatusBadRequest, "play_mode_not_supported", "Play mode not supported"},
+	714: {http.StatusUnsupportedMediaType, "illegal_mime_type", "Illegal MIME-type"},
+	715: {http.StatusConflict, "content_busy", "Content 'BUSY'"},
+	716: {http.StatusNotFound, "resource_not_found", "Resource not found"},
+	717: {http.StatusBadRequest, "play_speed_not_supported", "Play speed not supported"},
+	718: {http.StatusNotFound, "invalid_instance_id", "Invalid InstanceID"},
+}
+
+// contentDirectoryFaults are the error codes of the ContentDirectory
+// service.
+var contentDirectoryFaults = map[int]upnpFault{
+	701: {http.StatusNotFound, "no_such_object", "No such object"},
+	708: {http.StatusBadRequest, "unsupported_search_criteria", "Unsupported or invalid search criteria"},
+	709: {http.StatusBadRequest, "unsupported_sort_criteria", "Unsupported or invalid sort criteria"},
+	710: {http.StatusNotFound, "no_such_container", "No such container"},
+	720: {http.StatusBadGateway, "cannot_process_request", "Cannot process the request"},
+}
+
+// serviceFaults returns the error codes of the service that returned the
+// fault err is or wraps, if known.
+func serviceFaults(err error) map[int]upnpFault {
+	var avtFault *avt.UPnPError
+	var dirFault *dir.UPnPError
+	switch {
+	case errors.As(err, &avtFault):
+		return avTransportFaults
+	case errors.As(err, &dirFault):
+		return contentDirectoryFaults
+	}
+	return nil
+}
+
+// toError maps err to the error reported to the client.
+func toError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	e := &Error{
+		Status:  http.StatusBadGateway,
+		Code:    "player_error",
+		Message: err.Error(),
+	}
+
+	var ambiguous *sonos.AmbiguousRoomError
+	if code, ok := sonos.UPnPErrorCode(err); ok {
+		e.UPnPCode = code
+		e.Code = "upnp_error"
+		f, ok := upnpFaults[code]
+		if !ok {
+			f, ok = serviceFaults(err)[code]
+		}
+		if ok {
+			e.Status = f.status
+			e.Code = f.code
+			e.UPnPDescription = f.description
+		}
+	} else if errors.As(err, &ambiguous) {
+		e.Status = http.StatusConflict
+		e.Code = "ambiguous_room"
+		e.Matches = ambiguous.Matches
+	} else if errors.Is(err, sonos.ErrRoomNotFound) {
+		e.Status = http.StatusNotFound
+		e.Code = "room_not_found"
+	} else if errors.Is(err, sonos.ErrNotFound) {
+		e.Status = http.StatusNotFound
+		e.Code = "not_found"
+	} else if errors.Is(err, sonos.ErrNotSupported) {
+		e.Status = http.StatusUnprocessableEntity
+		e.Code = "not_supported"
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		e.Status = http.StatusGatewayTimeout
+		e.Code = "timeout"
+	}
+	return e
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e := toError(err)
+	writeJSON(w, e.Status, struct {
+		Error *Error `json:"error"`
+	}{e})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
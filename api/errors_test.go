@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestErrorResponses(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		method string
+		target string
+		fault  string
+		code   int
+		status int
+		want   Error
+	}{
+		{
+			name: "AVTransport fault", method: "POST", target: "/rooms/kitchen/play",
+			fault: "Play", code: 701, status: http.StatusConflict,
+			want: Error{Code: "transition_not_available", UPnPCode: 701, UPnPDescription: "Transition not available"},
+		},
+		{
+			name: "ContentDirectory fault", method: "GET", target: "/rooms/kitchen/favorites",
+			fault: "Browse", code: 701, status: http.StatusNotFound,
+			want: Error{Code: "no_such_object", UPnPCode: 701, UPnPDescription: "No such object"},
+		},
+		{
+			name: "generic fault", method: "GET", target: "/rooms/kitchen/volume",
+			fault: "GetVolume", code: 402, status: http.StatusBadRequest,
+			want: Error{Code: "invalid_args", UPnPCode: 402, UPnPDescription: "Invalid Args"},
+		},
+		{
+			name: "RenderingControl fault", method: "GET", target: "/rooms/kitchen/volume",
+			fault: "GetVolume", code: 701, status: http.StatusBadGateway,
+			want: Error{Code: "upnp_error", UPnPCode: 701},
+		},
+		{
+			name: "unknown room", method: "GET", target: "/rooms/attic/volume",
+			status: http.StatusNotFound, want: Error{Code: "room_not_found"},
+		},
+		{
+			name: "unknown route", method: "GET", target: "/rooms/kitchen/lights",
+			status: http.StatusNotFound, want: Error{Code: "not_found"},
+		},
+		{
+			name: "wrong method", method: "DELETE", target: "/rooms/kitchen/volume",
+			status: http.StatusMethodNotAllowed, want: Error{Code: "method_not_allowed"},
+		},
+	} {
+		a := newTestAPI(t)
+		if tt.fault != "" {
+			a.player.mu.Lock()
+			a.player.faults[tt.fault] = tt.code
+			a.player.mu.Unlock()
+		}
+
+		var body struct {
+			Error Error `json:"error"`
+		}
+		status := a.do(t, tt.method, tt.target, "", &body)
+		a.close()
+
+		if status != tt.status || body.Error.Status != tt.status {
+			t.Errorf("%s: status %d, body status %d, want %d", tt.name, status, body.Error.Status, tt.status)
+		}
+		got := body.Error
+		got.Status, got.Message = 0, ""
+		if got.Code != tt.want.Code || got.UPnPCode != tt.want.UPnPCode || got.UPnPDescription != tt.want.UPnPDescription {
+			t.Errorf("%s: error %+v, want %+v", tt.name, got, tt.want)
+		}
+		if body.Error.Message == "" {
+			t.Errorf("%s: no message", tt.name)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package api
+
+// openAPIDocument is the OpenAPI 3.0 description of the API, served at
+// /openapi.json. Keep it in sync with the routes of NewHandler.
+const openAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "Sonos REST API",
+    "version": "1.0.0",
+    "description": "Controls the rooms of a Sonos household. Rooms are addressed by name, case-insensitively; a unique partial name is accepted."
+  },
+  "paths": {
+    "/events": {
+      "get": {
+        "summary": "Stream changes as server-sent events, or over WebSocket when the request asks for an upgrade",
+        "description": "Every connection starts with a snapshot event per room and a topology event. Events carry a sequence number per connection; a gap means events were dropped and the client should reconnect. Served by cmd/sonos-api, see package stream.",
+        "parameters": [{"name": "room", "in": "query", "required": false, "description": "Only stream events of these rooms", "schema": {"type": "array", "items": {"type": "string"}}, "explode": true}],
+        "responses": {
+          "200": {"description": "Event stream", "content": {"text/event-stream": {"schema": {"type": "string"}}}},
+          "101": {"description": "Switching to WebSocket"}
+        }
+      }
+    },
+    "/rooms": {
+      "get": {
+        "summary": "List the rooms of the household",
+        "responses": {
+          "200": {"description": "Rooms", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Room"}}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/groups": {
+      "get": {
+        "summary": "List the groups of the household",
+        "responses": {
+          "200": {"description": "Groups", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Group"}}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/rooms/{room}": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "get": {
+        "summary": "Get a room",
+        "responses": {
+          "200": {"description": "Room", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Room"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/rooms/{room}/now-playing": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "get": {
+        "summary": "Get the current track of the group of the room",
+        "responses": {
+          "200": {"description": "Current track", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/NowPlaying"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/rooms/{room}/play": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "post": {"summary": "Start playback", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/rooms/{room}/pause": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "post": {"summary": "Pause playback", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/rooms/{room}/stop": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "post": {"summary": "Stop playback", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/rooms/{room}/next": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "post": {"summary": "Skip to the next track", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/rooms/{room}/previous": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "post": {"summary": "Go back to the previous track", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/rooms/{room}/play-mode": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "put": {
+        "summary": "Set the repeat and shuffle mode",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PlayMode"}}}},
+        "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}
+      }
+    },
+    "/rooms/{room}/volume": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "get": {
+        "summary": "Get the volume of the room",
+        "responses": {
+          "200": {"description": "Volume", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Volume"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "put": {
+        "summary": "Set the volume of the room, or change it by delta",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Volume"}}}},
+        "responses": {
+          "200": {"description": "New volume", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Volume"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/rooms/{room}/mute": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "get": {
+        "summary": "Get the mute state of the room",
+        "responses": {
+          "200": {"description": "Mute state", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Mute"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "put": {
+        "summary": "Mute or unmute the room",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Mute"}}}},
+        "responses": {
+          "200": {"description": "Mute state", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Mute"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/rooms/{room}/group-volume": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "get": {
+        "summary": "Get the volume of the group of the room",
+        "responses": {
+          "200": {"description": "Volume", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Volume"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "put": {
+        "summary": "Set the volume of the group, or change it by delta",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Volume"}}}},
+        "responses": {
+          "200": {"description": "New volume", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Volume"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/rooms/{room}/join": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "post": {
+        "summary": "Add the room to the group of another room",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"type": "object", "required": ["room"], "properties": {"room": {"type": "string"}}}}}},
+        "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}
+      }
+    },
+    "/rooms/{room}/leave": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "post": {"summary": "Take the room out of its group", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/rooms/{room}/queue": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "get": {
+        "summary": "List the queue",
+        "responses": {
+          "200": {"description": "Queue", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Media"}}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "delete": {"summary": "Clear the queue", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/rooms/{room}/queue/{index}/play": {
+      "parameters": [
+        {"$ref": "#/components/parameters/Room"},
+        {"name": "index", "in": "path", "required": true, "description": "Position in the queue, starting at 0", "schema": {"type": "integer", "minimum": 0}}
+      ],
+      "post": {"summary": "Play the queue from a track", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/rooms/{room}/favorites": {
+      "parameters": [{"$ref": "#/components/parameters/Room"}],
+      "get": {
+        "summary": "List the Sonos favorites",
+        "responses": {
+          "200": {"description": "Favorites", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Media"}}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/rooms/{room}/favorites/{name}/play": {
+      "parameters": [
+        {"$ref": "#/components/parameters/Room"},
+        {"name": "name", "in": "path", "required": true, "schema": {"type": "string"}}
+      ],
+      "post": {"summary": "Play a Sonos favorite", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    },
+    "/alarms": {
+      "get": {
+        "summary": "List the alarms of the household",
+        "responses": {
+          "200": {"description": "Alarms", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Alarm"}}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "post": {
+        "summary": "Create an alarm",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Alarm"}}}},
+        "responses": {
+          "201": {"description": "Created alarm", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Alarm"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/alarms/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+      "get": {
+        "summary": "Get an alarm",
+        "responses": {
+          "200": {"description": "Alarm", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Alarm"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "put": {
+        "summary": "Replace an alarm",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Alarm"}}}},
+        "responses": {
+          "200": {"description": "Updated alarm", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Alarm"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "delete": {"summary": "Delete an alarm", "responses": {"204": {"description": "Done"}, "default": {"$ref": "#/components/responses/Error"}}}
+    }
+  },
+  "components": {
+    "parameters": {
+      "Room": {"name": "room", "in": "path", "required": true, "description": "Room name", "schema": {"type": "string"}}
+    },
+    "responses": {
+      "Error": {"description": "Error", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+    },
+    "schemas": {
+      "Room": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "uuid": {"type": "string"},
+          "icon": {"type": "string"},
+          "group_id": {"type": "string"},
+          "coordinator": {"type": "string", "description": "Name of the coordinator of the group"},
+          "is_coordinator": {"type": "boolean"}
+        }
+      },
+      "Group": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "coordinator": {"type": "string"},
+          "members": {"type": "array", "items": {"type": "string"}}
+        }
+      },
+      "Media": {
+        "type": "object",
+        "properties": {
+          "title": {"type": "string"},
+          "artist": {"type": "string"},
+          "album": {"type": "string"},
+          "album_art_uri": {"type": "string"},
+          "uri": {"type": "string"}
+        }
+      },
+      "NowPlaying": {
+        "type": "object",
+        "properties": {
+          "state": {"type": "string", "enum": ["PLAYING", "PAUSED_PLAYBACK", "STOPPED", "TRANSITIONING"]},
+          "play_mode": {"type": "string"},
+          "track_number": {"type": "integer"},
+          "track": {"$ref": "#/components/schemas/Media"},
+          "position": {"type": "integer", "description": "Seconds"},
+          "duration": {"type": "integer", "description": "Seconds"}
+        }
+      },
+      "PlayMode": {
+        "type": "object",
+        "required": ["play_mode"],
+        "properties": {
+          "play_mode": {"type": "string", "enum": ["NORMAL", "REPEAT_ALL", "REPEAT_ONE", "SHUFFLE_NOREPEAT", "SHUFFLE", "SHUFFLE_REPEAT_ONE"]}
+        }
+      },
+      "Volume": {
+        "type": "object",
+        "properties": {
+          "volume": {"type": "integer", "minimum": 0, "maximum": 100},
+          "delta": {"type": "integer", "description": "Relative change; a PUT sets exactly one of volume and delta"}
+        }
+      },
+      "Mute": {
+        "type": "object",
+        "required": ["mute"],
+        "properties": {"mute": {"type": "boolean"}}
+      },
+      "Alarm": {
+        "type": "object",
+        "required": ["start_time", "room"],
+        "properties": {
+          "id": {"type": "integer", "readOnly": true},
+          "start_time": {"type": "string", "example": "07:30:00"},
+          "duration": {"type": "string", "example": "01:00:00"},
+          "recurrence": {"type": "string", "example": "WEEKDAYS", "description": "ONCE, DAILY, WEEKDAYS, WEEKENDS or ON_ followed by weekday digits, 0 being Sunday"},
+          "enabled": {"type": "boolean"},
+          "room": {"type": "string"},
+          "program_uri": {"type": "string"},
+          "play_mode": {"type": "string"},
+          "volume": {"type": "integer", "minimum": 0, "maximum": 100},
+          "include_linked_zones": {"type": "boolean"}
+        }
+      },
+      "Error": {
+        "type": "object",
+        "properties": {
+          "error": {
+            "type": "object",
+            "properties": {
+              "status": {"type": "integer"},
+              "code": {"type": "string", "example": "transition_not_available"},
+              "message": {"type": "string"},
+              "upnp_code": {"type": "integer", "description": "UPnP error code returned by the player"},
+              "upnp_description": {"type": "string"},
+              "matches": {"type": "array", "items": {"type": "string"}, "description": "Rooms an ambiguous name matched"}
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,301 @@
+package sonos
+
+import (
+	"errors"
+
+	ren "github.com/caglar10ur/sonos/services/RenderingControl"
+)
+
+// EQType values understood by RenderingControl GetEQ and SetEQ.
+const (
+	EQDialogLevel        = "DialogLevel"
+	EQNightMode          = "NightMode"
+	EQSubGain            = "SubGain"
+	EQSubEnabled         = "SubEnabled"
+	EQSurroundLevel      = "SurroundLevel"
+	EQSurroundEnable     = "SurroundEnable"
+	EQHeightChannelLevel = "HeightChannelLevel"
+	EQSurroundMode       = "SurroundMode"
+)
+
+// SurroundMode values of the SurroundMode EQ.
+const (
+	SurroundModeAmbient = 0
+	SurroundModeFull    = 1
+)
+
+// ErrNotSupported is returned when a setting or action is not available on
+// the player.
+var ErrNotSupported = errors.New("not supported by this player")
+
+// AudioSettings are the sound settings of a player. A nil field is either
+// not supported by the player (when read) or left unchanged (when applied).
+type AudioSettings struct {
+	// Bass and Treble range from -10 to 10.
+	Bass   *int
+	Treble *int
+
+	Loudness *bool
+
+	// Balance ranges from -100 (left only) to 100 (right only) and is
+	// derived from the LF and RF channel volumes.
+	Balance *int
+
+	// Home theater settings.
+	DialogLevel     *bool
+	NightMode       *bool
+	SubEnabled      *bool
+	SubGain         *int // -15 to 15
+	SurroundEnabled *bool
+	SurroundLevel   *int // -15 to 15
+	SurroundMode    *int // SurroundModeAmbient or SurroundModeFull
+	// HeightChannelLevel ranges from -10 to 10 on products with up-firing
+	// speakers.
+	HeightChannelLevel *int
+}
+
+// Int returns a pointer to v, for building AudioSettings.
+func Int(v int) *int {
+	return &v
+}
+
+// Bool returns a pointer to v, for building AudioSettings.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// audioCapabilities are the optional settings a player supports.
+type audioCapabilities struct {
+	homeTheater bool
+	sub         bool
+	surrounds   bool
+}
+
+func (z *ZonePlayer) audioCapabilities() (*audioCapabilities, error) {
+	tv, err := z.HasTV()
+	if err != nil {
+		return nil, err
+	}
+	member, err := z.zoneGroupMember()
+	if err != nil {
+		return nil, err
+	}
+
+	// A Sub bonded to a room without a TV input is part of its channel map
+	// rather than of the home theater one.
+	bonded, err := ParseChannelMap(member.ChannelMapSet)
+	if err != nil {
+		return nil, err
+	}
+	caps := &audioCapabilities{
+		homeTheater: tv,
+		sub:         bonded.Has(ChannelSubwoofer),
+	}
+	if !caps.homeTheater {
+		return caps, nil
+	}
+
+	m, err := ParseChannelMap(member.HTSatChanMapSet)
+	if err != nil {
+		return nil, err
+	}
+	caps.sub = caps.sub || m.Has(ChannelSubwoofer)
+	caps.surrounds = m.Has(ChannelLeftRear) || m.Has(ChannelRightRear)
+	return caps, nil
+}
+
+// GetAudioSettings reads every sound setting the player supports.
+func (z *ZonePlayer) GetAudioSettings() (*AudioSettings, error) {
+	caps, err := z.audioCapabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &AudioSettings{}
+
+	bass, err := z.RenderingControl().GetBass(&ren.GetBassArgs{})
+	if err != nil {
+		return nil, err
+	}
+	s.Bass = Int(int(bass.CurrentBass))
+
+	treble, err := z.RenderingControl().GetTreble(&ren.GetTrebleArgs{})
+	if err != nil {
+		return nil, err
+	}
+	s.Treble = Int(int(treble.CurrentTreble))
+
+	loudness, err := z.RenderingControl().GetLoudness(&ren.GetLoudnessArgs{Channel: "Master"})
+	if err != nil {
+		return nil, err
+	}
+	s.Loudness = Bool(loudness.CurrentLoudness)
+
+	left, err := z.RenderingControl().GetVolume(&ren.GetVolumeArgs{Channel: "LF"})
+	if err != nil {
+		return nil, err
+	}
+	right, err := z.RenderingControl().GetVolume(&ren.GetVolumeArgs{Channel: "RF"})
+	if err != nil {
+		return nil, err
+	}
+	s.Balance = Int(int(right.CurrentVolume) - int(left.CurrentVolume))
+
+	if caps.homeTheater {
+		if s.DialogLevel, err = z.eqBool(EQDialogLevel); err != nil {
+			return nil, err
+		}
+		if s.NightMode, err = z.eqBool(EQNightMode); err != nil {
+			return nil, err
+		}
+		// Only some home theater products have up-firing speakers.
+		s.HeightChannelLevel, _ = z.eqInt(EQHeightChannelLevel)
+	}
+	if caps.sub {
+		if s.SubEnabled, err = z.eqBool(EQSubEnabled); err != nil {
+			return nil, err
+		}
+		if s.SubGain, err = z.eqInt(EQSubGain); err != nil {
+			return nil, err
+		}
+	}
+	if caps.surrounds {
+		if s.SurroundEnabled, err = z.eqBool(EQSurroundEnable); err != nil {
+			return nil, err
+		}
+		if s.SurroundLevel, err = z.eqInt(EQSurroundLevel); err != nil {
+			return nil, err
+		}
+		s.SurroundMode, _ = z.eqInt(EQSurroundMode)
+	}
+
+	return s, nil
+}
+
+// ApplyAudioSettings changes the settings that are set in s and differ from
+// the current ones. ErrNotSupported is returned without changing anything
+// if s contains a setting the player does not support.
+func (z *ZonePlayer) ApplyAudioSettings(s AudioSettings) error {
+	current, err := z.GetAudioSettings()
+	if err != nil {
+		return err
+	}
+
+	type change struct {
+		want, have interface{}
+		apply      func() error
+	}
+	changes := []change{
+		{s.Bass, current.Bass, func() error {
+			_, err := z.RenderingControl().SetBass(&ren.SetBassArgs{DesiredBass: int16(clamp(*s.Bass, -10, 10))})
+			return err
+		}},
+		{s.Treble, current.Treble, func() error {
+			_, err := z.RenderingControl().SetTreble(&ren.SetTrebleArgs{DesiredTreble: int16(clamp(*s.Treble, -10, 10))})
+			return err
+		}},
+		{s.Loudness, current.Loudness, func() error {
+			_, err := z.RenderingControl().SetLoudness(&ren.SetLoudnessArgs{Channel: "Master", DesiredLoudness: *s.Loudness})
+			return err
+		}},
+		{s.Balance, current.Balance, func() error { return z.setBalance(*s.Balance) }},
+		{s.DialogLevel, current.DialogLevel, func() error { return z.setEQBool(EQDialogLevel, *s.DialogLevel) }},
+		{s.NightMode, current.NightMode, func() error { return z.setEQBool(EQNightMode, *s.NightMode) }},
+		{s.SubEnabled, current.SubEnabled, func() error { return z.setEQBool(EQSubEnabled, *s.SubEnabled) }},
+		{s.SubGain, current.SubGain, func() error { return z.setEQInt(EQSubGain, clamp(*s.SubGain, -15, 15)) }},
+		{s.SurroundEnabled, current.SurroundEnabled, func() error { return z.setEQBool(EQSurroundEnable, *s.SurroundEnabled) }},
+		{s.SurroundLevel, current.SurroundLevel, func() error { return z.setEQInt(EQSurroundLevel, clamp(*s.SurroundLevel, -15, 15)) }},
+		{s.SurroundMode, current.SurroundMode, func() error { return z.setEQInt(EQSurroundMode, *s.SurroundMode) }},
+		{s.HeightChannelLevel, current.HeightChannelLevel, func() error {
+			return z.setEQInt(EQHeightChannelLevel, clamp(*s.HeightChannelLevel, -10, 10))
+		}},
+	}
+
+	var pending []func() error
+	for _, c := range changes {
+		if isNilPtr(c.want) {
+			continue
+		}
+		if isNilPtr(c.have) {
+			return ErrNotSupported
+		}
+		if !equalPtr(c.want, c.have) {
+			pending = append(pending, c.apply)
+		}
+	}
+
+	for _, apply := range pending {
+		if err := apply(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isNilPtr(v interface{}) bool {
+	switch p := v.(type) {
+	case *int:
+		return p == nil
+	case *bool:
+		return p == nil
+	}
+	return true
+}
+
+func equalPtr(a, b interface{}) bool {
+	switch pa := a.(type) {
+	case *int:
+		return *pa == *b.(*int)
+	case *bool:
+		return *pa == *b.(*bool)
+	}
+	return false
+}
+
+func (z *ZonePlayer) setBalance(balance int) error {
+	balance = clamp(balance, -100, 100)
+	left, right := 100, 100
+	if balance < 0 {
+		right += balance
+	} else {
+		left -= balance
+	}
+
+	if _, err := z.RenderingControl().SetVolume(&ren.SetVolumeArgs{Channel: "LF", DesiredVolume: uint16(left)}); err != nil {
+		return err
+	}
+	_, err := z.RenderingControl().SetVolume(&ren.SetVolumeArgs{Channel: "RF", DesiredVolume: uint16(right)})
+	return err
+}
+
+func (z *ZonePlayer) eqInt(eqType string) (*int, error) {
+	res, err := z.RenderingControl().GetEQ(&ren.GetEQArgs{EQType: eqType})
+	if err != nil {
+		return nil, err
+	}
+	return Int(int(res.CurrentValue)), nil
+}
+
+func (z *ZonePlayer) eqBool(eqType string) (*bool, error) {
+	v, err := z.eqInt(eqType)
+	if err != nil {
+		return nil, err
+	}
+	return Bool(*v != 0), nil
+}
+
+func (z *ZonePlayer) setEQInt(eqType string, value int) error {
+	_, err := z.RenderingControl().SetEQ(&ren.SetEQArgs{
+		EQType:       eqType,
+		DesiredValue: int16(value),
+	})
+	return err
+}
+
+func (z *ZonePlayer) setEQBool(eqType string, value bool) error {
+	v := 0
+	if value {
+		v = 1
+	}
+	return z.setEQInt(eqType, v)
+}
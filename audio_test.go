@@ -0,0 +1,71 @@
+package sonos
+
+import (
+	"testing"
+)
+
+func TestAudioSettingsSub(t *testing.T) {
+	tests := []struct {
+		name      string
+		htAudioIn string
+		member    string
+		sub       bool
+		surrounds bool
+	}{
+		{
+			name: "no sub",
+		},
+		{
+			name:   "sub bonded to a room without a tv input",
+			member: `ChannelMapSet="RINCON_1:LF,RF;RINCON_S:SW,SW"`,
+			sub:    true,
+		},
+		{
+			name:      "home theater without satellites",
+			htAudioIn: "1",
+		},
+		{
+			name:      "home theater with a sub",
+			htAudioIn: "1",
+			member:    `HTSatChanMapSet="RINCON_1:LF,RF;RINCON_S:SW"`,
+			sub:       true,
+		},
+		{
+			name:      "home theater with surrounds",
+			htAudioIn: "1",
+			member:    `HTSatChanMapSet="RINCON_1:LF,RF;RINCON_L:LR;RINCON_R:RR"`,
+			surrounds: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topology := "<ZoneGroupState>" + escapeXML(`<ZoneGroupState><ZoneGroups>`+
+				`<ZoneGroup Coordinator="`+testPlayerUUID+`"><ZoneGroupMember UUID="`+testPlayerUUID+`" ZoneName="Kitchen" `+tt.member+`/>`+
+				`</ZoneGroup></ZoneGroups></ZoneGroupState>`) + "</ZoneGroupState>"
+			p := newTestPlayer(func(action, args string) (string, error) {
+				switch action {
+				case "GetZoneInfo":
+					return "<HTAudioIn>" + tt.htAudioIn + "</HTAudioIn>", nil
+				case "GetZoneGroupState":
+					return topology, nil
+				case "GetEQ":
+					return "<CurrentValue>1</CurrentValue>", nil
+				}
+				return "", nil
+			})
+			defer p.Close()
+
+			s, err := p.zonePlayer(t).GetAudioSettings()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.SubEnabled != nil && s.SubGain != nil; got != tt.sub {
+				t.Errorf("sub settings reported = %v, want %v", got, tt.sub)
+			}
+			if got := s.SurroundEnabled != nil && s.SurroundLevel != nil; got != tt.surrounds {
+				t.Errorf("surround settings reported = %v, want %v", got, tt.surrounds)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package sonos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dev "github.com/caglar10ur/sonos/services/DeviceProperties"
+)
+
+// Channel is the role of a speaker in a bonded room.
+type Channel string
+
+const (
+	ChannelLeftFront  Channel = "LF"
+	ChannelRightFront Channel = "RF"
+	ChannelSubwoofer  Channel = "SW"
+	ChannelLeftRear   Channel = "LR"
+	ChannelRightRear  Channel = "RR"
+)
+
+const (
+	// bondTimeout is how long to wait for a bond change to show up in the
+	// zone group topology.
+	bondTimeout = 10 * time.Second
+	// bondPollInterval is the interval between two topology checks.
+	bondPollInterval = 500 * time.Millisecond
+)
+
+var (
+	// ErrAlreadyBonded is returned when a player is already part of a
+	// stereo pair or home theater setup.
+	ErrAlreadyBonded = errors.New("player is already bonded")
+	// ErrNotBonded is returned when removing a bond that does not exist.
+	ErrNotBonded = errors.New("player is not bonded")
+)
+
+// ChannelAssignment assigns channels to a player. Bonded speakers take a
+// channel per input channel, e.g. LF,LF for the left speaker of a stereo
+// pair; home theater satellites take a single one.
+type ChannelAssignment struct {
+	UUID     string
+	Channels []Channel
+}
+
+// ChannelMap is a parsed ChannelMapSet or HTSatChanMapSet, e.g.
+// RINCON_A:LF,LF;RINCON_B:RF,RF.
+type ChannelMap []ChannelAssignment
+
+// ParseChannelMap parses a ChannelMapSet or HTSatChanMapSet. An empty
+// string yields an empty map.
+func ParseChannelMap(s string) (ChannelMap, error) {
+	var m ChannelMap
+	if s == "" {
+		return m, nil
+	}
+	for _, part := range strings.Split(s, ";") {
+		i := strings.Index(part, ":")
+		if i <= 0 || i == len(part)-1 {
+			return nil, fmt.Errorf("invalid channel map %q", s)
+		}
+		a := ChannelAssignment{UUID: part[:i]}
+		for _, c := range strings.Split(part[i+1:], ",") {
+			a.Channels = append(a.Channels, Channel(c))
+		}
+		m = append(m, a)
+	}
+	return m, nil
+}
+
+// String returns the map in the format used by DeviceProperties.
+func (m ChannelMap) String() string {
+	parts := make([]string, 0, len(m))
+	for _, a := range m {
+		channels := make([]string, 0, len(a.Channels))
+		for _, c := range a.Channels {
+			channels = append(channels, string(c))
+		}
+		parts = append(parts, a.UUID+":"+strings.Join(channels, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Channels returns the channels assigned to the player with the given UUID.
+func (m ChannelMap) Channels(uuid string) []Channel {
+	for _, a := range m {
+		if a.UUID == uuid {
+			return a.Channels
+		}
+	}
+	return nil
+}
+
+// Has reports whether any player is assigned channel c.
+func (m ChannelMap) Has(c Channel) bool {
+	for _, a := range m {
+		for _, channel := range a.Channels {
+			if channel == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Contains reports whether the player with the given UUID is part of the
+// map.
+func (m ChannelMap) Contains(uuid string) bool {
+	return m.Channels(uuid) != nil
+}
+
+// isSub reports whether the assignment is the one of a bonded Sub.
+func (a ChannelAssignment) isSub() bool {
+	return len(a.Channels) > 0 && a.Channels[0] == ChannelSubwoofer
+}
+
+// member returns the zone group member with the given UUID.
+func (s *ZoneGroupState) member(uuid string) *ZoneGroupMember {
+	for i := range s.ZoneGroups {
+		for j := range s.ZoneGroups[i].ZoneGroupMember {
+			if m := &s.ZoneGroups[i].ZoneGroupMember[j]; m.UUID == uuid {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// isSatellite reports whether the player with the given UUID is a satellite
+// of a home theater room.
+func (s *ZoneGroupState) isSatellite(uuid string) bool {
+	for _, group := range s.ZoneGroups {
+		for _, member := range group.ZoneGroupMember {
+			for _, satellite := range member.Satellite {
+				if satellite.UUID == uuid {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isBonded reports whether the player with the given UUID is part of a
+// stereo pair or home theater setup.
+func (s *ZoneGroupState) isBonded(uuid string) bool {
+	if s.isSatellite(uuid) {
+		return true
+	}
+	m := s.member(uuid)
+	return m != nil && (m.ChannelMapSet != "" || m.HTSatChanMapSet != "" || m.Invisible == "1")
+}
+
+// ChannelMap returns the stereo pair or bonded sub channel map of the
+// player's room; it is empty if the room is not bonded.
+func (z *ZonePlayer) ChannelMap() (ChannelMap, error) {
+	member, err := z.zoneGroupMember()
+	if err != nil {
+		return nil, err
+	}
+	return ParseChannelMap(member.ChannelMapSet)
+}
+
+// HTChannelMap returns the home theater channel map of the player's room;
+// it is empty if no satellites are attached.
+func (z *ZonePlayer) HTChannelMap() (ChannelMap, error) {
+	member, err := z.zoneGroupMember()
+	if err != nil {
+		return nil, err
+	}
+	return ParseChannelMap(member.HTSatChanMapSet)
+}
+
+func (z *ZonePlayer) zoneGroupMember() (*ZoneGroupMember, error) {
+	zoneGroupState, err := z.GetZoneGroupState()
+	if err != nil {
+		return nil, err
+	}
+	member := zoneGroupState.member(z.UUID())
+	if member == nil {
+		return nil, errors.New("player is not part of the zone group topology")
+	}
+	return member, nil
+}
+
+// checkUnbonded returns ErrAlreadyBonded if any of the players is bonded.
+func (z *ZonePlayer) checkUnbonded(players ...*ZonePlayer) error {
+	zoneGroupState, err := z.GetZoneGroupState()
+	if err != nil {
+		return err
+	}
+	for _, p := range players {
+		if zoneGroupState.isBonded(p.UUID()) {
+			return fmt.Errorf("%s: %w", p.RoomName(), ErrAlreadyBonded)
+		}
+	}
+	return nil
+}
+
+// waitForBond polls the zone group topology until done reports that the
+// player's room reached the expected state.
+func (z *ZonePlayer) waitForBond(done func(*ZoneGroupState, *ZoneGroupMember) bool) error {
+	deadline := time.Now().Add(bondTimeout)
+	for {
+		zoneGroupState, err := z.GetZoneGroupState()
+		if err != nil {
+			return err
+		}
+		if member := zoneGroupState.member(z.UUID()); member != nil && done(zoneGroupState, member) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("bond change not reflected in zone group topology")
+		}
+		time.Sleep(bondPollInterval)
+	}
+}
+
+// CreateStereoPair pairs the player, as the left speaker, with right. Both
+// players must be the same model and not bonded yet.
+func (z *ZonePlayer) CreateStereoPair(right *ZonePlayer) error {
+	if right.UUID() == z.UUID() {
+		return errors.New("cannot pair a player with itself")
+	}
+	if right.ModelName() != z.ModelName() {
+		return fmt.Errorf("cannot pair %s with %s", z.ModelName(), right.ModelName())
+	}
+	if err := z.checkUnbonded(z, right); err != nil {
+		return err
+	}
+
+	m := ChannelMap{
+		{UUID: z.UUID(), Channels: []Channel{ChannelLeftFront, ChannelLeftFront}},
+		{UUID: right.UUID(), Channels: []Channel{ChannelRightFront, ChannelRightFront}},
+	}
+	_, err := z.DeviceProperties().CreateStereoPair(&dev.CreateStereoPairArgs{
+		ChannelMapSet: m.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return z.waitForBond(func(_ *ZoneGroupState, member *ZoneGroupMember) bool {
+		m, err := ParseChannelMap(member.ChannelMapSet)
+		return err == nil && m.Contains(right.UUID())
+	})
+}
+
+// SeparateStereoPair splits the stereo pair of the player's room. A Sub
+// bonded to the room is removed first.
+func (z *ZonePlayer) SeparateStereoPair() error {
+	m, err := z.ChannelMap()
+	if err != nil {
+		return err
+	}
+	var pair ChannelMap
+	var sub *ChannelAssignment
+	for i, a := range m {
+		if a.isSub() {
+			sub = &m[i]
+		} else {
+			pair = append(pair, a)
+		}
+	}
+	if len(pair) < 2 {
+		return ErrNotBonded
+	}
+	if sub != nil {
+		if err := z.removeBondedSub(*sub); err != nil {
+			return err
+		}
+	}
+
+	_, err = z.DeviceProperties().SeparateStereoPair(&dev.SeparateStereoPairArgs{
+		ChannelMapSet: pair.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return z.waitForBond(func(_ *ZoneGroupState, member *ZoneGroupMember) bool {
+		return member.ChannelMapSet == ""
+	})
+}
+
+// AddSub attaches sub to the player's room. On home theater products it is
+// added as a satellite, otherwise it is bonded to the room.
+func (z *ZonePlayer) AddSub(sub *ZonePlayer) error {
+	if err := checkSub(sub); err != nil {
+		return err
+	}
+	if err := z.checkUnbonded(sub); err != nil {
+		return err
+	}
+
+	tv, err := z.HasTV()
+	if err != nil {
+		return err
+	}
+	if tv {
+		return z.addSatellites(ChannelAssignment{UUID: sub.UUID(), Channels: []Channel{ChannelSubwoofer}})
+	}
+
+	m, err := z.ChannelMap()
+	if err != nil {
+		return err
+	}
+	if m.Has(ChannelSubwoofer) {
+		return fmt.Errorf("%s: %w", z.RoomName(), ErrAlreadyBonded)
+	}
+	if len(m) == 0 {
+		m = ChannelMap{{UUID: z.UUID(), Channels: []Channel{ChannelLeftFront, ChannelRightFront}}}
+	}
+	m = append(m, ChannelAssignment{UUID: sub.UUID(), Channels: []Channel{ChannelSubwoofer, ChannelSubwoofer}})
+
+	_, err = z.DeviceProperties().AddBondedZones(&dev.AddBondedZonesArgs{
+		ChannelMapSet: m.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return z.waitForBond(func(_ *ZoneGroupState, member *ZoneGroupMember) bool {
+		m, err := ParseChannelMap(member.ChannelMapSet)
+		return err == nil && m.Contains(sub.UUID())
+	})
+}
+
+// checkSub returns an error unless zp is a Sub. Models missing from the
+// catalogue, such as newer Subs, are recognised by the display name of their
+// device description; ErrNotSupported is returned for other unknown models.
+func checkSub(zp *ZonePlayer) error {
+	if model, ok := LookupModel(zp.ModelNumber()); ok {
+		if !model.Subwoofer {
+			return fmt.Errorf("%s is not a Sub", model.Name)
+		}
+		return nil
+	}
+	if name := zp.device().DisplayName; name == "Sub" || strings.HasPrefix(name, "Sub ") {
+		return nil
+	}
+	return fmt.Errorf("cannot tell whether model %s is a Sub: %w", zp.ModelNumber(), ErrNotSupported)
+}
+
+// AddSurrounds attaches left and right as the rear surrounds of the home
+// theater player. Both must be the same model and not bonded yet.
+func (z *ZonePlayer) AddSurrounds(left, right *ZonePlayer) error {
+	if left.UUID() == right.UUID() {
+		return errors.New("surrounds must be two different players")
+	}
+	if left.ModelName() != right.ModelName() {
+		return fmt.Errorf("cannot use %s and %s as surrounds", left.ModelName(), right.ModelName())
+	}
+	if err := z.checkUnbonded(left, right); err != nil {
+		return err
+	}
+
+	return z.addSatellites(
+		ChannelAssignment{UUID: left.UUID(), Channels: []Channel{ChannelLeftRear}},
+		ChannelAssignment{UUID: right.UUID(), Channels: []Channel{ChannelRightRear}},
+	)
+}
+
+func (z *ZonePlayer) addSatellites(satellites ...ChannelAssignment) error {
+	tv, err := z.HasTV()
+	if err != nil {
+		return err
+	}
+	if !tv {
+		return ErrNotSupported
+	}
+
+	m, err := z.HTChannelMap()
+	if err != nil {
+		return err
+	}
+	if len(m) == 0 {
+		m = ChannelMap{{UUID: z.UUID(), Channels: []Channel{ChannelLeftFront, ChannelRightFront}}}
+	}
+	for _, s := range satellites {
+		for _, c := range s.Channels {
+			if m.Has(c) {
+				return fmt.Errorf("%s: channel %s: %w", z.RoomName(), c, ErrAlreadyBonded)
+			}
+		}
+		m = append(m, s)
+	}
+
+	_, err = z.DeviceProperties().AddHTSatellite(&dev.AddHTSatelliteArgs{
+		HTSatChanMapSet: m.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return z.waitForBond(func(zoneGroupState *ZoneGroupState, _ *ZoneGroupMember) bool {
+		for _, s := range satellites {
+			if !zoneGroupState.isSatellite(s.UUID) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// RemoveSatellite detaches a Sub or surround speaker from the player's room.
+func (z *ZonePlayer) RemoveSatellite(satellite *ZonePlayer) error {
+	bonded, err := z.ChannelMap()
+	if err != nil {
+		return err
+	}
+	if a := (ChannelAssignment{UUID: satellite.UUID(), Channels: bonded.Channels(satellite.UUID())}); a.isSub() {
+		// A Sub bonded to a room without a home theater input.
+		return z.removeBondedSub(a)
+	}
+
+	m, err := z.HTChannelMap()
+	if err != nil {
+		return err
+	}
+	if !m.Contains(satellite.UUID()) {
+		return ErrNotBonded
+	}
+
+	_, err = z.DeviceProperties().RemoveHTSatellite(&dev.RemoveHTSatelliteArgs{
+		SatRoomUUID: satellite.UUID(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return z.waitForBond(func(zoneGroupState *ZoneGroupState, _ *ZoneGroupMember) bool {
+		return !zoneGroupState.isSatellite(satellite.UUID())
+	})
+}
+
+// removeBondedSub detaches the Sub bonded to a room without a home theater
+// input.
+func (z *ZonePlayer) removeBondedSub(sub ChannelAssignment) error {
+	_, err := z.DeviceProperties().RemoveBondedZones(&dev.RemoveBondedZonesArgs{
+		ChannelMapSet: ChannelMap{sub}.String(),
+	})
+	if err != nil {
+		return err
+	}
+	return z.waitForBond(func(_ *ZoneGroupState, member *ZoneGroupMember) bool {
+		m, err := ParseChannelMap(member.ChannelMapSet)
+		return err == nil && !m.Contains(sub.UUID)
+	})
+}
@@ -0,0 +1,223 @@
+package sonos
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseChannelMap(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want ChannelMap
+	}{
+		{"", nil},
+		{"RINCON_A:LF,LF;RINCON_B:RF,RF", ChannelMap{
+			{UUID: "RINCON_A", Channels: []Channel{ChannelLeftFront, ChannelLeftFront}},
+			{UUID: "RINCON_B", Channels: []Channel{ChannelRightFront, ChannelRightFront}},
+		}},
+		{"RINCON_A:LF,RF;RINCON_S:SW,SW", ChannelMap{
+			{UUID: "RINCON_A", Channels: []Channel{ChannelLeftFront, ChannelRightFront}},
+			{UUID: "RINCON_S", Channels: []Channel{ChannelSubwoofer, ChannelSubwoofer}},
+		}},
+		{"RINCON_T:LF,RF;RINCON_L:LR;RINCON_R:RR", ChannelMap{
+			{UUID: "RINCON_T", Channels: []Channel{ChannelLeftFront, ChannelRightFront}},
+			{UUID: "RINCON_L", Channels: []Channel{ChannelLeftRear}},
+			{UUID: "RINCON_R", Channels: []Channel{ChannelRightRear}},
+		}},
+	} {
+		m, err := ParseChannelMap(tt.in)
+		if err != nil {
+			t.Errorf("ParseChannelMap(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(m, tt.want) {
+			t.Errorf("ParseChannelMap(%q) = %v, want %v", tt.in, m, tt.want)
+		}
+		if s := m.String(); s != tt.in {
+			t.Errorf("ParseChannelMap(%q).String() = %q", tt.in, s)
+		}
+	}
+}
+
+func TestParseChannelMapInvalid(t *testing.T) {
+	for _, s := range []string{"RINCON_A", ":LF", "RINCON_A:", "RINCON_A:LF;", ";RINCON_A:LF"} {
+		if _, err := ParseChannelMap(s); err == nil {
+			t.Errorf("ParseChannelMap(%q) succeeded", s)
+		}
+	}
+}
+
+func TestChannelMapLookups(t *testing.T) {
+	m, err := ParseChannelMap("RINCON_A:LF,LF;RINCON_B:RF,RF;RINCON_S:SW,SW")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		uuid     string
+		channels []Channel
+		sub      bool
+	}{
+		{"RINCON_A", []Channel{ChannelLeftFront, ChannelLeftFront}, false},
+		{"RINCON_B", []Channel{ChannelRightFront, ChannelRightFront}, false},
+		{"RINCON_S", []Channel{ChannelSubwoofer, ChannelSubwoofer}, true},
+		{"RINCON_X", nil, false},
+	} {
+		if channels := m.Channels(tt.uuid); !reflect.DeepEqual(channels, tt.channels) {
+			t.Errorf("Channels(%s) = %v, want %v", tt.uuid, channels, tt.channels)
+		}
+		if contains := m.Contains(tt.uuid); contains != (tt.channels != nil) {
+			t.Errorf("Contains(%s) = %v", tt.uuid, contains)
+		}
+		if sub := (ChannelAssignment{UUID: tt.uuid, Channels: tt.channels}).isSub(); sub != tt.sub {
+			t.Errorf("%s: isSub = %v, want %v", tt.uuid, sub, tt.sub)
+		}
+	}
+
+	for _, tt := range []struct {
+		channel Channel
+		want    bool
+	}{
+		{ChannelLeftFront, true},
+		{ChannelRightFront, true},
+		{ChannelSubwoofer, true},
+		{ChannelLeftRear, false},
+	} {
+		if has := m.Has(tt.channel); has != tt.want {
+			t.Errorf("Has(%s) = %v, want %v", tt.channel, has, tt.want)
+		}
+	}
+}
+
+// bondingPlayer is the left speaker of a room whose ChannelMapSet follows
+// the bonding actions received.
+type bondingPlayer struct {
+	channelMapSet string
+}
+
+func (b *bondingPlayer) respond(action, args string) (string, error) {
+	switch action {
+	case "GetZoneGroupState":
+		return "<ZoneGroupState>" + escapeXML(fmt.Sprintf(`<ZoneGroupState><ZoneGroups>`+
+			`<ZoneGroup Coordinator="%s"><ZoneGroupMember UUID="%s" ZoneName="Kitchen" ChannelMapSet="%s"/>`+
+			`</ZoneGroup></ZoneGroups></ZoneGroupState>`, testPlayerUUID, testPlayerUUID, b.channelMapSet)) + "</ZoneGroupState>", nil
+	case "RemoveBondedZones":
+		m, _ := ParseChannelMap(b.channelMapSet)
+		removed, _ := ParseChannelMap(argValue(args, "ChannelMapSet"))
+		var kept ChannelMap
+		for _, a := range m {
+			if !removed.Contains(a.UUID) {
+				kept = append(kept, a)
+			}
+		}
+		b.channelMapSet = kept.String()
+	case "AddBondedZones":
+		b.channelMapSet = argValue(args, "ChannelMapSet")
+	case "SeparateStereoPair":
+		b.channelMapSet = ""
+	}
+	return "", nil
+}
+
+// argValue returns the value of the argument name of a SOAP request body.
+func argValue(args, name string) string {
+	start := strings.Index(args, "<"+name+">")
+	end := strings.Index(args, "</"+name+">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return args[start+len(name)+2 : end]
+}
+
+func TestSeparateStereoPair(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		bonded   string
+		err      error
+		removed  []string
+		separate []string
+	}{
+		{
+			name:     "pair",
+			bonded:   "RINCON_1:LF,LF;RINCON_2:RF,RF",
+			separate: []string{"RINCON_1:LF,LF;RINCON_2:RF,RF"},
+		},
+		{
+			name:     "pair with a Sub",
+			bonded:   "RINCON_1:LF,LF;RINCON_2:RF,RF;RINCON_S:SW,SW",
+			removed:  []string{"RINCON_S:SW,SW"},
+			separate: []string{"RINCON_1:LF,LF;RINCON_2:RF,RF"},
+		},
+		{
+			name:   "single speaker with a Sub",
+			bonded: "RINCON_1:LF,RF;RINCON_S:SW,SW",
+			err:    ErrNotBonded,
+		},
+		{
+			name:   "not bonded",
+			bonded: "",
+			err:    ErrNotBonded,
+		},
+	} {
+		b := &bondingPlayer{channelMapSet: tt.bonded}
+		p := newTestPlayer(b.respond)
+		zp := p.zonePlayer(t)
+
+		err := zp.SeparateStereoPair()
+		var removed, separate []string
+		for _, r := range p.actions("RemoveBondedZones") {
+			removed = append(removed, argValue(r, "ChannelMapSet"))
+		}
+		for _, r := range p.actions("SeparateStereoPair") {
+			separate = append(separate, argValue(r, "ChannelMapSet"))
+		}
+		p.Close()
+
+		if err != tt.err {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+		if !reflect.DeepEqual(removed, tt.removed) {
+			t.Errorf("%s: removed %v, want %v", tt.name, removed, tt.removed)
+		}
+		if !reflect.DeepEqual(separate, tt.separate) {
+			t.Errorf("%s: separated %v, want %v", tt.name, separate, tt.separate)
+		}
+	}
+}
+
+func TestAddSubModel(t *testing.T) {
+	for _, tt := range []struct {
+		model   string
+		display string
+		// err is a substring of the error, empty if the Sub is added.
+		err string
+	}{
+		{"Sub", "Sub", ""},
+		// A Sub missing from the catalogue.
+		{"S36", "Sub Mini", ""},
+		{"S1", "Play:1", "is not a Sub"},
+		// An unknown speaker whose model number merely contains Sub.
+		{"SubStandard", "Standard", ErrNotSupported.Error()},
+	} {
+		room := newTestPlayer((&bondingPlayer{}).respond)
+		zp := room.zonePlayer(t)
+		candidate := newTestPlayer((&bondingPlayer{}).respond)
+		candidate.mu.Lock()
+		candidate.uuid, candidate.model, candidate.display = "RINCON_S", tt.model, tt.display
+		candidate.mu.Unlock()
+		sub := candidate.zonePlayer(t)
+
+		err := zp.AddSub(sub)
+		candidate.Close()
+		room.Close()
+
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: AddSub: %v", tt.model, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: AddSub = %v, want %q", tt.model, err, tt.err)
+		}
+	}
+}
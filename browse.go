@@ -0,0 +1,360 @@
+package sonos
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/caglar10ur/sonos/didl"
+	dir "github.com/caglar10ur/sonos/services/ContentDirectory"
+	que "github.com/caglar10ur/sonos/services/Queue"
+)
+
+const (
+	// DefaultBrowsePageSize is the number of objects requested per Browse call
+	// unless overridden with WithPageSize.
+	DefaultBrowsePageSize = 100
+
+	// DefaultBrowseFilter asks for the metadata most callers need to display
+	// and enqueue an object.
+	DefaultBrowseFilter = "dc:title,res,dc:creator,upnp:artist,upnp:album,upnp:albumArtURI,upnp:originalTrackNumber"
+)
+
+// ErrBrowseModified is returned by a BrowseIterator when the UpdateID of the
+// browsed object changed between two pages, meaning that the object was
+// modified while it was being paged through.
+var ErrBrowseModified = errors.New("browsed object modified during iteration")
+
+// BrowseObject is a single child returned by a BrowseIterator. Exactly one of
+// Container or Item is set.
+type BrowseObject struct {
+	Container *didl.Container
+	Item      *didl.Item
+}
+
+// ID returns the object ID of the underlying container or item.
+func (o BrowseObject) ID() string {
+	if o.Container != nil {
+		return o.Container.ID
+	}
+	if o.Item != nil {
+		return o.Item.ID
+	}
+	return ""
+}
+
+// Title returns the title of the underlying container or item.
+func (o BrowseObject) Title() string {
+	if o.Container != nil {
+		return firstTitle(o.Container.Title)
+	}
+	if o.Item != nil {
+		return firstTitle(o.Item.Title)
+	}
+	return ""
+}
+
+// browsePage is the subset of a Browse response shared by the ContentDirectory
+// and Queue services.
+type browsePage struct {
+	Result         string
+	NumberReturned uint32
+	TotalMatches   uint32
+	UpdateID       uint32
+}
+
+type BrowseOption func(*BrowseIterator)
+
+// WithPageSize sets the number of objects requested per Browse call.
+func WithPageSize(n uint32) BrowseOption {
+	return func(it *BrowseIterator) {
+		if n > 0 {
+			it.pageSize = n
+		}
+	}
+}
+
+// WithFilter sets the Browse filter. It is ignored when browsing the Queue
+// service.
+func WithFilter(filter string) BrowseOption {
+	return func(it *BrowseIterator) {
+		it.filter = filter
+	}
+}
+
+// WithSortCriteria sets the Browse sort criteria, e.g. "+dc:title". It is
+// ignored when browsing the Queue service.
+func WithSortCriteria(criteria string) BrowseOption {
+	return func(it *BrowseIterator) {
+		it.sortCriteria = criteria
+	}
+}
+
+// WithStartingIndex sets the zero based index of the first object returned.
+func WithStartingIndex(index uint32) BrowseOption {
+	return func(it *BrowseIterator) {
+		it.index = index
+	}
+}
+
+// BrowseIterator pages through the direct children of an object. It is used
+// like bufio.Scanner:
+//
+//	it := zp.Browse(ctx, "A:ARTIST")
+//	for it.Next() {
+//		fmt.Println(it.Object().Title())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type BrowseIterator struct {
+	ctx   context.Context
+	fetch func(it *BrowseIterator) (*browsePage, error)
+
+	pageSize     uint32
+	filter       string
+	sortCriteria string
+
+	// index of the next object to be fetched
+	index    uint32
+	total    uint32
+	updateID uint32
+	started  bool
+
+	pending []BrowseObject
+	current BrowseObject
+	err     error
+}
+
+func newBrowseIterator(ctx context.Context, fetch func(*BrowseIterator) (*browsePage, error), opts ...BrowseOption) *BrowseIterator {
+	it := &BrowseIterator{
+		ctx:      ctx,
+		fetch:    fetch,
+		pageSize: DefaultBrowsePageSize,
+		filter:   DefaultBrowseFilter,
+	}
+
+	for _, opt := range opts {
+		opt(it)
+	}
+
+	return it
+}
+
+// NewContentDirectoryIterator returns an iterator over the direct children of
+// objectID (e.g. "Q:0", "A:ALBUM", "FV:2").
+func NewContentDirectoryIterator(ctx context.Context, cd *dir.Service, objectID string, opts ...BrowseOption) *BrowseIterator {
+	return newBrowseIterator(ctx, func(it *BrowseIterator) (*browsePage, error) {
+		res, err := cd.Browse(&dir.BrowseArgs{
+			ObjectID:       objectID,
+			BrowseFlag:     "BrowseDirectChildren",
+			Filter:         it.filter,
+			StartingIndex:  it.index,
+			RequestedCount: it.pageSize,
+			SortCriteria:   it.sortCriteria,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &browsePage{
+			Result:         res.Result,
+			NumberReturned: res.NumberReturned,
+			TotalMatches:   res.TotalMatches,
+			UpdateID:       res.UpdateID,
+		}, nil
+	}, opts...)
+}
+
+// NewQueueIterator returns an iterator over the tracks of the given queue
+// using the Queue service.
+func NewQueueIterator(ctx context.Context, q *que.Service, queueID uint32, opts ...BrowseOption) *BrowseIterator {
+	return newBrowseIterator(ctx, func(it *BrowseIterator) (*browsePage, error) {
+		res, err := q.Browse(&que.BrowseArgs{
+			QueueID:        queueID,
+			StartingIndex:  it.index,
+			RequestedCount: it.pageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &browsePage{
+			Result:         res.Result,
+			NumberReturned: res.NumberReturned,
+			TotalMatches:   res.TotalMatches,
+			UpdateID:       res.UpdateID,
+		}, nil
+	}, opts...)
+}
+
+// Next advances the iterator to the next object, fetching a new page when
+// needed. It returns false when there are no more objects or an error
+// occurred.
+func (it *BrowseIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for len(it.pending) == 0 {
+		if it.started && it.index >= it.total {
+			return false
+		}
+		if err := it.nextPage(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.current, it.pending = it.pending[0], it.pending[1:]
+	return true
+}
+
+func (it *BrowseIterator) nextPage() error {
+	if err := it.ctx.Err(); err != nil {
+		return err
+	}
+
+	page, err := it.fetch(it)
+	if err != nil {
+		return err
+	}
+
+	if it.started && page.UpdateID != it.updateID {
+		return ErrBrowseModified
+	}
+	it.started = true
+	it.updateID = page.UpdateID
+	it.total = page.TotalMatches
+
+	if page.NumberReturned == 0 {
+		// Device returned nothing even though we have not reached
+		// TotalMatches; stop rather than loop forever.
+		it.total = it.index
+		return nil
+	}
+	it.index += page.NumberReturned
+
+	objects, err := parseBrowseObjects(page.Result)
+	if err != nil {
+		return err
+	}
+	it.pending = append(it.pending, objects...)
+
+	return nil
+}
+
+// parseBrowseObjects decodes the containers and items of a DIDL-Lite
+// document in document order. ParseDIDL separates them, which loses the
+// order of pages mixing both, such as shares or favorites.
+func parseBrowseObjects(raw string) ([]BrowseObject, error) {
+	var (
+		objects []BrowseObject
+		depth   int
+		root    bool
+	)
+	d := xml.NewDecoder(strings.NewReader(raw))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF && root {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			root = true
+			if depth != 1 {
+				depth++
+				continue
+			}
+			switch t.Name.Local {
+			case "container":
+				var c didl.Container
+				if err := d.DecodeElement(&c, &t); err != nil {
+					return nil, err
+				}
+				objects = append(objects, BrowseObject{Container: &c})
+			case "item":
+				var i didl.Item
+				if err := d.DecodeElement(&i, &t); err != nil {
+					return nil, err
+				}
+				objects = append(objects, BrowseObject{Item: &i})
+			default:
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// Object returns the object the iterator currently points at.
+func (it *BrowseIterator) Object() BrowseObject {
+	return it.current
+}
+
+// Err returns the first error encountered by the iterator.
+func (it *BrowseIterator) Err() error {
+	return it.err
+}
+
+// TotalMatches returns the total number of children reported by the device.
+// It is only valid after the first call to Next.
+func (it *BrowseIterator) TotalMatches() uint32 {
+	return it.total
+}
+
+// UpdateID returns the UpdateID reported with the first page.
+// It is only valid after the first call to Next.
+func (it *BrowseIterator) UpdateID() uint32 {
+	return it.updateID
+}
+
+// All drains the iterator and returns every remaining object as a Lite
+// document.
+func (it *BrowseIterator) All() (*Lite, error) {
+	lite := NewDIDL()
+	for it.Next() {
+		o := it.Object()
+		if o.Container != nil {
+			lite.Container = append(lite.Container, *o.Container)
+		}
+		if o.Item != nil {
+			lite.Item = append(lite.Item, *o.Item)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return lite, nil
+}
+
+// MediaObjects drains the iterator and returns every remaining object
+// flattened into a MediaObject.
+func (it *BrowseIterator) MediaObjects() ([]MediaObject, error) {
+	var objects []MediaObject
+	for it.Next() {
+		objects = append(objects, NewMediaObject(it.Object()))
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// Browse returns an iterator over the direct children of objectID using the
+// ContentDirectory service.
+func (z *ZonePlayer) Browse(ctx context.Context, objectID string, opts ...BrowseOption) *BrowseIterator {
+	return NewContentDirectoryIterator(ctx, z.ContentDirectory(), objectID, opts...)
+}
+
+// BrowseQueue returns an iterator over the tracks in the player's queue.
+func (z *ZonePlayer) BrowseQueue(ctx context.Context, opts ...BrowseOption) *BrowseIterator {
+	return NewQueueIterator(ctx, z.Queue(), 0, opts...)
+}
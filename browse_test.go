@@ -0,0 +1,93 @@
+package sonos
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var startingIndexArg = regexp.MustCompile(`<StartingIndex>(\d+)</StartingIndex>`)
+
+func TestBrowseIteratorOrder(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// pages are the objects of each Browse response.
+		pages [][]string
+		ids   string
+		err   bool
+	}{
+		{
+			name:  "items",
+			pages: [][]string{{`<item id="A"/>`, `<item id="B"/>`}},
+			ids:   "[A B]",
+		},
+		{
+			name:  "mixed page",
+			pages: [][]string{{`<item id="A"/>`, `<container id="B"/>`, `<item id="C"/>`, `<container id="D"/>`}},
+			ids:   "[A B C D]",
+		},
+		{
+			name: "mixed pages",
+			pages: [][]string{
+				{`<container id="A"><res>x-rincon-cpcontainer:1</res></container>`, `<item id="B"><item id="nested"/></item>`},
+				{`<item id="C"/>`, `<container id="D"/>`},
+			},
+			ids: "[A B C D]",
+		},
+		{
+			name:  "other elements",
+			pages: [][]string{{`<desc id="x"><item id="nested"/></desc>`, `<item id="A"/>`}},
+			ids:   "[A]",
+		},
+		{
+			name:  "malformed",
+			pages: [][]string{{`<item id="A">`}},
+			err:   true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var total int
+			for _, page := range tt.pages {
+				total += len(page)
+			}
+			p := newTestPlayer(func(action, args string) (string, error) {
+				start, _ := strconv.Atoi(startingIndexArg.FindStringSubmatch(args)[1])
+				var page []string
+				for _, objects := range tt.pages {
+					if start < len(objects) {
+						page = objects[start:]
+						break
+					}
+					start -= len(objects)
+				}
+				result := `<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/">` +
+					strings.Join(page, "") + `</DIDL-Lite>`
+				return fmt.Sprintf("<Result>%s</Result><NumberReturned>%d</NumberReturned>"+
+					"<TotalMatches>%d</TotalMatches><UpdateID>1</UpdateID>", escapeXML(result), len(page), total), nil
+			})
+			defer p.Close()
+			zp := p.zonePlayer(t)
+
+			var ids []string
+			it := NewContentDirectoryIterator(context.Background(), zp.ContentDirectory(), "FV:2")
+			for it.Next() {
+				ids = append(ids, it.Object().ID())
+			}
+			if tt.err {
+				if it.Err() == nil {
+					t.Errorf("objects %v, want an error", ids)
+				}
+				return
+			}
+			if it.Err() != nil {
+				t.Fatal(it.Err())
+			}
+			if got := fmt.Sprint(ids); got != tt.ids {
+				t.Errorf("objects %s, want %s", got, tt.ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package sonos
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	zgt "github.com/caglar10ur/sonos/services/ZoneGroupTopology"
+)
+
+// CachedPlayer is a player remembered by a HouseholdCache.
+type CachedPlayer struct {
+	UUID            string
+	SerialNum       string
+	Location        string
+	RoomName        string
+	ModelName       string
+	ModelNumber     string
+	SoftwareVersion string
+	HouseholdID     string
+	// Coordinator tells whether the player coordinated its group when it
+	// was last seen.
+	Coordinator bool
+	// Root is the device description, so that the player can be created
+	// without fetching it.
+	Root     *Root
+	LastSeen time.Time
+}
+
+// HouseholdCache remembers the players of the household on disk so that
+// they are available immediately on the next start, before discovery
+// completes. It also serves as the DescriptionCache of those players.
+type HouseholdCache struct {
+	path string
+
+	mu      sync.Mutex
+	players map[string]*CachedPlayer
+	// topology is the last ZoneGroupState document seen.
+	topology string
+}
+
+// householdCacheFile is the on-disk format of a HouseholdCache.
+type householdCacheFile struct {
+	Players  []*CachedPlayer
+	Topology string
+}
+
+// DefaultHouseholdCachePath returns the location of the household cache in
+// the user's cache directory.
+func DefaultHouseholdCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sonos", "household.json"), nil
+}
+
+// OpenHouseholdCache loads the household cache stored at path. A missing
+// file yields an empty cache.
+func OpenHouseholdCache(path string) (*HouseholdCache, error) {
+	c := &HouseholdCache{
+		path:    path,
+		players: make(map[string]*CachedPlayer),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f householdCacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for _, p := range f.Players {
+		c.players[p.UUID] = p
+	}
+	c.topology = f.Topology
+	return c, nil
+}
+
+// Save writes the cache to disk. The file is replaced atomically so that a
+// crash never leaves a truncated cache behind.
+func (c *HouseholdCache) Save() error {
+	c.mu.Lock()
+	f := householdCacheFile{Topology: c.topology}
+	for _, p := range c.players {
+		f.Players = append(f.Players, p)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(f.Players, func(i, j int) bool {
+		return f.Players[i].UUID < f.Players[j].UUID
+	})
+	data, err := json.MarshalIndent(&f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// Players returns the cached players ordered by room name.
+func (c *HouseholdCache) Players() []CachedPlayer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	players := make([]CachedPlayer, 0, len(c.players))
+	for _, p := range c.players {
+		players = append(players, *p)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].RoomName < players[j].RoomName
+	})
+	return players
+}
+
+// Add remembers zp, replacing what was cached for it before.
+func (c *HouseholdCache) Add(zp *ZonePlayer) error {
+	household, err := zp.HouseholdID()
+	if err != nil {
+		return err
+	}
+
+	root := zp.Root()
+
+	p := &CachedPlayer{
+		UUID:            zp.UUID(),
+		SerialNum:       zp.SerialNum(),
+		Location:        zp.Location().String(),
+		RoomName:        zp.RoomName(),
+		ModelName:       zp.ModelName(),
+		ModelNumber:     zp.ModelNumber(),
+		SoftwareVersion: root.Device.SoftwareVersion,
+		HouseholdID:     household,
+		Coordinator:     zp.IsCoordinator(),
+		Root:            root,
+		LastSeen:        time.Now(),
+	}
+
+	c.mu.Lock()
+	c.players[p.UUID] = p
+	c.mu.Unlock()
+	return nil
+}
+
+// Remove forgets the player with the given UUID.
+func (c *HouseholdCache) Remove(uuid string) {
+	c.mu.Lock()
+	delete(c.players, uuid)
+	c.mu.Unlock()
+}
+
+// Topology returns the last ZoneGroupState document stored in the cache.
+func (c *HouseholdCache) Topology() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.topology
+}
+
+// SetTopology stores the last ZoneGroupState document.
+func (c *HouseholdCache) SetTopology(zoneGroupState string) {
+	c.mu.Lock()
+	c.topology = zoneGroupState
+	c.mu.Unlock()
+}
+
+// Get returns the cached description of a player, see DescriptionCache.
+func (c *HouseholdCache) Get(uuid, softwareVersion string) (*Root, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.players[uuid]
+	if !ok || p.Root == nil || p.SoftwareVersion != softwareVersion {
+		return nil, false
+	}
+	return p.Root, true
+}
+
+// Put updates the cached description of a player, see DescriptionCache.
+// Players not in the cache yet are added with Add.
+func (c *HouseholdCache) Put(root *Root) {
+	uuid := strings.TrimPrefix(root.Device.UDN, "uuid:")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.players[uuid]; ok {
+		p.Root = root
+		p.SoftwareVersion = root.Device.SoftwareVersion
+		p.RoomName = root.Device.RoomName
+	}
+}
+
+// searchCache reports the coordinators remembered in the household cache
+// and verifies them in the background.
+func (s *Sonos) searchCache(foundFn FoundZonePlayer) {
+	for _, p := range s.cache.Players() {
+		if !p.Coordinator || p.Root == nil || !s.wantHousehold(p.HouseholdID) {
+			continue
+		}
+		location, err := url.Parse(p.Location)
+		if err != nil {
+			continue
+		}
+		zp, err := NewZonePlayer(s.playerOptions(
+			WithLocation(location),
+			WithRoot(p.Root),
+			WithDescriptionCache(s.cache, "", ""),
+		)...)
+		if err != nil {
+			continue
+		}
+		zp.setHouseholdID(p.HouseholdID)
+		if _, loaded := s.zonePlayers.LoadOrStore(zp.SerialNum(), zp); loaded {
+			continue
+		}
+		go s.verifyCached(zp)
+		foundFn(s, zp)
+	}
+}
+
+// verifyCached checks that a player created from the household cache still
+// responds, evicting it otherwise.
+func (s *Sonos) verifyCached(zp *ZonePlayer) {
+	res, err := zp.ZoneGroupTopology().GetZoneGroupState(&zgt.GetZoneGroupStateArgs{})
+	if err != nil {
+		if p, ok := s.zonePlayers.Load(zp.SerialNum()); ok && p == zp {
+			s.zonePlayers.Delete(zp.SerialNum())
+		}
+		s.cache.Remove(zp.UUID())
+		s.cache.Save()
+		return
+	}
+	s.cache.SetTopology(res.ZoneGroupState)
+	s.cache.Save()
+}
+
+// remember adds a player found by discovery to the household cache.
+func (s *Sonos) remember(zp *ZonePlayer) {
+	if s.cache == nil {
+		return
+	}
+	zp.descriptions = s.cache
+	if err := s.cache.Add(zp); err != nil {
+		return
+	}
+	s.cache.Save()
+}
@@ -124,15 +124,6 @@ func MakeServiceApi(ServiceName, serviceControlEndpoint, serviceEventEndpoint st
 		fmt.Fprintf(state, "type %s %s\n", sv.Name, sv.GoDataType())
 	}
 
-	otherstate := bytes.NewBufferString("")
-
-	for _, sv := range s.StateVariables {
-		if sv.SendEvents != "yes" {
-			continue
-		}
-		fmt.Fprintf(otherstate, "%s *%s\n", sv.Name, sv.Name)
-	}
-
 	buf := bytes.NewBufferString("")
 
 	// Header
@@ -146,6 +137,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -177,8 +169,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	%s
-
 	location        *url.URL
 	client          *http.Client
 }
@@ -235,7 +225,6 @@ func (s *Service) Client() *http.Client {
 		ServiceName,
 
 		state,
-		otherstate,
 		serviceControlEndpoint,
 		serviceEventEndpoint,
 	)
@@ -269,6 +258,7 @@ func (s *Service) Client() *http.Client {
 	fmt.Fprintf(buf, "// internal use only\n")
 	fmt.Fprintf(buf, "type bodyResponse struct {\n")
 	fmt.Fprint(buf, "XMLName xml.Name `xml:\"Body\"`\n")
+	fmt.Fprint(buf, "Fault *fault `xml:\"Fault,omitempty\"`\n")
 	for _, action := range s.Actions {
 		fmt.Fprintf(buf, "%s *%sResponse `xml:\"%sResponse,omitempty\"`\n", action.Name, action.Name, action.Name)
 	}
@@ -301,10 +291,48 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
+
+// internal use only
+type fault struct {
+	FaultCode   string ` + "`xml:\"faultcode\"`" + `
+	FaultString string ` + "`xml:\"faultstring\"`" + `
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    ` + "`xml:\"errorCode\"`" + `
+			ErrorDescription string ` + "`xml:\"errorDescription\"`" + `
+		} ` + "`xml:\"UPnPError\"`" + `
+	} ` + "`xml:\"detail\"`" + `
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%[1]s.%%s: UPnP error %%d: %%s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("%[1]s.%%s: UPnP error %%d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
 	`
-	fmt.Fprintf(buf, w)
+	fmt.Fprintf(buf, w, strings.ToLower(ServiceName))
 
 	for _, action := range s.Actions {
 		var inArguments, outArguments []Argument
@@ -388,7 +416,6 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 		}
 		// fmt.Fprintf(buf, "case prop.%s != nil:\n zp.EventCallback(*prop.%s)\n", sv.Name, sv.Name)
 		fmt.Fprintf(buf, "case prop.%s != nil:\n", sv.Name)
-		fmt.Fprintf(buf, "zp.%s = prop.%s\n", sv.Name, sv.Name)
 		fmt.Fprintf(buf, "events = append(events, *prop.%s)\n", sv.Name)
 	}
 	fmt.Fprintf(buf, "}\n}\nreturn events\n}")
@@ -0,0 +1,68 @@
+// Command sonos-api serves a JSON REST API for controlling the Sonos
+// players on the local network. The API is described at /openapi.json;
+// changes are streamed from /events.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/caglar10ur/sonos"
+	"github.com/caglar10ur/sonos/api"
+	"github.com/caglar10ur/sonos/stream"
+)
+
+func main() {
+	listen := flag.String("listen", ":5005", "address to serve the API on")
+	household := flag.String("household", "", "only control the players of this household")
+	cache := flag.Bool("cache", true, "use the household cache")
+	timeout := flag.Duration("timeout", api.DefaultTimeout, "how long to wait for discovery to find a room")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := []sonos.SonosOption{
+		sonos.WithErrorHandler(func(err error) {
+			fmt.Printf("Error: %v\n", err)
+		}),
+	}
+	if *household != "" {
+		opts = append(opts, sonos.WithHousehold(*household))
+	}
+	if *cache {
+		if path, err := sonos.DefaultHouseholdCachePath(); err == nil {
+			if c, err := sonos.OpenHouseholdCache(path); err == nil {
+				opts = append(opts, sonos.WithHouseholdCache(c))
+			}
+		}
+	}
+
+	son, err := sonos.NewSonos(opts...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer son.Close()
+
+	events := stream.NewHandler(son, stream.WithErrorHandler(func(err error) {
+		fmt.Printf("Error: %v\n", err)
+	}))
+	// Finds the players up front, so that the first requests need not wait.
+	go func() {
+		if err := events.Run(ctx); err != nil {
+			fmt.Printf("Run Error: %v\n", err)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/events", events)
+	mux.Handle("/", api.NewHandler(son, api.WithTimeout(*timeout)))
+	if err := http.ListenAndServe(*listen, mux); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,99 @@
+// Command sonos-exporter serves Prometheus metrics about the Sonos players
+// on the local network at /metrics. It subscribes to the events of every
+// visible player so that the event and subscription metrics are reported.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/caglar10ur/sonos"
+	"github.com/caglar10ur/sonos/exporter"
+)
+
+func main() {
+	listen := flag.String("listen", ":9798", "address to serve the metrics on")
+	household := flag.String("household", "", "only report the players of this household")
+	cache := flag.Bool("cache", true, "use the household cache")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	metrics := sonos.NewMetrics()
+	opts := []sonos.SonosOption{
+		sonos.WithMetrics(metrics),
+		sonos.WithErrorHandler(func(err error) {
+			fmt.Printf("Error: %v\n", err)
+		}),
+	}
+	if *household != "" {
+		opts = append(opts, sonos.WithHousehold(*household))
+	}
+	if *cache {
+		if path, err := sonos.DefaultHouseholdCachePath(); err == nil {
+			if c, err := sonos.OpenHouseholdCache(path); err == nil {
+				opts = append(opts, sonos.WithHouseholdCache(c))
+			}
+		}
+	}
+
+	son, err := sonos.NewSonos(opts...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer son.Close()
+
+	subs := sonos.NewSubscriptionSet(son, services,
+		sonos.WithSubscriptionErrorHandler(func(err error) {
+			fmt.Printf("Subscribe Error: %v\n", err)
+		}),
+	)
+
+	http.Handle("/metrics", exporter.NewHandler(son, metrics))
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- http.ListenAndServe(*listen, nil)
+		cancel()
+	}()
+
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	if err := subs.Run(ctx); err != nil {
+		fmt.Printf("Search Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	select {
+	case err := <-serveErr:
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	default:
+	}
+}
+
+// services are the services subscribed to so that the subscription and
+// event series of the library metrics are populated.
+func services(zp *sonos.ZonePlayer) []sonos.SonosService {
+	services := []sonos.SonosService{
+		zp.AVTransport(),
+		zp.RenderingControl(),
+		zp.ZoneGroupTopology(),
+	}
+	if zp.IsCoordinator() {
+		services = append(services, zp.GroupRenderingControl())
+	}
+	return services
+}
@@ -0,0 +1,93 @@
+// Command sonos-mqtt bridges the Sonos players on the local network to an
+// MQTT broker.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/caglar10ur/sonos"
+	"github.com/caglar10ur/sonos/mqtt"
+)
+
+func main() {
+	broker := flag.String("broker", "localhost:1883", "MQTT broker address")
+	username := flag.String("username", "", "MQTT username")
+	password := flag.String("password", "", "MQTT password")
+	clientID := flag.String("client-id", "sonos-mqtt", "MQTT client identifier")
+	prefix := flag.String("prefix", mqtt.DefaultTopicPrefix, "topic prefix")
+	discovery := flag.String("discovery-prefix", mqtt.DefaultDiscoveryPrefix, "Home Assistant discovery prefix, empty to disable")
+	household := flag.String("household", "", "only bridge the players of this household")
+	cache := flag.Bool("cache", true, "use the household cache")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	opts := []sonos.SonosOption{
+		sonos.WithErrorHandler(func(err error) {
+			fmt.Printf("Error: %v\n", err)
+		}),
+	}
+	if *household != "" {
+		opts = append(opts, sonos.WithHousehold(*household))
+	}
+	if *cache {
+		if path, err := sonos.DefaultHouseholdCachePath(); err == nil {
+			if c, err := sonos.OpenHouseholdCache(path); err == nil {
+				opts = append(opts, sonos.WithHouseholdCache(c))
+			}
+		}
+	}
+
+	son, err := sonos.NewSonos(opts...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer son.Close()
+
+	connOpts := []mqtt.ConnOption{
+		mqtt.WithClientID(*clientID),
+		mqtt.WithWill(mqtt.AvailabilityTopic(*prefix), []byte("offline"), true),
+	}
+	if *username != "" {
+		connOpts = append(connOpts, mqtt.WithCredentials(*username, *password))
+	}
+
+	conn, err := mqtt.Dial(ctx, *broker, connOpts...)
+	if err != nil {
+		fmt.Printf("Dial Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	bridge := mqtt.NewBridge(son, conn,
+		mqtt.WithTopicPrefix(*prefix),
+		mqtt.WithDiscoveryPrefix(*discovery),
+		mqtt.WithErrorHandler(func(err error) {
+			fmt.Printf("Error: %v\n", err)
+		}),
+	)
+
+	go func() {
+		select {
+		case <-signals:
+		case <-conn.Done():
+			fmt.Printf("Connection Error: %v\n", conn.Err())
+		}
+		cancel()
+	}()
+
+	if err := bridge.Run(ctx); err != nil {
+		fmt.Printf("Run Error: %v\n", err)
+		os.Exit(1)
+	}
+}
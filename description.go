@@ -0,0 +1,166 @@
+package sonos
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DescriptionCache stores device descriptions so that players can be
+// created without fetching them again. Entries are keyed by UUID and
+// software version since a firmware update may change the description.
+type DescriptionCache interface {
+	Get(uuid, softwareVersion string) (*Root, bool)
+	Put(root *Root)
+}
+
+// MemoryDescriptionCache is a DescriptionCache held in memory.
+type MemoryDescriptionCache struct {
+	mu    sync.RWMutex
+	roots map[string]*Root
+}
+
+// NewMemoryDescriptionCache returns an empty in-memory description cache.
+func NewMemoryDescriptionCache() *MemoryDescriptionCache {
+	return &MemoryDescriptionCache{roots: make(map[string]*Root)}
+}
+
+func descriptionKey(uuid, softwareVersion string) string {
+	return uuid + "/" + softwareVersion
+}
+
+// Get returns the cached description of the player with the given UUID and
+// software version.
+func (c *MemoryDescriptionCache) Get(uuid, softwareVersion string) (*Root, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	root, ok := c.roots[descriptionKey(uuid, softwareVersion)]
+	return root, ok
+}
+
+// Put stores a description, replacing older ones of the same player.
+func (c *MemoryDescriptionCache) Put(root *Root) {
+	uuid := strings.TrimPrefix(root.Device.UDN, "uuid:")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.roots {
+		if strings.HasPrefix(key, uuid+"/") {
+			delete(c.roots, key)
+		}
+	}
+	c.roots[descriptionKey(uuid, root.Device.SoftwareVersion)] = root
+}
+
+// WithRoot creates the player from an already known device description
+// instead of fetching it. The description is revalidated in the background.
+func WithRoot(root *Root) ZonePlayerOption {
+	return func(z *ZonePlayer) {
+		z.root = root
+	}
+}
+
+// WithDescriptionCache stores fetched descriptions in c. If c holds the
+// description of the player with the given UUID and software version, as
+// found in ZoneGroupState, it is used instead of fetching it and is
+// revalidated in the background. Both may be empty to only fill the cache.
+func WithDescriptionCache(c DescriptionCache, uuid, softwareVersion string) ZonePlayerOption {
+	return func(z *ZonePlayer) {
+		z.descriptions = c
+		if uuid == "" {
+			return
+		}
+		if root, ok := c.Get(uuid, softwareVersion); ok {
+			z.root = root
+		}
+	}
+}
+
+func (z *ZonePlayer) fetchDescription() (*Root, error) {
+	resp, err := z.client.Get(z.location.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching device description: %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &Root{}
+	if err := xml.Unmarshal(body, root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func (z *ZonePlayer) setRoot(root *Root) {
+	z.rootMu.Lock()
+	z.root = root
+	z.rootMu.Unlock()
+
+	if z.descriptions != nil {
+		z.descriptions.Put(root)
+	}
+}
+
+// Root returns the device description of the player. It is replaced, not
+// modified, when the description is refreshed, and must not be modified.
+func (z *ZonePlayer) Root() *Root {
+	z.rootMu.RLock()
+	defer z.rootMu.RUnlock()
+
+	return z.root
+}
+
+func (z *ZonePlayer) device() Device {
+	return z.Root().Device
+}
+
+// RefreshDescription fetches the device description again. It is called
+// automatically when the player reboots, as reported by the BootSeq of
+// ZoneGroupState.
+func (z *ZonePlayer) RefreshDescription() error {
+	root, err := z.fetchDescription()
+	if err != nil {
+		return err
+	}
+	z.setRoot(root)
+	return nil
+}
+
+// refreshDescription refreshes the description in the background, reporting
+// failures to the error handler.
+func (z *ZonePlayer) refreshDescription() {
+	if err := z.RefreshDescription(); err != nil && z.errorHandler != nil {
+		z.errorHandler(fmt.Errorf("refreshing device description: %w", err))
+	}
+}
+
+// checkBootSeq refreshes the description when the boot sequence number of
+// the player changed since the last ZoneGroupState seen.
+func (z *ZonePlayer) checkBootSeq(zoneGroupState *ZoneGroupState) {
+	member := zoneGroupState.member(z.UUID())
+	if member == nil || member.BootSeq == "" {
+		return
+	}
+
+	z.rootMu.Lock()
+	previous := z.bootSeq
+	z.bootSeq = member.BootSeq
+	z.rootMu.Unlock()
+
+	if previous != "" && previous != member.BootSeq {
+		go z.refreshDescription()
+	}
+}
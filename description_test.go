@@ -0,0 +1,61 @@
+package sonos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRefreshDescriptionError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 1)
+	u, _ := url.Parse(srv.URL + "/xml/device_description.xml")
+	root := &Root{Device: Device{UDN: "uuid:" + testPlayerUUID}}
+	zp, err := NewZonePlayer(WithLocation(u), WithRoot(root), WithEventErrorHandler(func(err error) {
+		errs <- err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "503") {
+			t.Errorf("error %v, want the status of the description request", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("refresh error not reported")
+	}
+	if zp.Root() != root {
+		t.Error("description replaced after a failed refresh")
+	}
+}
+
+func TestServicesLazy(t *testing.T) {
+	p := newTestPlayer(func(action, args string) (string, error) {
+		return "", nil
+	})
+	defer p.Close()
+	zp := p.zonePlayer(t)
+
+	if zp.Services.avTransport != nil {
+		t.Fatal("AVTransport built before it is used")
+	}
+	avt := zp.AVTransport()
+	if avt == nil || zp.AVTransport() != avt {
+		t.Error("AVTransport not built once")
+	}
+	if got, want := avt.ControlEndpoint().String(), p.URL+"/MediaRenderer/AVTransport/Control"; got != want {
+		t.Errorf("control endpoint %s, want %s", got, want)
+	}
+	if zp.Services.renderingControl != nil {
+		t.Error("RenderingControl built along with AVTransport")
+	}
+}
@@ -0,0 +1,84 @@
+package sonos
+
+import (
+	dev "github.com/caglar10ur/sonos/services/DeviceProperties"
+)
+
+// DeviceInfo is the identity and hardware information of a player, merged
+// from its device description and GetZoneInfo.
+type DeviceInfo struct {
+	UUID        string
+	RoomName    string
+	DisplayName string
+	Model       Model
+	ModelName   string
+	SerialNum   string
+
+	SoftwareVersion        string
+	DisplaySoftwareVersion string
+	HardwareVersion        string
+	SwGen                  string
+	APIVersion             string
+
+	MACAddress string
+	IPAddress  string
+
+	Feature1  string
+	Feature2  string
+	Feature3  string
+	ZoneType  int
+	Variant   int
+	AmpOnTime int
+	Flags     uint32
+	HTAudioIn uint32
+	ExtraInfo string
+}
+
+// DeviceInfo returns the identity and hardware information of the player.
+func (z *ZonePlayer) DeviceInfo() (*DeviceInfo, error) {
+	info, err := z.DeviceProperties().GetZoneInfo(&dev.GetZoneInfoArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	d := z.device()
+	return &DeviceInfo{
+		UUID:                   z.UUID(),
+		RoomName:               d.RoomName,
+		DisplayName:            d.DisplayName,
+		Model:                  z.Model(),
+		ModelName:              d.ModelName,
+		SerialNum:              d.SerialNum,
+		SoftwareVersion:        info.SoftwareVersion,
+		DisplaySoftwareVersion: info.DisplaySoftwareVersion,
+		HardwareVersion:        info.HardwareVersion,
+		SwGen:                  d.SwGen,
+		APIVersion:             d.APIVersion,
+		MACAddress:             info.MACAddress,
+		IPAddress:              info.IPAddress,
+		Feature1:               d.Feature1,
+		Feature2:               d.Feature2,
+		Feature3:               d.Feature3,
+		ZoneType:               d.ZoneType,
+		Variant:                d.Variant,
+		AmpOnTime:              d.AmpOnTime,
+		Flags:                  info.Flags,
+		HTAudioIn:              info.HTAudioIn,
+		ExtraInfo:              info.ExtraInfo,
+	}, nil
+}
+
+// ModelNumber returns the model number of the player, e.g. S14.
+func (z *ZonePlayer) ModelNumber() string {
+	return z.device().ModelNumber
+}
+
+// Model returns the catalogue entry of the player's model. Models missing
+// from the catalogue are reported with their device description name and
+// no capabilities.
+func (z *ZonePlayer) Model() Model {
+	if m, ok := LookupModel(z.ModelNumber()); ok {
+		return m
+	}
+	return Model{Number: z.ModelNumber(), Name: z.ModelName()}
+}
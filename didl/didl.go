@@ -62,6 +62,12 @@ type AlbumArtURI struct {
 	Value   string `xml:",chardata"`
 }
 
+type Artist struct {
+	XMLName xml.Name `json:"-"`
+	Role    string `xml:"role,attr"`
+	Value   string `xml:",chardata"`
+}
+
 type Class struct {
 	XMLName xml.Name `json:"-"`
 	Value   string `xml:",chardata"`
@@ -72,6 +78,23 @@ type Creator struct {
 	Value   string `xml:",chardata"`
 }
 
+type Desc struct {
+	XMLName   xml.Name `json:"-"`
+	ID        string `xml:"id,attr"`
+	NameSpace string `xml:"nameSpace,attr"`
+	Value     string `xml:",chardata"`
+}
+
+type Description struct {
+	XMLName xml.Name `json:"-"`
+	Value   string `xml:",chardata"`
+}
+
+type Genre struct {
+	XMLName xml.Name `json:"-"`
+	Value   string `xml:",chardata"`
+}
+
 type OriginalTrackNumber struct {
 	XMLName xml.Name `json:"-"`
 	Value   string `xml:",chardata"`
@@ -83,6 +106,11 @@ type Res struct {
 	Duration     string `xml:"duration,attr"`
 	Value        string `xml:",chardata"`
 }
+type ResMD struct {
+	XMLName xml.Name `json:"-"`
+	Value   string `xml:",chardata"`
+}
+
 type Title struct {
 	XMLName xml.Name `json:"-"`
 	Value   string `xml:",chardata"`
@@ -98,6 +126,12 @@ type Container struct {
 	Class       []Class       `xml:"class"`
 	AlbumArtURI []AlbumArtURI `xml:"albumArtURI"`
 	Creator     []Creator     `xml:"creator"`
+	Artist      []Artist      `xml:"artist"`
+	Album       []Album       `xml:"album"`
+	Genre       []Genre       `xml:"genre"`
+	Desc        []Desc        `xml:"desc"`
+	ResMD       []ResMD       `xml:"resMD"`
+	Description []Description `xml:"description"`
 	didlValidated
 }
 
@@ -113,6 +147,11 @@ type Item struct {
 	Creator             []Creator             `xml:"creator"`
 	Album               []Album               `xml:"album"`
 	OriginalTrackNumber []OriginalTrackNumber `xml:"originalTrackNumber"`
+	Artist              []Artist              `xml:"artist"`
+	Genre               []Genre               `xml:"genre"`
+	Desc                []Desc                `xml:"desc"`
+	ResMD               []ResMD               `xml:"resMD"`
+	Description         []Description         `xml:"description"`
 	didlValidated
 }
 
@@ -129,10 +168,12 @@ func EmptyDocument() string {
 	return emptyDocument
 }
 
+// EmptyDocuments returns num space separated empty documents, as expected by
+// the EnqueuedURIsMetaData argument of AddMultipleURIsToQueue.
 func EmptyDocuments(num int) string {
-	var docs string
-	for i := 0; i < num; i++ {
-		docs = strings.Join([]string{docs, emptyDocument}, " ")
+	docs := make([]string, num)
+	for i := range docs {
+		docs[i] = emptyDocument
 	}
-	return docs
+	return strings.Join(docs, " ")
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package sonos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration parses the "H:MM:SS" durations used throughout the UPnP
+// services. An empty string and "NOT_IMPLEMENTED" parse as zero.
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" || s == "NOT_IMPLEMENTED" {
+		return 0, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	var values [3]int
+	for i, part := range parts {
+		// Fractional seconds ("0:00:01.500") are dropped.
+		if i == 2 {
+			part = strings.SplitN(part, ".", 2)[0]
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		values[i] = v
+	}
+
+	return time.Duration(values[0])*time.Hour +
+		time.Duration(values[1])*time.Minute +
+		time.Duration(values[2])*time.Second, nil
+}
+
+// FormatDuration formats d as "HH:MM:SS", truncating to whole seconds.
+// Negative durations are formatted as zero.
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	secs := int64(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
@@ -0,0 +1,134 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+)
+
+// MaxURIsPerEnqueue is the maximum number of URIs a player accepts in a
+// single AddMultipleURIsToQueue call.
+const MaxURIsPerEnqueue = 16
+
+// ErrQueueModified is returned by a bulk enqueue when adding a chunk failed
+// and the queue changed since the chunk was sent, so it is impossible to tell
+// whether the chunk made it in.
+var ErrQueueModified = errors.New("queue modified during enqueue")
+
+// EnqueueProgress is called after every chunk with the number of objects
+// enqueued so far and the total number of objects.
+type EnqueueProgress func(done, total int)
+
+// AddMultiple appends the objects to the end of the queue using as few
+// AddMultipleURIsToQueue calls as possible.
+//
+// It returns the number of objects that were enqueued. On error the caller
+// can resume with objects[n:]. A chunk whose call failed is only sent again
+// when the queue is known to be unchanged; otherwise ErrQueueModified is
+// returned since the chunk may have been applied.
+func (q *Queue) AddMultiple(ctx context.Context, objects []MediaObject, progress EnqueueProgress) (int, error) {
+	return q.addMultiple(ctx, 0, objects, progress)
+}
+
+// InsertMultiple is like AddMultiple but inserts the objects at index.
+func (q *Queue) InsertMultiple(ctx context.Context, index int, objects []MediaObject, progress EnqueueProgress) (int, error) {
+	if index < 0 {
+		return 0, errors.New("index out of range")
+	}
+	return q.addMultiple(ctx, uint32(index)+1, objects, progress)
+}
+
+// addMultiple enqueues objects in chunks starting at the 1 based track number
+// desired, or at the end of the queue when desired is 0.
+func (q *Queue) addMultiple(ctx context.Context, desired uint32, objects []MediaObject, progress EnqueueProgress) (int, error) {
+	added := 0
+	for added < len(objects) {
+		if err := ctx.Err(); err != nil {
+			return added, err
+		}
+
+		end := added + MaxURIsPerEnqueue
+		if end > len(objects) {
+			end = len(objects)
+		}
+		chunk := objects[added:end]
+
+		res, err := q.enqueueChunk(desired, chunk)
+		if err != nil {
+			return added, err
+		}
+
+		added += len(chunk)
+		if desired > 0 {
+			desired += res.NumTracksAdded
+		}
+
+		if progress != nil {
+			progress(added, len(objects))
+		}
+	}
+
+	return added, nil
+}
+
+// enqueueChunk adds a chunk through the UpdateID tracker. When the call fails
+// without a fault from the player, the chunk may have been applied anyway:
+// it is sent again if the UpdateID of the queue is still the one it was sent
+// with, and ErrQueueModified is returned otherwise. The UpdateID is compared
+// rather than the queue length since a container such as an album adds more
+// than one track.
+func (q *Queue) enqueueChunk(desired uint32, chunk []MediaObject) (*avt.AddMultipleURIsToQueueResponse, error) {
+	var (
+		res  *avt.AddMultipleURIsToQueueResponse
+		sent uint32
+		// called is false when fetching the UpdateID failed before the chunk
+		// was sent.
+		called bool
+	)
+	add := func(updateID uint32) (*uint32, error) {
+		sent, called = updateID, true
+		var err error
+		res, err = q.addChunk(desired, updateID, chunk)
+		if err != nil {
+			return nil, err
+		}
+		return &res.NewUpdateID, nil
+	}
+
+	err := q.tracker.do(add)
+	if _, fault := UPnPErrorCode(err); err == nil || fault || !called {
+		return res, err
+	}
+
+	current, ferr := q.fetchUpdateID()
+	if ferr != nil {
+		return nil, err
+	}
+	if current != sent {
+		return nil, ErrQueueModified
+	}
+	q.tracker.set(current)
+	if err := q.tracker.do(add); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (q *Queue) addChunk(desired, updateID uint32, chunk []MediaObject) (*avt.AddMultipleURIsToQueueResponse, error) {
+	uris := make([]string, len(chunk))
+	metadata := make([]string, len(chunk))
+	for i, m := range chunk {
+		uris[i] = m.URI
+		metadata[i] = m.Metadata()
+	}
+
+	return q.zp.AVTransport().AddMultipleURIsToQueue(&avt.AddMultipleURIsToQueueArgs{
+		UpdateID:                        updateID,
+		NumberOfURIs:                    uint32(len(chunk)),
+		EnqueuedURIs:                    strings.Join(uris, " "),
+		EnqueuedURIsMetaData:            strings.Join(metadata, " "),
+		DesiredFirstTrackNumberEnqueued: desired,
+	})
+}
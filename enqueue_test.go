@@ -0,0 +1,169 @@
+package sonos
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// queuePlayer answers Browse and AddMultipleURIsToQueue for a queue that
+// grows by tracksPer tracks for every URI. browseModes scripts the successive
+// Browse calls, which are dropped with "drop", and modes the successive
+// AddMultipleURIsToQueue calls:
+//
+//	ok          apply the chunk and respond
+//	stale       fault with the invalid UpdateID code
+//	fault       fault with another code
+//	drop        close the connection without applying the chunk
+//	apply-drop  apply the chunk, then close the connection
+type queuePlayer struct {
+	tracksPer   uint32
+	modes       []string
+	browseModes []string
+
+	updateID uint32
+	length   uint32
+	calls    int
+	browses  int
+}
+
+var (
+	updateIDArg = regexp.MustCompile(`<UpdateID>(\d+)</UpdateID>`)
+	numberArg   = regexp.MustCompile(`<NumberOfURIs>(\d+)</NumberOfURIs>`)
+)
+
+func (q *queuePlayer) respond(action, args string) (string, error) {
+	switch action {
+	case "Browse":
+		q.browses++
+		if q.browses <= len(q.browseModes) && q.browseModes[q.browses-1] == "drop" {
+			return "", errDrop
+		}
+		return fmt.Sprintf("<Result></Result><NumberReturned>0</NumberReturned>"+
+			"<TotalMatches>%d</TotalMatches><UpdateID>%d</UpdateID>", q.length, q.updateID), nil
+	case "AddMultipleURIsToQueue":
+	default:
+		return "", nil
+	}
+
+	mode := "ok"
+	if q.calls < len(q.modes) {
+		mode = q.modes[q.calls]
+	}
+	q.calls++
+
+	switch mode {
+	case "stale":
+		return "", testFault(errCodeInvalidUpdateID)
+	case "fault":
+		return "", testFault(701)
+	case "drop":
+		return "", errDrop
+	}
+
+	if m := updateIDArg.FindStringSubmatch(args); m == nil || m[1] != strconv.Itoa(int(q.updateID)) {
+		return "", testFault(errCodeInvalidUpdateID)
+	}
+	n, _ := strconv.Atoi(numberArg.FindStringSubmatch(args)[1])
+	first := q.length + 1
+	q.length += uint32(n) * q.tracksPer
+	q.updateID++
+
+	if mode == "apply-drop" {
+		return "", errDrop
+	}
+	return fmt.Sprintf("<FirstTrackNumberEnqueued>%d</FirstTrackNumberEnqueued><NumTracksAdded>%d</NumTracksAdded>"+
+		"<NewQueueLength>%d</NewQueueLength><NewUpdateID>%d</NewUpdateID>",
+		first, uint32(n)*q.tracksPer, q.length, q.updateID), nil
+}
+
+func TestAddMultiple(t *testing.T) {
+	objects := make([]MediaObject, 20)
+	for i := range objects {
+		objects[i] = MediaObject{URI: fmt.Sprintf("x-file-cifs://nas/music/%d.flac", i)}
+	}
+
+	for _, tt := range []struct {
+		name        string
+		tracksPer   uint32
+		modes       []string
+		browseModes []string
+		added       int
+		length      uint32
+		calls       int
+		err         error
+		code        int
+		// failed is set when another error than ErrQueueModified or a fault
+		// is expected.
+		failed bool
+	}{
+		{name: "tracks", tracksPer: 1, added: 20, length: 20, calls: 2},
+		{name: "containers", tracksPer: 12, added: 20, length: 240, calls: 2},
+		{name: "stale UpdateID", tracksPer: 1, modes: []string{"stale"}, added: 20, length: 20, calls: 3},
+		{name: "dropped before applying", tracksPer: 12, modes: []string{"ok", "drop"}, added: 20, length: 240, calls: 3},
+		{name: "dropped after applying", tracksPer: 12, modes: []string{"ok", "apply-drop"}, added: 16, length: 240, calls: 2, err: ErrQueueModified},
+		{name: "fault", tracksPer: 1, modes: []string{"fault"}, added: 0, length: 0, calls: 1, code: 701},
+		{name: "UpdateID fetch dropped", tracksPer: 1, browseModes: []string{"drop"}, added: 0, length: 0, calls: 0, failed: true},
+	} {
+		q := &queuePlayer{tracksPer: tt.tracksPer, modes: tt.modes, browseModes: tt.browseModes, updateID: 3}
+		p := newTestPlayer(q.respond)
+		zp := p.zonePlayer(t)
+
+		added, err := zp.GetQueue().AddMultiple(context.Background(), objects, nil)
+		p.Close()
+
+		if added != tt.added {
+			t.Errorf("%s: added %d, want %d", tt.name, added, tt.added)
+		}
+		if q.length != tt.length || q.calls != tt.calls {
+			t.Errorf("%s: queue length %d after %d calls, want %d after %d", tt.name, q.length, q.calls, tt.length, tt.calls)
+		}
+		switch {
+		case tt.failed:
+			if _, fault := UPnPErrorCode(err); err == nil || err == ErrQueueModified || fault {
+				t.Errorf("%s: err = %v, want the error of the request", tt.name, err)
+			}
+		case tt.code != 0:
+			if code, ok := UPnPErrorCode(err); !ok || code != tt.code {
+				t.Errorf("%s: err = %v, want UPnP error %d", tt.name, err, tt.code)
+			}
+		case err != tt.err:
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestInsertMultipleContainers(t *testing.T) {
+	q := &queuePlayer{tracksPer: 3, length: 5}
+	p := newTestPlayer(q.respond)
+	defer p.Close()
+	zp := p.zonePlayer(t)
+
+	objects := make([]MediaObject, 20)
+	for i := range objects {
+		objects[i] = MediaObject{URI: fmt.Sprintf("x-rincon-playlist:album%d", i)}
+	}
+	var progress []int
+	added, err := zp.GetQueue().InsertMultiple(context.Background(), 2, objects, func(done, total int) {
+		progress = append(progress, done)
+	})
+	if err != nil || added != 20 {
+		t.Fatalf("InsertMultiple = %d, %v", added, err)
+	}
+
+	// The second chunk goes after the 16 containers of the first, which
+	// added 48 tracks starting at track 3.
+	desired := regexp.MustCompile(`<DesiredFirstTrackNumberEnqueued>(\d+)<`)
+	var firsts []string
+	for _, r := range p.actions("AddMultipleURIsToQueue") {
+		firsts = append(firsts, desired.FindStringSubmatch(r)[1])
+	}
+	if fmt.Sprint(firsts) != "[3 51]" {
+		t.Errorf("chunks inserted at %v, want [3 51]", firsts)
+	}
+	if fmt.Sprint(progress) != "[16 20]" {
+		t.Errorf("progress %v, want [16 20]", progress)
+	}
+}
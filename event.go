@@ -39,11 +39,37 @@ type InstanceID struct {
 	NextTrackMetaData struct {
 		Value string `xml:"val,attr"`
 	} `xml:"NextTrackMetaData"`
+	SleepTimerGeneration struct {
+		Value string `xml:"val,attr"`
+	} `xml:"SleepTimerGeneration"`
+}
+
+// RenderingControlInstanceID holds the variables of a RenderingControl
+// LastChange event. Volume, Mute and Loudness are reported per channel.
+type RenderingControlInstanceID struct {
+	Volume []struct {
+		Channel string `xml:"channel,attr"`
+		Value   string `xml:"val,attr"`
+	} `xml:"Volume"`
+	Mute []struct {
+		Channel string `xml:"channel,attr"`
+		Value   string `xml:"val,attr"`
+	} `xml:"Mute"`
+	Bass struct {
+		Value string `xml:"val,attr"`
+	} `xml:"Bass"`
+	Treble struct {
+		Value string `xml:"val,attr"`
+	} `xml:"Treble"`
+	Loudness []struct {
+		Channel string `xml:"channel,attr"`
+		Value   string `xml:"val,attr"`
+	} `xml:"Loudness"`
 }
 
 // http://upnp.org/specs/av/UPnP-av-RenderingControl-v1-Service.pdf
 type RenderingControlLastChange struct {
-	InstanceID InstanceID `xml:"InstanceID"`
+	InstanceID RenderingControlInstanceID `xml:"InstanceID"`
 }
 
 // http://upnp.org/specs/av/UPnP-av-AVTransport-v1-Service.pdf
@@ -51,6 +77,16 @@ type AVTransportLastChange struct {
 	InstanceID InstanceID `xml:"InstanceID"`
 }
 
+// QueueLastChange is the LastChange payload of the Queue service.
+type QueueLastChange struct {
+	QueueID []struct {
+		Value    string `xml:"val,attr"`
+		UpdateID struct {
+			Value string `xml:"val,attr"`
+		} `xml:"UpdateID"`
+	} `xml:"QueueID"`
+}
+
 func (e *AVTransportLastChange) String() string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "TransportState: %s\n", e.InstanceID.TransportState.Value)
@@ -59,27 +95,25 @@ func (e *AVTransportLastChange) String() string {
 	fmt.Fprintf(&b, "CurrentTrack: %s\n", e.InstanceID.CurrentTrack.Value)
 	fmt.Fprintf(&b, "CurrentTrackDuration: %s\n", e.InstanceID.CurrentTrackDuration.Value)
 	fmt.Fprintf(&b, "CurrentTrackURI: %s\n", e.InstanceID.CurrentTrackURI.Value)
-
-	metadata, err := ParseDIDL(e.InstanceID.CurrentTrackMetaData.Value)
-	if err == nil && len(metadata.Item) > 0 {
-		m := metadata.Item[0]
-
-		fmt.Fprintf(&b, "CurrentTrackMetaData>Title: %s\n", m.Title[0].Value)
-		fmt.Fprintf(&b, "CurrentTrackMetaData>Album: %s\n", m.Album[0].Value)
-		fmt.Fprintf(&b, "CurrentTrackMetaData>Creator: %s\n", m.Creator[0].Value)
-		fmt.Fprintf(&b, "CurrentTrackMetaData>AlbumArtURI: %s\n", m.AlbumArtURI[0].Value)
-	}
+	writeTrackMetadata(&b, "CurrentTrackMetaData", e.InstanceID.CurrentTrackMetaData.Value)
 
 	fmt.Fprintf(&b, "NextTrackURI: %s\n", e.InstanceID.NextTrackURI.Value)
-	metadata, err = ParseDIDL(e.InstanceID.NextTrackMetaData.Value)
-	if err == nil && len(metadata.Item) > 0 {
-		m := metadata.Item[0]
+	writeTrackMetadata(&b, "NextTrackMetaData", e.InstanceID.NextTrackMetaData.Value)
 
-		fmt.Fprintf(&b, "NextTrackMetaData>Title: %s\n", m.Title[0].Value)
-		fmt.Fprintf(&b, "NextTrackMetaData>Album: %s\n", m.Album[0].Value)
-		fmt.Fprintf(&b, "NextTrackMetaData>Creator: %s\n", m.Creator[0].Value)
-		fmt.Fprintf(&b, "NextTrackMetaData>AlbumArtURI: %s\n", m.AlbumArtURI[0].Value)
+	return b.String()
+}
+
+// writeTrackMetadata writes the fields of DIDL-Lite track metadata. Radio
+// stations and inputs often lack some of them.
+func writeTrackMetadata(b *strings.Builder, prefix, raw string) {
+	metadata, err := ParseDIDL(raw)
+	if err != nil || len(metadata.Item) == 0 {
+		return
 	}
+	m := metadata.Item[0]
 
-	return b.String()
+	fmt.Fprintf(b, "%s>Title: %s\n", prefix, firstTitle(m.Title))
+	fmt.Fprintf(b, "%s>Album: %s\n", prefix, firstAlbum(m.Album))
+	fmt.Fprintf(b, "%s>Creator: %s\n", prefix, firstCreator(m.Creator))
+	fmt.Fprintf(b, "%s>AlbumArtURI: %s\n", prefix, firstAlbumArtURI(m.AlbumArtURI))
 }
@@ -7,7 +7,6 @@ import (
 	"github.com/caglar10ur/sonos"
 	avtransport "github.com/caglar10ur/sonos/services/AVTransport"
 	contentdirectory "github.com/caglar10ur/sonos/services/ContentDirectory"
-	grouprenderingcontrol "github.com/caglar10ur/sonos/services/GroupRenderingControl"
 )
 
 func main() {
@@ -39,11 +38,15 @@ func main() {
 	for _, zp := range zps {
 		fmt.Printf("Connected to %s\t%s\t%s (coordinator %t)\n", zp.RoomName(), zp.ModelName(), zp.SerialNum(), zp.IsCoordinator())
 
-		zp.GroupRenderingControl.SetGroupVolume(&grouprenderingcontrol.SetGroupVolumeArgs{
-			DesiredVolume: 10,
-		})
+		group, err := zp.Group()
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+		if err := group.SetGroupVolume(10); err != nil {
+			log.Fatalf("%s", err)
+		}
 
-		az, err := zp.AVTransport.GetPositionInfo(&avtransport.GetPositionInfoArgs{})
+		az, err := zp.AVTransport().GetPositionInfo(&avtransport.GetPositionInfoArgs{})
 		if err != nil {
 			log.Fatalf("%s", err)
 		}
@@ -66,7 +69,7 @@ func main() {
 			}
 		}
 
-		ac, err := zp.ContentDirectory.Browse(
+		ac, err := zp.ContentDirectory().Browse(
 			&contentdirectory.BrowseArgs{
 				ObjectID:       "Q:0",
 				BrowseFlag:     "BrowseDirectChildren",
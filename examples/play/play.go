@@ -18,7 +18,14 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
 	defer cancel()
 
-	son, err := sonos.NewSonos()
+	var opts []sonos.SonosOption
+	if path, err := sonos.DefaultHouseholdCachePath(); err == nil {
+		if cache, err := sonos.OpenHouseholdCache(path); err == nil {
+			opts = append(opts, sonos.WithHouseholdCache(cache))
+		}
+	}
+
+	son, err := sonos.NewSonos(opts...)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
@@ -37,21 +37,21 @@ func main() {
 	if zp.IsCoordinator() {
 		fmt.Printf("Connected to %s\t%s\t%s (coordinator %t)\n", zp.RoomName(), zp.ModelName(), zp.SerialNum(), zp.IsCoordinator())
 
-		sid, err := son.Subscribe(ctx, zp, zp.AVTransport)
+		sid, err := son.Subscribe(ctx, zp, zp.AVTransport())
 		if err != nil {
 			log.Fatalf("%s", err)
 		}
 
 		time.Sleep(10 * time.Second)
 
-		err = son.Renew(ctx, zp, zp.AVTransport, sid)
+		err = son.Renew(ctx, zp, zp.AVTransport(), sid)
 		if err != nil {
 			log.Fatalf("%s", err)
 		}
 
 		time.Sleep(10 * time.Second)
 
-		err = son.Unsubscribe(ctx, zp, zp.AVTransport, sid)
+		err = son.Unsubscribe(ctx, zp, zp.AVTransport(), sid)
 		if err != nil {
 			log.Fatalf("%s", err)
 		}
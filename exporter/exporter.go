@@ -0,0 +1,192 @@
+// Package exporter serves Prometheus metrics about the players of a Sonos
+// household and about the library itself. Player metrics are gathered with
+// Get calls on every scrape; library metrics come from sonos.Metrics.
+package exporter
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/caglar10ur/sonos"
+)
+
+// transportStates are the values of the transport state metric.
+var transportStates = []string{"PLAYING", "PAUSED_PLAYBACK", "STOPPED", "TRANSITIONING"}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+type Handler struct {
+	sonos   *sonos.Sonos
+	metrics *sonos.Metrics
+}
+
+// NewHandler returns a handler reporting the players found by s. Library
+// metrics are reported if m is not nil; s should then be created with
+// sonos.WithMetrics(m).
+func NewHandler(s *sonos.Sonos, m *sonos.Metrics) *Handler {
+	return &Handler{
+		sonos:   s,
+		metrics: m,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reg := newRegistry()
+	h.collectPlayers(reg)
+	h.collectLibrary(reg)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	reg.write(w)
+}
+
+func (h *Handler) collectPlayers(reg *registry) {
+	households := h.sonos.Households()
+	reg.add("sonos_households", "Number of households found.", typeGauge, float64(len(households)))
+
+	var wg sync.WaitGroup
+	for _, household := range households {
+		players := household.Players()
+		if len(players) == 0 {
+			continue
+		}
+		zoneGroupState, err := household.ZoneGroupState()
+		if err != nil {
+			continue
+		}
+
+		coordinators := make(map[string]*sonos.ZonePlayer)
+		for _, zp := range players {
+			coordinators[zp.UUID()] = zp
+		}
+
+		rooms := 0
+		for _, group := range zoneGroupState.ZoneGroups {
+			var members []sonos.ZoneGroupMember
+			for _, member := range group.ZoneGroupMember {
+				if member.Invisible != "1" {
+					members = append(members, member)
+				}
+			}
+			if len(members) == 0 {
+				continue
+			}
+			rooms += len(members)
+			reg.add("sonos_group_members", "Number of rooms in the group.", typeGauge, float64(len(members)),
+				"household", household.ID, "group", group.ID)
+
+			for _, member := range group.ZoneGroupMember {
+				h.collectNetwork(reg, &member)
+			}
+
+			coordinator, ok := coordinators[group.Coordinator]
+			if !ok {
+				continue
+			}
+			wg.Add(1)
+			go func(coordinator *sonos.ZonePlayer, members []sonos.ZoneGroupMember) {
+				defer wg.Done()
+				h.collectGroup(reg, coordinator, members)
+			}(coordinator, members)
+		}
+
+		reg.add("sonos_groups", "Number of groups in the household.", typeGauge, float64(len(zoneGroupState.ZoneGroups)),
+			"household", household.ID)
+		reg.add("sonos_rooms", "Number of rooms in the household.", typeGauge, float64(rooms),
+			"household", household.ID)
+	}
+	wg.Wait()
+}
+
+// collectNetwork reports the network attributes of a device, including the
+// invisible members of bonded rooms.
+func (h *Handler) collectNetwork(reg *registry, member *sonos.ZoneGroupMember) {
+	kv := []string{"room", member.ZoneName, "uuid", member.UUID}
+	if v, err := strconv.Atoi(member.WirelessMode); err == nil {
+		reg.add("sonos_player_wireless_mode", "Wireless mode of the player, 0 when wired.", typeGauge, float64(v), kv...)
+	}
+	if v, err := strconv.Atoi(member.ChannelFreq); err == nil {
+		reg.add("sonos_player_channel_frequency_mhz", "Frequency of the wireless channel of the player.", typeGauge, float64(v), kv...)
+	}
+}
+
+// collectGroup reports the transport of the group and the rendering of its
+// members.
+func (h *Handler) collectGroup(reg *registry, coordinator *sonos.ZonePlayer, members []sonos.ZoneGroupMember) {
+	nowPlaying, err := coordinator.NowPlaying()
+
+	// Group reuses the players of the members as long as their location
+	// does not change.
+	players := make(map[string]*sonos.ZonePlayer)
+	if g, gerr := coordinator.Group(); gerr == nil {
+		for _, zp := range g.Members {
+			players[zp.UUID()] = zp
+		}
+	}
+
+	for _, member := range members {
+		kv := []string{"room", member.ZoneName, "uuid", member.UUID}
+
+		zp := players[member.UUID]
+		if zp == nil {
+			reg.add("sonos_player_up", "Whether the player responded.", typeGauge, 0, kv...)
+			continue
+		}
+		volume, verr := zp.GetVolume()
+		mute, merr := zp.GetMute()
+		if verr != nil || merr != nil {
+			reg.add("sonos_player_up", "Whether the player responded.", typeGauge, 0, kv...)
+			continue
+		}
+
+		reg.add("sonos_player_up", "Whether the player responded.", typeGauge, 1, kv...)
+		reg.add("sonos_player_info", "Model and software of the player.", typeGauge, 1,
+			append(kv, "model", zp.ModelName(), "software_version", member.SoftwareVersion)...)
+		reg.add("sonos_player_volume", "Volume of the player, 0 to 100.", typeGauge, float64(volume), kv...)
+		reg.add("sonos_player_mute", "Whether the player is muted.", typeGauge, boolValue(mute), kv...)
+
+		if err != nil {
+			continue
+		}
+		for _, state := range transportStates {
+			reg.add("sonos_player_transport_state", "Transport state of the group of the player.", typeGauge,
+				boolValue(nowPlaying.TransportState == state), append(kv, "state", state)...)
+		}
+		reg.add("sonos_player_track_position_seconds", "Position in the current track.", typeGauge,
+			nowPlaying.Position.Seconds(), kv...)
+		reg.add("sonos_player_track_duration_seconds", "Duration of the current track, 0 for streams.", typeGauge,
+			nowPlaying.Duration.Seconds(), kv...)
+	}
+}
+
+func (h *Handler) collectLibrary(reg *registry) {
+	for service, n := range h.sonos.ActiveSubscriptions() {
+		reg.add("sonos_subscriptions_active", "Number of event subscriptions.", typeGauge, float64(n),
+			"service", service)
+	}
+
+	if h.metrics == nil {
+		return
+	}
+	for _, c := range h.metrics.Calls() {
+		kv := []string{"service", c.Service, "action", c.Action}
+		reg.addHistogram("sonos_soap_call_duration_seconds", "Latency of SOAP calls.",
+			sonos.LatencyBuckets, c.Buckets, c.Count, c.Sum.Seconds(), kv...)
+		reg.add("sonos_soap_call_errors_total", "Number of failed SOAP calls, including faults.", typeCounter,
+			float64(c.Errors), kv...)
+	}
+	for service, n := range h.metrics.RenewFailures() {
+		reg.add("sonos_subscription_renew_failures_total", "Number of failed subscription renewals.", typeCounter,
+			float64(n), "service", service)
+	}
+	for service, n := range h.metrics.Events() {
+		reg.add("sonos_events_total", "Number of events received.", typeCounter, float64(n),
+			"service", service)
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
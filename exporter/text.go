@@ -0,0 +1,140 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types of the Prometheus text exposition format.
+const (
+	typeGauge     = "gauge"
+	typeCounter   = "counter"
+	typeHistogram = "histogram"
+)
+
+// labels are the labels of a sample, written in the given order.
+type labels []string
+
+// family is a metric family: its samples must be written together.
+type family struct {
+	name    string
+	help    string
+	typ     string
+	samples []sample
+}
+
+type sample struct {
+	suffix string
+	labels labels
+	value  float64
+}
+
+// registry collects the samples of a scrape. It is safe for concurrent use.
+type registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func newRegistry() *registry {
+	return &registry{families: make(map[string]*family)}
+}
+
+// add records a sample of the family name. Labels are given as name, value
+// pairs.
+func (r *registry) add(name, help, typ string, value float64, kv ...string) {
+	r.addSample(name, help, typ, "", value, kv)
+}
+
+func (r *registry) addSample(name, help, typ, suffix string, value float64, kv labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, typ: typ}
+		r.families[name] = f
+	}
+	f.samples = append(f.samples, sample{suffix: suffix, labels: kv, value: value})
+}
+
+// addHistogram records a histogram from non-cumulative bucket counts.
+func (r *registry) addHistogram(name, help string, bounds []float64, counts []uint64, count uint64, sum float64, kv ...string) {
+	var cumulative uint64
+	for i, le := range bounds {
+		cumulative += counts[i]
+		r.addSample(name, help, typeHistogram, "_bucket", float64(cumulative), withLabel(kv, "le", formatFloat(le)))
+	}
+	r.addSample(name, help, typeHistogram, "_bucket", float64(count), withLabel(kv, "le", "+Inf"))
+	r.addSample(name, help, typeHistogram, "_sum", sum, kv)
+	r.addSample(name, help, typeHistogram, "_count", float64(count), kv)
+}
+
+// withLabel returns a copy of kv with the label name added.
+func withLabel(kv labels, name, value string) labels {
+	return append(append(labels{}, kv...), name, value)
+}
+
+// write writes the families ordered by name in the text exposition format.
+func (r *registry) write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := bufio.NewWriter(w)
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.typ)
+		for _, s := range f.samples {
+			b.WriteString(f.name + s.suffix)
+			if len(s.labels) > 0 {
+				b.WriteByte('{')
+				for i := 0; i+1 < len(s.labels); i += 2 {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(b, "%s=\"%s\"", s.labels[i], escapeLabel(s.labels[i+1]))
+				}
+				b.WriteByte('}')
+			}
+			b.WriteString(" " + formatFloat(s.value) + "\n")
+		}
+	}
+	return b.Flush()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	for _, tt := range []struct {
+		in    string
+		help  string
+		label string
+	}{
+		{`Living Room`, `Living Room`, `Living Room`},
+		{`Kid's "Room"`, `Kid's "Room"`, `Kid's \"Room\"`},
+		{`C:\Music`, `C:\\Music`, `C:\\Music`},
+		{"two\nlines", `two\nlines`, `two\nlines`},
+		{`\"`, `\\"`, `\\\"`},
+	} {
+		if got := escapeHelp(tt.in); got != tt.help {
+			t.Errorf("escapeHelp(%q) = %q, want %q", tt.in, got, tt.help)
+		}
+		if got := escapeLabel(tt.in); got != tt.label {
+			t.Errorf("escapeLabel(%q) = %q, want %q", tt.in, got, tt.label)
+		}
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	for _, tt := range []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{0.005, "0.005"},
+		{2.5, "2.5"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	} {
+		if got := formatFloat(tt.v); got != tt.want {
+			t.Errorf("formatFloat(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestRegistryWrite(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		fill func(r *registry)
+		want string
+	}{
+		{
+			name: "families sorted, samples in order",
+			fill: func(r *registry) {
+				r.add("sonos_player_volume", "Volume.", typeGauge, 20, "room", "Kitchen")
+				r.add("sonos_groups", "Groups.", typeGauge, 2)
+				r.add("sonos_player_volume", "Volume.", typeGauge, 35, "room", `Den "East"`)
+			},
+			want: "# HELP sonos_groups Groups.\n" +
+				"# TYPE sonos_groups gauge\n" +
+				"sonos_groups 2\n" +
+				"# HELP sonos_player_volume Volume.\n" +
+				"# TYPE sonos_player_volume gauge\n" +
+				"sonos_player_volume{room=\"Kitchen\"} 20\n" +
+				"sonos_player_volume{room=\"Den \\\"East\\\"\"} 35\n",
+		},
+		{
+			name: "escaped help",
+			fill: func(r *registry) {
+				r.add("sonos_up", "Up\nor down, see C:\\docs.", typeGauge, 1)
+			},
+			want: "# HELP sonos_up Up\\nor down, see C:\\\\docs.\n" +
+				"# TYPE sonos_up gauge\n" +
+				"sonos_up 1\n",
+		},
+		{
+			name: "histogram",
+			fill: func(r *registry) {
+				r.addHistogram("sonos_call_seconds", "Calls.", []float64{0.1, 0.5, 1}, []uint64{2, 0, 3}, 6, 4.25,
+					"service", "AVTransport", "action", "Play")
+			},
+			want: "# HELP sonos_call_seconds Calls.\n" +
+				"# TYPE sonos_call_seconds histogram\n" +
+				"sonos_call_seconds_bucket{service=\"AVTransport\",action=\"Play\",le=\"0.1\"} 2\n" +
+				"sonos_call_seconds_bucket{service=\"AVTransport\",action=\"Play\",le=\"0.5\"} 2\n" +
+				"sonos_call_seconds_bucket{service=\"AVTransport\",action=\"Play\",le=\"1\"} 5\n" +
+				"sonos_call_seconds_bucket{service=\"AVTransport\",action=\"Play\",le=\"+Inf\"} 6\n" +
+				"sonos_call_seconds_sum{service=\"AVTransport\",action=\"Play\"} 4.25\n" +
+				"sonos_call_seconds_count{service=\"AVTransport\",action=\"Play\"} 6\n",
+		},
+		{
+			name: "histogram without labels",
+			fill: func(r *registry) {
+				r.addHistogram("sonos_seconds", "Seconds.", []float64{1}, []uint64{0}, 0, 0)
+			},
+			want: "# HELP sonos_seconds Seconds.\n" +
+				"# TYPE sonos_seconds histogram\n" +
+				"sonos_seconds_bucket{le=\"1\"} 0\n" +
+				"sonos_seconds_bucket{le=\"+Inf\"} 0\n" +
+				"sonos_seconds_sum 0\n" +
+				"sonos_seconds_count 0\n",
+		},
+	} {
+		r := newRegistry()
+		tt.fill(r)
+		var b bytes.Buffer
+		if err := r.write(&b); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, b.String(), tt.want)
+		}
+	}
+}
+
+func TestHistogramLabelsNotShared(t *testing.T) {
+	kv := make(labels, 2, 8)
+	kv[0], kv[1] = "service", "Queue"
+
+	r := newRegistry()
+	r.addHistogram("h", "H.", []float64{1, 2}, []uint64{1, 1}, 2, 1.5, kv...)
+
+	for _, s := range r.families["h"].samples {
+		if s.suffix == "_bucket" && s.labels[len(s.labels)-2] != "le" {
+			t.Errorf("bucket labels %v do not end with le", s.labels)
+		}
+	}
+	if b := r.families["h"].samples; b[0].labels[3] != "1" || b[1].labels[3] != "2" {
+		t.Errorf("bucket bounds %v, %v share their labels", b[0].labels, b[1].labels)
+	}
+}
@@ -0,0 +1,19 @@
+package sonos
+
+import "errors"
+
+// upnpFault is implemented by the UPnPError of every service package.
+type upnpFault interface {
+	error
+	UPnPErrorCode() int
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault a player returned,
+// if err is or wraps one.
+func UPnPErrorCode(err error) (int, bool) {
+	var f upnpFault
+	if errors.As(err, &f) {
+		return f.UPnPErrorCode(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,141 @@
+package sonos
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+)
+
+// Well known ContentDirectory object IDs of Sonos managed content.
+const (
+	SonosFavorites     = "FV:2"
+	SonosRadioStations = "R:0/0"
+	SonosPlaylists     = "SQ:"
+)
+
+// streamURIPrefixes are URI schemes that are played directly through
+// SetAVTransportURI rather than through the queue.
+var streamURIPrefixes = []string{
+	"x-sonosapi-stream:",
+	"x-sonosapi-radio:",
+	"x-sonosapi-hls:",
+	"x-sonosapi-hls-static:",
+	"x-rincon-mp3radio:",
+	"x-rincon-stream:",
+	"x-sonos-htastream:",
+	"hls-radio:",
+	"aac:",
+}
+
+// IsStream reports whether the object is a stream (radio station, line-in,
+// TV) that has to be played directly instead of being added to the queue.
+func (m *MediaObject) IsStream() bool {
+	for _, prefix := range streamURIPrefixes {
+		if strings.HasPrefix(m.URI, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Favorites lists the Sonos favorites of the household.
+func (z *ZonePlayer) Favorites(ctx context.Context) ([]MediaObject, error) {
+	return z.Browse(ctx, SonosFavorites).MediaObjects()
+}
+
+// Favorite looks up a Sonos favorite by name, ignoring case.
+func (z *ZonePlayer) Favorite(ctx context.Context, name string) (*MediaObject, error) {
+	return z.findByTitle(ctx, SonosFavorites, name)
+}
+
+// PlayFavorite looks up a Sonos favorite by name and starts playing it.
+func (z *ZonePlayer) PlayFavorite(ctx context.Context, name string) error {
+	fav, err := z.Favorite(ctx, name)
+	if err != nil {
+		return err
+	}
+	return z.PlayMedia(*fav)
+}
+
+// RadioStations lists the radio stations saved under "My Radio Stations".
+func (z *ZonePlayer) RadioStations(ctx context.Context) ([]MediaObject, error) {
+	return z.Browse(ctx, SonosRadioStations).MediaObjects()
+}
+
+// PlayRadioStation looks up a saved radio station by name and starts playing
+// it.
+func (z *ZonePlayer) PlayRadioStation(ctx context.Context, name string) error {
+	station, err := z.findByTitle(ctx, SonosRadioStations, name)
+	if err != nil {
+		return err
+	}
+	return z.PlayMedia(*station)
+}
+
+// PlayMedia starts playing the given object. Streams are played directly,
+// everything else is appended to the queue and played from there.
+func (z *ZonePlayer) PlayMedia(m MediaObject) error {
+	if m.IsStream() {
+		_, err := z.AVTransport().SetAVTransportURI(&avt.SetAVTransportURIArgs{
+			CurrentURI:         m.URI,
+			CurrentURIMetaData: m.Metadata(),
+		})
+		if err != nil {
+			return err
+		}
+		return z.Play()
+	}
+
+	res, err := z.AVTransport().AddURIToQueue(&avt.AddURIToQueueArgs{
+		EnqueuedURI:         m.URI,
+		EnqueuedURIMetaData: m.Metadata(),
+	})
+	if err != nil {
+		return err
+	}
+	return z.playFromQueue(res.FirstTrackNumberEnqueued)
+}
+
+// QueueURI returns the transport URI that makes the player play from its
+// own queue.
+func (z *ZonePlayer) QueueURI() string {
+	return "x-rincon-queue:" + z.UUID() + "#0"
+}
+
+// playFromQueue switches the transport to the queue and starts playing at
+// the given 1 based track number.
+func (z *ZonePlayer) playFromQueue(track uint32) error {
+	_, err := z.AVTransport().SetAVTransportURI(&avt.SetAVTransportURIArgs{
+		CurrentURI: z.QueueURI(),
+	})
+	if err != nil {
+		return err
+	}
+	if track > 0 {
+		_, err = z.AVTransport().Seek(&avt.SeekArgs{
+			Unit:   "TRACK_NR",
+			Target: strconv.FormatUint(uint64(track), 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return z.Play()
+}
+
+// findByTitle returns the child of objectID titled name, ignoring case.
+func (z *ZonePlayer) findByTitle(ctx context.Context, objectID, name string) (*MediaObject, error) {
+	it := z.Browse(ctx, objectID)
+	for it.Next() {
+		if strings.EqualFold(it.Object().Title(), name) {
+			m := NewMediaObject(it.Object())
+			return &m, nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFound
+}
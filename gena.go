@@ -0,0 +1,145 @@
+package sonos
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// pendingSIDTimeout bounds how long an event for an unknown SID waits
+	// for a Subscribe in flight to register it.
+	pendingSIDTimeout = 2 * time.Second
+	pendingSIDRetry   = 20 * time.Millisecond
+)
+
+// subscription is an event subscription made with Subscribe. It tracks the
+// SEQ of the last event to detect duplicates and gaps.
+type subscription struct {
+	zp      *ZonePlayer
+	service SonosService
+
+	mu   sync.Mutex
+	seq  uint32
+	seen bool
+}
+
+// sequence classifies the SEQ of an incoming event.
+type sequence int
+
+const (
+	seqNext sequence = iota
+	seqStale
+	seqGap
+)
+
+// check records seq and reports whether it is the expected one, a stale or
+// duplicate one, or one past a gap.
+func (sub *subscription) check(seq uint32) sequence {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if !sub.seen {
+		sub.seen = true
+		sub.seq = seq
+		if seq != 0 {
+			// The initial event, carrying the full state, was missed.
+			return seqGap
+		}
+		return seqNext
+	}
+
+	expected := sub.seq + 1
+	if expected == 0 {
+		// SEQ wraps to 1, 0 is reserved for the initial event.
+		expected = 1
+	}
+	switch diff := int32(seq - expected); {
+	case diff < 0:
+		return seqStale
+	case diff > 0:
+		sub.seq = seq
+		return seqGap
+	}
+	sub.seq = seq
+	return seqNext
+}
+
+// parseSEQ parses the SEQ header of a NOTIFY request.
+func parseSEQ(v string) (uint32, error) {
+	seq, err := strconv.ParseUint(v, 10, 32)
+	return uint32(seq), err
+}
+
+func (s *Sonos) addSubscription(sid string, zp *ZonePlayer, service SonosService) {
+	s.subscriptions.Store(sid, &subscription{zp: zp, service: service})
+}
+
+func (s *Sonos) removeSubscription(sid string) {
+	s.subscriptions.Delete(sid)
+}
+
+func (s *Sonos) lookupSubscription(sid string) (*subscription, bool) {
+	sub, ok := s.subscriptions.Load(sid)
+	if !ok {
+		return nil, false
+	}
+	return sub.(*subscription), true
+}
+
+// awaitSubscription looks sid up. While Subscribe calls are in flight an
+// unknown SID may be theirs, so the lookup is retried for a short while.
+func (s *Sonos) awaitSubscription(ctx context.Context, sid string) (*subscription, bool) {
+	deadline := time.Now().Add(pendingSIDTimeout)
+	for {
+		if sub, ok := s.lookupSubscription(sid); ok {
+			return sub, true
+		}
+		if atomic.LoadInt32(&s.subscribing) == 0 || time.Now().After(deadline) {
+			return nil, false
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(pendingSIDRetry):
+		}
+	}
+}
+
+// Resync fetches the state normally delivered by the events of service
+// through its Get actions, after events were missed.
+func (z *ZonePlayer) Resync(service SonosService) error {
+	switch service {
+	case z.AlarmClock():
+		z.alarms.invalidate()
+		_, err := z.Alarms()
+		return err
+
+	case z.AVTransport():
+		if err := z.GetSleepTimer().Refresh(); err != nil {
+			return err
+		}
+		return z.GetState().populateTransport()
+
+	case z.RenderingControl():
+		return z.GetState().populateRendering()
+
+	case z.GroupRenderingControl():
+		return z.GetState().populateGroupRendering()
+
+	case z.Queue():
+		return z.GetQueue().sync()
+
+	case z.ZoneGroupTopology():
+		return z.GetState().populateTopology()
+
+	case z.DeviceProperties():
+		return z.GetState().populateDevice()
+
+	case z.AudioIn():
+		// LineInConnected cannot be read, the next event carries it.
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package sonos
+
+import (
+	"fmt"
+	"testing"
+)
+
+func (s sequence) String() string {
+	return [...]string{"next", "stale", "gap"}[s]
+}
+
+func TestSubscriptionCheck(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		seqs []uint32
+		want []sequence
+	}{
+		{"in order", []uint32{0, 1, 2, 3}, []sequence{seqNext, seqNext, seqNext, seqNext}},
+		{"initial event missed", []uint32{2, 3}, []sequence{seqGap, seqNext}},
+		{"duplicate", []uint32{0, 1, 1, 2}, []sequence{seqNext, seqNext, seqStale, seqNext}},
+		{"out of order", []uint32{0, 2, 1, 3}, []sequence{seqNext, seqGap, seqStale, seqNext}},
+		{"gap", []uint32{0, 1, 5, 6}, []sequence{seqNext, seqNext, seqGap, seqNext}},
+		{"wraps to 1", []uint32{0xfffffffe, 0xffffffff, 1, 2}, []sequence{seqGap, seqNext, seqNext, seqNext}},
+		{"gap across the wrap", []uint32{0xfffffffe, 2}, []sequence{seqGap, seqGap}},
+		{"stale across the wrap", []uint32{0xffffffff, 1, 0xffffffff}, []sequence{seqGap, seqNext, seqStale}},
+	} {
+		sub := &subscription{}
+		var got []sequence
+		for _, seq := range tt.seqs {
+			got = append(got, sub.check(seq))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: SEQs %v are %v, want %v", tt.name, tt.seqs, got, tt.want)
+		}
+	}
+}
+
+func TestParseSEQ(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		seq    uint32
+		err    bool
+	}{
+		{"0", 0, false},
+		{"42", 42, false},
+		{"4294967295", 0xffffffff, false},
+		{"4294967296", 0, true},
+		{"-1", 0, true},
+		{"", 0, true},
+	} {
+		seq, err := parseSEQ(tt.header)
+		if (err != nil) != tt.err || (err == nil && seq != tt.seq) {
+			t.Errorf("parseSEQ(%q) = %d, %v", tt.header, seq, err)
+		}
+	}
+}
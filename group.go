@@ -0,0 +1,247 @@
+package sonos
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+	rcg "github.com/caglar10ur/sonos/services/GroupRenderingControl"
+)
+
+// Group is a set of rooms playing in sync. Group wide actions are sent to
+// the coordinator.
+type Group struct {
+	ID          string
+	Coordinator *ZonePlayer
+	Members     []*ZonePlayer
+}
+
+// Group returns the group the player is currently part of. Players for the
+// other members are created with the same HTTP client and volume caps, and
+// reused by later calls.
+func (z *ZonePlayer) Group() (*Group, error) {
+	zoneGroupState, err := z.GetZoneGroupState()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range zoneGroupState.ZoneGroups {
+		if !group.hasMember(z.UUID()) {
+			continue
+		}
+
+		g := &Group{ID: group.ID}
+		for _, member := range group.ZoneGroupMember {
+			// Invisible members are the secondary speakers of bonded
+			// rooms, they are controlled through the primary.
+			if member.Invisible == "1" {
+				continue
+			}
+
+			zp := z
+			if member.UUID != z.UUID() {
+				if zp, err = z.peer(&member); err != nil {
+					return nil, err
+				}
+			}
+			g.Members = append(g.Members, zp)
+			if member.UUID == group.Coordinator {
+				g.Coordinator = zp
+			}
+		}
+
+		if g.Coordinator == nil {
+			return nil, errors.New("group coordinator not found")
+		}
+		return g, nil
+	}
+
+	return nil, errors.New("player is not part of any group")
+}
+
+// peers holds the players created for the devices of a household so that
+// they are reused rather than created again. A player and the peers created
+// from it share one; so do the players created by a Sonos.
+type peers struct {
+	mu      sync.Mutex
+	players map[string]*ZonePlayer
+}
+
+func newPeers() *peers {
+	return &peers{players: make(map[string]*ZonePlayer)}
+}
+
+// get returns the player of uuid if it is still at location.
+func (p *peers) get(uuid, location string) (*ZonePlayer, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	zp, ok := p.players[uuid]
+	if !ok || zp.location.String() != location {
+		return nil, false
+	}
+	return zp, true
+}
+
+// add stores zp as the player of uuid and returns it. A player stored for
+// the same location in the meantime is kept and returned instead; one at
+// another location, e.g. after the device got a new IP address, is replaced.
+func (p *peers) add(uuid string, zp *ZonePlayer) *ZonePlayer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if known, ok := p.players[uuid]; ok && known.location.String() == zp.location.String() {
+		return known
+	}
+	p.players[uuid] = zp
+	return zp
+}
+
+// peer returns the player of another device of the household, creating it
+// on first use.
+func (z *ZonePlayer) peer(member *ZoneGroupMember) (*ZonePlayer, error) {
+	if zp, ok := z.peers.get(member.UUID, member.Location); ok {
+		return zp, nil
+	}
+	zp, err := z.newPeer(member)
+	if err != nil {
+		return nil, err
+	}
+	return z.peers.add(member.UUID, zp), nil
+}
+
+// newPeer creates a player for another device of the household sharing the
+// client, volume caps, description cache and peers of z.
+func (z *ZonePlayer) newPeer(member *ZoneGroupMember) (*ZonePlayer, error) {
+	u, err := url.Parse(member.Location)
+	if err != nil {
+		return nil, err
+	}
+	opts := []ZonePlayerOption{
+		WithLocation(u),
+		WithClient(z.client),
+		WithVolumeLimits(z.volumeLimits),
+		WithEventErrorHandler(z.errorHandler),
+		withPeers(z.peers),
+	}
+	if z.descriptions != nil {
+		opts = append(opts, WithDescriptionCache(z.descriptions, member.UUID, member.SoftwareVersion))
+	}
+	return NewZonePlayer(opts...)
+}
+
+func (g *ZoneGroup) hasMember(uuid string) bool {
+	for _, member := range g.ZoneGroupMember {
+		if member.UUID == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot stores the relative volumes of the members so that subsequent
+// group volume changes keep them in proportion.
+func (g *Group) snapshot() error {
+	_, err := g.Coordinator.GroupRenderingControl().SnapshotGroupVolume(&rcg.SnapshotGroupVolumeArgs{})
+	return err
+}
+
+// GroupVolume returns the volume of the group, the average of its members.
+func (g *Group) GroupVolume() (int, error) {
+	res, err := g.Coordinator.GroupRenderingControl().GetGroupVolume(&rcg.GetGroupVolumeArgs{})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.CurrentVolume), nil
+}
+
+// SetGroupVolume sets the volume of the group keeping the members in
+// proportion. Members are then held to their volume caps.
+func (g *Group) SetGroupVolume(volume int) error {
+	if err := g.snapshot(); err != nil {
+		return err
+	}
+	_, err := g.Coordinator.GroupRenderingControl().SetGroupVolume(&rcg.SetGroupVolumeArgs{
+		DesiredVolume: uint16(clamp(volume, 0, MaxVolume)),
+	})
+	if err != nil {
+		return err
+	}
+	return g.enforceVolumeLimits()
+}
+
+// AdjustGroupVolume changes the volume of the group by delta and returns the
+// new group volume.
+func (g *Group) AdjustGroupVolume(delta int) (int, error) {
+	if err := g.snapshot(); err != nil {
+		return 0, err
+	}
+	res, err := g.Coordinator.GroupRenderingControl().SetRelativeGroupVolume(&rcg.SetRelativeGroupVolumeArgs{
+		Adjustment: int32(delta),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := g.enforceVolumeLimits(); err != nil {
+		return 0, err
+	}
+	if delta > 0 {
+		// Caps may have lowered some members.
+		return g.GroupVolume()
+	}
+	return int(res.NewVolume), nil
+}
+
+// GroupMute reports whether the group is muted.
+func (g *Group) GroupMute() (bool, error) {
+	res, err := g.Coordinator.GroupRenderingControl().GetGroupMute(&rcg.GetGroupMuteArgs{})
+	if err != nil {
+		return false, err
+	}
+	return res.CurrentMute, nil
+}
+
+// SetGroupMute mutes or unmutes every member of the group.
+func (g *Group) SetGroupMute(mute bool) error {
+	_, err := g.Coordinator.GroupRenderingControl().SetGroupMute(&rcg.SetGroupMuteArgs{
+		DesiredMute: mute,
+	})
+	return err
+}
+
+// enforceVolumeLimits turns down members that ended up above their cap.
+func (g *Group) enforceVolumeLimits() error {
+	for _, member := range g.Members {
+		max := member.MaxVolume()
+		if max == MaxVolume {
+			continue
+		}
+		volume, err := member.GetVolume()
+		if err != nil {
+			return err
+		}
+		if volume > max {
+			if err := member.SetVolume(max); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// JoinGroup makes the player a member of the group coordinated by
+// coordinator.
+func (z *ZonePlayer) JoinGroup(coordinator *ZonePlayer) error {
+	if coordinator.UUID() == z.UUID() {
+		return nil
+	}
+	return z.SetAVTransportURI("x-rincon:" + coordinator.UUID())
+}
+
+// LeaveGroup takes the player out of its group. The remaining members keep
+// playing.
+func (z *ZonePlayer) LeaveGroup() error {
+	_, err := z.AVTransport().BecomeCoordinatorOfStandaloneGroup(&avt.BecomeCoordinatorOfStandaloneGroupArgs{})
+	return err
+}
@@ -0,0 +1,71 @@
+package sonos
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGroupReusesPeers(t *testing.T) {
+	respond := func(string, string) (string, error) { return "", nil }
+	member := newTestPlayer(respond)
+	defer member.Close()
+	moved := newTestPlayer(respond)
+	defer moved.Close()
+	for _, p := range []*testPlayer{member, moved} {
+		p.mu.Lock()
+		p.uuid = "RINCON_2"
+		p.mu.Unlock()
+	}
+
+	location := member.URL
+	coordinator := newTestPlayer(func(action, _ string) (string, error) {
+		if action != "GetZoneGroupState" {
+			return "", nil
+		}
+		return "<ZoneGroupState>" + escapeXML(fmt.Sprintf(`<ZoneGroupState><ZoneGroups>`+
+			`<ZoneGroup Coordinator="RINCON_1" ID="RINCON_1:1">`+
+			`<ZoneGroupMember UUID="RINCON_1" Location="%s/xml/device_description.xml" ZoneName="Kitchen"/>`+
+			`<ZoneGroupMember UUID="RINCON_2" Location="%s/xml/device_description.xml" ZoneName="Den"/>`+
+			`</ZoneGroup></ZoneGroups></ZoneGroupState>`, "http://unused", location)) + "</ZoneGroupState>", nil
+	})
+	defer coordinator.Close()
+	zp := coordinator.zonePlayer(t)
+
+	var players []*ZonePlayer
+	for _, tt := range []struct {
+		name     string
+		location string
+	}{
+		{"first", member.URL},
+		{"again", member.URL},
+		{"new address", moved.URL},
+		{"again at the new address", moved.URL},
+	} {
+		coordinator.mu.Lock()
+		location = tt.location
+		coordinator.mu.Unlock()
+
+		g, err := zp.Group()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(g.Members) != 2 || g.Coordinator != zp || g.Members[0] != zp {
+			t.Fatalf("%s: group %+v", tt.name, g)
+		}
+		peer := g.Members[1]
+		if got := peer.Location().String(); got != tt.location+"/xml/device_description.xml" {
+			t.Errorf("%s: member at %s, want %s", tt.name, got, tt.location)
+		}
+		players = append(players, peer)
+	}
+
+	if players[0] != players[1] || players[2] != players[3] {
+		t.Error("member player created again for the same location")
+	}
+	if players[1] == players[2] {
+		t.Error("member player reused after its address changed")
+	}
+	if n := len(member.actions("GET")); n != 1 {
+		t.Errorf("description of the member fetched %d times, want 1", n)
+	}
+}
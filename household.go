@@ -0,0 +1,133 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+
+	dev "github.com/caglar10ur/sonos/services/DeviceProperties"
+)
+
+// Household is the set of players sharing a household ID. Several
+// households can share a network; a Household scopes lookups to one of them.
+type Household struct {
+	ID    string
+	sonos *Sonos
+}
+
+// WithHousehold makes discovery ignore players of other households.
+func WithHousehold(id string) SonosOption {
+	return func(s *Sonos) {
+		s.household = id
+	}
+}
+
+// HouseholdID returns the ID of the household the player belongs to.
+func (z *ZonePlayer) HouseholdID() (string, error) {
+	z.rootMu.RLock()
+	id := z.householdID
+	z.rootMu.RUnlock()
+	if id != "" {
+		return id, nil
+	}
+
+	res, err := z.DeviceProperties().GetHouseholdID(&dev.GetHouseholdIDArgs{})
+	if err != nil {
+		return "", err
+	}
+	z.setHouseholdID(res.CurrentHouseholdID)
+	return res.CurrentHouseholdID, nil
+}
+
+func (z *ZonePlayer) setHouseholdID(id string) {
+	z.rootMu.Lock()
+	z.householdID = id
+	z.rootMu.Unlock()
+}
+
+// householdOf returns the household ID of the player at location without
+// fetching its device description.
+func householdOf(location *url.URL) (string, error) {
+	res, err := dev.NewService(
+		dev.WithLocation(location),
+		dev.WithClient(http.DefaultClient),
+	).GetHouseholdID(&dev.GetHouseholdIDArgs{})
+	if err != nil {
+		return "", err
+	}
+	return res.CurrentHouseholdID, nil
+}
+
+// wantHousehold reports whether players of the household id are to be
+// reported by discovery.
+func (s *Sonos) wantHousehold(id string) bool {
+	return s.household == "" || s.household == id
+}
+
+// Households returns the households of the players found so far.
+func (s *Sonos) Households() []*Household {
+	seen := make(map[string]bool)
+	var households []*Household
+	s.zonePlayers.Range(func(_, p interface{}) bool {
+		id, err := p.(*ZonePlayer).HouseholdID()
+		if err == nil && !seen[id] {
+			seen[id] = true
+			households = append(households, &Household{ID: id, sonos: s})
+		}
+		return true
+	})
+	sort.Slice(households, func(i, j int) bool {
+		return households[i].ID < households[j].ID
+	})
+	return households
+}
+
+// Household returns a handle for the household with the given ID.
+func (s *Sonos) Household(id string) *Household {
+	return &Household{ID: id, sonos: s}
+}
+
+// Players returns the coordinators of the household found so far, ordered
+// by room name.
+func (h *Household) Players() []*ZonePlayer {
+	var players []*ZonePlayer
+	h.sonos.zonePlayers.Range(func(_, p interface{}) bool {
+		zp := p.(*ZonePlayer)
+		if id, err := zp.HouseholdID(); err == nil && id == h.ID {
+			players = append(players, zp)
+		}
+		return true
+	})
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].RoomName() < players[j].RoomName()
+	})
+	return players
+}
+
+// Search is like Sonos.Search but only reports players of the household.
+func (h *Household) Search(ctx context.Context, foundFn FoundZonePlayer) error {
+	return h.sonos.Search(ctx, func(s *Sonos, zp *ZonePlayer) {
+		if id, err := zp.HouseholdID(); err == nil && id == h.ID {
+			foundFn(s, zp)
+		}
+	})
+}
+
+// FindRoom is like Sonos.FindRoom but only considers rooms of the
+// household.
+func (h *Household) FindRoom(ctx context.Context, room string, opts ...RoomOption) (*ZonePlayer, error) {
+	return h.sonos.findRoom(ctx, room, func(id string) bool {
+		return id == h.ID
+	}, opts)
+}
+
+// ZoneGroupState returns the topology of the household.
+func (h *Household) ZoneGroupState() (*ZoneGroupState, error) {
+	players := h.Players()
+	if len(players) == 0 {
+		return nil, errors.New("no player of the household found")
+	}
+	return players[0].GetZoneGroupState()
+}
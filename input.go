@@ -0,0 +1,122 @@
+package sonos
+
+import (
+	ain "github.com/caglar10ur/sonos/services/AudioIn"
+	dev "github.com/caglar10ur/sonos/services/DeviceProperties"
+)
+
+// TVURI returns the URI of the home theater input of the player.
+func (z *ZonePlayer) TVURI() string {
+	return "x-sonos-htastream:" + z.UUID() + ":spdif"
+}
+
+// LineInURI returns the URI of the analogue line-in of the player.
+func (z *ZonePlayer) LineInURI() string {
+	return "x-rincon-stream:" + z.UUID()
+}
+
+// HasTV reports whether the player has a home theater (HDMI or optical)
+// input.
+func (z *ZonePlayer) HasTV() (bool, error) {
+	info, err := z.DeviceProperties().GetZoneInfo(&dev.GetZoneInfoArgs{})
+	if err != nil {
+		return false, err
+	}
+	return info.HTAudioIn != 0, nil
+}
+
+// HasLineIn reports whether the player has an analogue line-in. The
+// SupportsAudioIn DeviceProperties event is used once received, otherwise
+// the AudioIn service is probed; players without a line-in answer with a
+// UPnP fault. Other errors, such as timeouts, are returned.
+func (z *ZonePlayer) HasLineIn() (bool, error) {
+	if supports := z.GetState().Snapshot().SupportsAudioIn; supports != nil {
+		return *supports, nil
+	}
+	_, err := z.AudioIn().GetAudioInputAttributes(&ain.GetAudioInputAttributesArgs{})
+	if _, ok := UPnPErrorCode(err); ok {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AudioInputAttributes returns the name and icon of the line-in of the
+// player.
+func (z *ZonePlayer) AudioInputAttributes() (name, icon string, err error) {
+	res, err := z.AudioIn().GetAudioInputAttributes(&ain.GetAudioInputAttributesArgs{})
+	if err != nil {
+		return "", "", err
+	}
+	return res.CurrentName, res.CurrentIcon, nil
+}
+
+// SelectAudio selects the audio input with the given object ID on the
+// AudioIn service of the player.
+func (z *ZonePlayer) SelectAudio(objectID string) error {
+	_, err := z.AudioIn().SelectAudio(&ain.SelectAudioArgs{ObjectID: objectID})
+	return err
+}
+
+// LineInConnected reports whether a source is plugged into the line-in.
+// It relies on AudioIn events; known is false until the first one arrives.
+func (z *ZonePlayer) LineInConnected() (connected, known bool) {
+	state := z.GetState().Snapshot()
+	return state.LineInConnected, state.LineInKnown
+}
+
+// SwitchToTV plays the home theater input of the player.
+func (z *ZonePlayer) SwitchToTV() error {
+	tv, err := z.HasTV()
+	if err != nil {
+		return err
+	}
+	if !tv {
+		return ErrNotSupported
+	}
+	if err := z.SetAVTransportURI(z.TVURI()); err != nil {
+		return err
+	}
+	return z.Play()
+}
+
+// SwitchToLineIn plays the line-in of source, which may be another player of
+// the household. A nil source selects the line-in of the player itself.
+func (z *ZonePlayer) SwitchToLineIn(source *ZonePlayer) error {
+	if source == nil {
+		source = z
+	}
+	lineIn, err := source.HasLineIn()
+	if err != nil {
+		return err
+	}
+	if !lineIn {
+		return ErrNotSupported
+	}
+	if err := z.SetAVTransportURI(source.LineInURI()); err != nil {
+		return err
+	}
+	return z.Play()
+}
+
+// LineInLevel returns the input levels of the left and right line-in
+// channels.
+func (z *ZonePlayer) LineInLevel() (left, right int, err error) {
+	res, err := z.AudioIn().GetLineInLevel(&ain.GetLineInLevelArgs{})
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(res.CurrentLeftLineInLevel), int(res.CurrentRightLineInLevel), nil
+}
+
+// SetLineInLevel sets the input levels of the left and right line-in
+// channels.
+func (z *ZonePlayer) SetLineInLevel(left, right int) error {
+	_, err := z.AudioIn().SetLineInLevel(&ain.SetLineInLevelArgs{
+		DesiredLeftLineInLevel:  int32(left),
+		DesiredRightLineInLevel: int32(right),
+	})
+	return err
+}
@@ -0,0 +1,61 @@
+package sonos
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasLineIn(t *testing.T) {
+	yes, no := true, false
+	for _, tt := range []struct {
+		name     string
+		supports *bool
+		probe    error
+		lineIn   bool
+		err      bool
+		probed   bool
+	}{
+		{name: "evented", supports: &yes, lineIn: true},
+		{name: "evented without", supports: &no},
+		{name: "probed", lineIn: true, probed: true},
+		{name: "probe faults", probe: testFault(401), probed: true},
+		{name: "probe fails", probe: errDrop, err: true, probed: true},
+	} {
+		probe := tt.probe
+		p := newTestPlayer(func(action, _ string) (string, error) {
+			if action == "GetAudioInputAttributes" {
+				return "<CurrentName>Turntable</CurrentName><CurrentIcon></CurrentIcon>", probe
+			}
+			return "", nil
+		})
+		zp := p.zonePlayer(t)
+		zp.GetState().update(func(state *PlayerState) {
+			state.SupportsAudioIn = tt.supports
+		})
+
+		lineIn, err := zp.HasLineIn()
+		probed := len(p.actions("GetAudioInputAttributes")) > 0
+		p.Close()
+
+		if lineIn != tt.lineIn || (err != nil) != tt.err {
+			t.Errorf("%s: HasLineIn = %v, %v", tt.name, lineIn, err)
+		}
+		if probed != tt.probed {
+			t.Errorf("%s: probed %v, want %v", tt.name, probed, tt.probed)
+		}
+	}
+}
+
+func TestSelectAudio(t *testing.T) {
+	p := newTestPlayer(func(string, string) (string, error) { return "", nil })
+	defer p.Close()
+	zp := p.zonePlayer(t)
+
+	if err := zp.SelectAudio("RINCON_2:LineIn"); err != nil {
+		t.Fatal(err)
+	}
+	requests := p.actions("SelectAudio")
+	if len(requests) != 1 || !strings.Contains(requests[0], "<ObjectID>RINCON_2:LineIn</ObjectID>") {
+		t.Errorf("requests %v", requests)
+	}
+}
@@ -0,0 +1,209 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	dir "github.com/caglar10ur/sonos/services/ContentDirectory"
+)
+
+// Well known ContentDirectory object IDs of the local music library.
+const (
+	LibraryArtists      = "A:ARTIST"
+	LibraryAlbumArtists = "A:ALBUMARTIST"
+	LibraryAlbums       = "A:ALBUM"
+	LibraryGenres       = "A:GENRE"
+	LibraryComposers    = "A:COMPOSER"
+	LibraryTracks       = "A:TRACKS"
+	LibraryPlaylists    = "A:PLAYLISTS"
+	LibraryShares       = "S:"
+)
+
+// ErrNotFound is returned when a named object does not exist.
+var ErrNotFound = errors.New("not found")
+
+// PrefixLocation is the index at which titles starting with Prefix begin
+// within a library container.
+type PrefixLocation struct {
+	Prefix string
+	Index  uint32
+}
+
+// Library browses the local music library indexed by a player.
+type Library struct {
+	zp *ZonePlayer
+}
+
+// Library returns a handle to the music library of the player.
+func (z *ZonePlayer) Library() *Library {
+	return &Library{zp: z}
+}
+
+// Children returns the direct children of the given library object ID.
+func (l *Library) Children(ctx context.Context, objectID string, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.zp.Browse(ctx, objectID, opts...).MediaObjects()
+}
+
+// Artists lists every artist of the library.
+func (l *Library) Artists(ctx context.Context, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, LibraryArtists, opts...)
+}
+
+// AlbumArtists lists every album artist of the library.
+func (l *Library) AlbumArtists(ctx context.Context, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, LibraryAlbumArtists, opts...)
+}
+
+// Albums lists every album of the library.
+func (l *Library) Albums(ctx context.Context, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, LibraryAlbums, opts...)
+}
+
+// Genres lists every genre of the library.
+func (l *Library) Genres(ctx context.Context, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, LibraryGenres, opts...)
+}
+
+// Composers lists every composer of the library.
+func (l *Library) Composers(ctx context.Context, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, LibraryComposers, opts...)
+}
+
+// Tracks lists every track of the library.
+func (l *Library) Tracks(ctx context.Context, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, LibraryTracks, opts...)
+}
+
+// Playlists lists the imported playlists of the library.
+func (l *Library) Playlists(ctx context.Context, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, LibraryPlaylists, opts...)
+}
+
+// Shares lists the music shares the library is indexed from.
+func (l *Library) Shares(ctx context.Context, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, LibraryShares, opts...)
+}
+
+// AlbumsByArtist lists the albums of an artist returned by Artists or
+// AlbumArtists. The synthetic "All" container Sonos adds is skipped.
+func (l *Library) AlbumsByArtist(ctx context.Context, artist MediaObject, opts ...BrowseOption) ([]MediaObject, error) {
+	objects, err := l.Children(ctx, artist.ID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	albums := objects[:0]
+	for _, o := range objects {
+		if o.ID == artist.ID+"/" {
+			continue
+		}
+		albums = append(albums, o)
+	}
+	return albums, nil
+}
+
+// TracksByAlbum lists the tracks of an album.
+func (l *Library) TracksByAlbum(ctx context.Context, album MediaObject, opts ...BrowseOption) ([]MediaObject, error) {
+	return l.Children(ctx, album.ID, opts...)
+}
+
+// Artist looks up an artist by name, ignoring case.
+func (l *Library) Artist(ctx context.Context, name string) (*MediaObject, error) {
+	return l.Find(ctx, LibraryArtists, name)
+}
+
+// Album looks up an album by name, ignoring case.
+func (l *Library) Album(ctx context.Context, name string) (*MediaObject, error) {
+	return l.Find(ctx, LibraryAlbums, name)
+}
+
+// Genre looks up a genre by name, ignoring case.
+func (l *Library) Genre(ctx context.Context, name string) (*MediaObject, error) {
+	return l.Find(ctx, LibraryGenres, name)
+}
+
+// Find returns the child of objectID titled name, ignoring case.
+func (l *Library) Find(ctx context.Context, objectID, name string) (*MediaObject, error) {
+	objects, err := l.Search(ctx, objectID, name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range objects {
+		if strings.EqualFold(objects[i].Title, name) {
+			return &objects[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Search returns the children of objectID whose title starts with prefix,
+// ignoring case. It jumps straight to the first match using FindPrefix
+// rather than browsing the whole container.
+func (l *Library) Search(ctx context.Context, objectID, prefix string, opts ...BrowseOption) ([]MediaObject, error) {
+	res, err := l.zp.ContentDirectory().FindPrefix(&dir.FindPrefixArgs{
+		ObjectID: objectID,
+		Prefix:   prefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, WithStartingIndex(res.StartingIndex))
+	it := l.zp.Browse(ctx, objectID, opts...)
+
+	var objects []MediaObject
+	for it.Next() {
+		o := NewMediaObject(it.Object())
+		if !hasPrefixFold(o.Title, prefix) {
+			break
+		}
+		objects = append(objects, o)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// PrefixLocations returns the index of every title prefix within objectID,
+// as used by the alphabet scrollers of the Sonos apps.
+func (l *Library) PrefixLocations(objectID string) ([]PrefixLocation, error) {
+	res, err := l.zp.ContentDirectory().GetAllPrefixLocations(&dir.GetAllPrefixLocationsArgs{
+		ObjectID: objectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parsePrefixLocations(res.PrefixAndIndexCSV)
+}
+
+// parsePrefixLocations parses a "A,0,B,12,C,30" style CSV.
+func parsePrefixLocations(csv string) ([]PrefixLocation, error) {
+	if csv == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(csv, ",")
+	if len(fields)%2 != 0 {
+		return nil, errors.New("malformed PrefixAndIndexCSV")
+	}
+
+	locations := make([]PrefixLocation, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		index, err := strconv.ParseUint(fields[i+1], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, PrefixLocation{
+			Prefix: fields[i],
+			Index:  uint32(index),
+		})
+	}
+	return locations, nil
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
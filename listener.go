@@ -0,0 +1,85 @@
+package sonos
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WithListenAddress sets the address the event server listens on, e.g.
+// ":3400" for a fixed port that can be opened in a firewall or published
+// from a container. The default is a random port on every interface.
+func WithListenAddress(addr string) SonosOption {
+	return func(s *Sonos) {
+		s.listenAddress = addr
+	}
+}
+
+// WithListener serves events on l instead of a listener of our own. It is
+// closed by Close.
+func WithListener(l net.Listener) SonosOption {
+	return func(s *Sonos) {
+		s.tcpListener = l
+	}
+}
+
+// WithCallbackURL sets the URL players send events to, e.g.
+// http://192.168.1.10:3400 when the listener is reachable through a port
+// mapping. The path of u, if any, is the prefix the event handler is
+// served under. By default the callback uses the local address of a
+// connection to the player and the port of the listener.
+func WithCallbackURL(u *url.URL) SonosOption {
+	return func(s *Sonos) {
+		s.callbackURL = u
+	}
+}
+
+// WithServeMux mounts the event handler on mux under pattern, e.g.
+// "/sonos/", instead of starting an event server. WithCallbackURL must be
+// given as well since the address of the server is unknown; the pattern is
+// used as its path unless it has one.
+func WithServeMux(mux *http.ServeMux, pattern string) SonosOption {
+	return func(s *Sonos) {
+		s.mux = mux
+		s.muxPattern = pattern
+	}
+}
+
+// callback returns the URL zp sends events of service to.
+func (s *Sonos) callback(zp *ZonePlayer, service SonosService) (*url.URL, error) {
+	u := &url.URL{
+		Scheme:   "http",
+		Path:     service.EventEndpoint().Path,
+		RawQuery: "sn=" + zp.SerialNum(),
+	}
+
+	if s.callbackURL != nil {
+		u.Scheme = s.callbackURL.Scheme
+		u.Host = s.callbackURL.Host
+		u.Path = s.callbackPrefix() + u.Path
+		return u, nil
+	}
+
+	_, port, err := net.SplitHostPort(s.tcpListener.Addr().String())
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial("tcp", service.EventEndpoint().Host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	u.Host = net.JoinHostPort(conn.LocalAddr().(*net.TCPAddr).IP.String(), port)
+	return u, nil
+}
+
+// callbackPrefix returns the path prefix events are received under.
+func (s *Sonos) callbackPrefix() string {
+	if s.callbackURL == nil {
+		return ""
+	}
+	return strings.TrimSuffix(s.callbackURL.Path, "/")
+}
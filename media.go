@@ -0,0 +1,195 @@
+package sonos
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/caglar10ur/sonos/didl"
+)
+
+const (
+	// didlHeader opens a DIDL-Lite document with every namespace Sonos
+	// expects in EnqueuedURIMetaData and CurrentURIMetaData.
+	didlHeader = `<DIDL-Lite xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/" xmlns:r="urn:schemas-rinconnetworks-com:metadata-1-0/" xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/">`
+	didlFooter = `</DIDL-Lite>`
+
+	// descNameSpace is the namespace of the <desc> element carrying the
+	// music service account an object belongs to.
+	descNameSpace = "urn:schemas-rinconnetworks-com:metadata-1-0/"
+
+	// LocalLibraryDesc is the <desc> value used for objects of the local
+	// music library.
+	LocalLibraryDesc = "RINCON_AssociatedZPUDN"
+)
+
+// MediaObject is a flattened ContentDirectory object. It carries everything
+// needed to display it and to hand it to AddURIToQueue or SetAVTransportURI.
+type MediaObject struct {
+	ID          string
+	ParentID    string
+	Title       string
+	Class       string
+	Creator     string
+	Album       string
+	Genre       string
+	AlbumArtURI string
+	URI         string
+	Duration    string
+	Desc        string
+	IsContainer bool
+
+	// ResMD is the raw metadata Sonos stores next to favorites. When set it
+	// is used verbatim by Metadata.
+	ResMD string
+}
+
+// NewMediaObject flattens a BrowseObject into a MediaObject.
+func NewMediaObject(o BrowseObject) MediaObject {
+	if o.Container != nil {
+		c := o.Container
+		m := MediaObject{
+			ID:          c.ID,
+			ParentID:    c.ParentID,
+			Title:       firstTitle(c.Title),
+			Class:       firstClass(c.Class),
+			Creator:     firstCreator(c.Creator),
+			Album:       firstAlbum(c.Album),
+			Genre:       firstGenre(c.Genre),
+			AlbumArtURI: firstAlbumArtURI(c.AlbumArtURI),
+			Desc:        firstDesc(c.Desc),
+			ResMD:       firstResMD(c.ResMD),
+			IsContainer: true,
+		}
+		if len(c.Res) > 0 {
+			m.URI = c.Res[0].Value
+			m.Duration = c.Res[0].Duration
+		}
+		return m
+	}
+	if o.Item != nil {
+		i := o.Item
+		m := MediaObject{
+			ID:          i.ID,
+			ParentID:    i.ParentID,
+			Title:       firstTitle(i.Title),
+			Class:       firstClass(i.Class),
+			Creator:     firstCreator(i.Creator),
+			Album:       firstAlbum(i.Album),
+			Genre:       firstGenre(i.Genre),
+			AlbumArtURI: firstAlbumArtURI(i.AlbumArtURI),
+			Desc:        firstDesc(i.Desc),
+			ResMD:       firstResMD(i.ResMD),
+		}
+		if m.Creator == "" && len(i.Artist) > 0 {
+			m.Creator = i.Artist[0].Value
+		}
+		if len(i.Res) > 0 {
+			m.URI = i.Res[0].Value
+			m.Duration = i.Res[0].Duration
+		}
+		return m
+	}
+	return MediaObject{}
+}
+
+// Metadata returns the DIDL-Lite document describing the object, suitable for
+// the *MetaData arguments of AVTransport actions.
+func (m *MediaObject) Metadata() string {
+	if m.ResMD != "" {
+		return m.ResMD
+	}
+
+	tag := "item"
+	if m.IsContainer {
+		tag = "container"
+	}
+	desc := m.Desc
+	if desc == "" {
+		desc = LocalLibraryDesc
+	}
+
+	var b strings.Builder
+	b.WriteString(didlHeader)
+	fmt.Fprintf(&b, `<%s id="%s" parentID="%s" restricted="true">`, tag, escapeXML(m.ID), escapeXML(m.ParentID))
+	writeXMLElement(&b, "dc:title", m.Title)
+	writeXMLElement(&b, "upnp:class", m.Class)
+	writeXMLElement(&b, "dc:creator", m.Creator)
+	writeXMLElement(&b, "upnp:album", m.Album)
+	writeXMLElement(&b, "upnp:albumArtURI", m.AlbumArtURI)
+	fmt.Fprintf(&b, `<desc id="cdudn" nameSpace="%s">%s</desc>`, descNameSpace, escapeXML(desc))
+	fmt.Fprintf(&b, `</%s>`, tag)
+	b.WriteString(didlFooter)
+
+	return b.String()
+}
+
+func escapeXML(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeXMLElement(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "<%s>%s</%s>", name, escapeXML(value), name)
+}
+
+func firstTitle(v []didl.Title) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].Value
+}
+
+func firstClass(v []didl.Class) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].Value
+}
+
+func firstCreator(v []didl.Creator) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].Value
+}
+
+func firstAlbum(v []didl.Album) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].Value
+}
+
+func firstGenre(v []didl.Genre) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].Value
+}
+
+func firstAlbumArtURI(v []didl.AlbumArtURI) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].Value
+}
+
+func firstDesc(v []didl.Desc) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].Value
+}
+
+func firstResMD(v []didl.ResMD) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0].Value
+}
@@ -0,0 +1,212 @@
+package sonos
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the SOAP call latency
+// histogram.
+var LatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics counts what the library does: SOAP calls, events and
+// subscription renewals. It is safe for concurrent use.
+type Metrics struct {
+	mu            sync.Mutex
+	calls         map[actionKey]*CallStats
+	events        map[string]uint64
+	renewFailures map[string]uint64
+
+	client *http.Client
+}
+
+type actionKey struct {
+	service, action string
+}
+
+// CallStats are the statistics of the calls of a SOAP action.
+type CallStats struct {
+	Service string
+	Action  string
+	Count   uint64
+	Errors  uint64
+	// Sum is the total time spent in the calls.
+	Sum time.Duration
+	// Buckets counts the calls that took at most the matching entry of
+	// LatencyBuckets. Counts are not cumulative.
+	Buckets []uint64
+}
+
+// NewMetrics returns empty metrics.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		calls:         make(map[actionKey]*CallStats),
+		events:        make(map[string]uint64),
+		renewFailures: make(map[string]uint64),
+	}
+	m.client = m.Client(nil)
+	return m
+}
+
+// WithMetrics records the metrics of s and of the players it finds in m.
+func WithMetrics(m *Metrics) SonosOption {
+	return func(s *Sonos) {
+		s.metrics = m
+	}
+}
+
+// Client returns a copy of c, or of a client with the default timeout if c
+// is nil, whose SOAP calls are recorded in m. Use it with WithClient for
+// players not created by discovery.
+func (m *Metrics) Client(c *http.Client) *http.Client {
+	if c == nil {
+		c = &http.Client{Timeout: 10 * time.Second}
+	}
+	instrumented := *c
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	instrumented.Transport = &soapTransport{metrics: m, next: transport}
+	return &instrumented
+}
+
+// soapTransport times the requests carrying a SOAPAction header.
+type soapTransport struct {
+	metrics *Metrics
+	next    http.RoundTripper
+}
+
+func (t *soapTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	soapAction := req.Header.Get("SOAPAction")
+	if soapAction == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	// Faults come back as 500 Internal Server Error.
+	failed := err != nil || res.StatusCode >= http.StatusBadRequest
+	t.metrics.call(soapAction, time.Since(start), failed)
+	return res, err
+}
+
+// call records a call to soapAction, e.g.
+// "urn:schemas-upnp-org:service:AVTransport:1#Play".
+func (m *Metrics) call(soapAction string, d time.Duration, failed bool) {
+	urn, action := soapAction, ""
+	if i := strings.LastIndex(soapAction, "#"); i >= 0 {
+		urn, action = soapAction[:i], soapAction[i+1:]
+	}
+	service := urn
+	if parts := strings.Split(urn, ":"); len(parts) >= 2 {
+		service = parts[len(parts)-2]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := actionKey{service, action}
+	stats, ok := m.calls[key]
+	if !ok {
+		stats = &CallStats{
+			Service: service,
+			Action:  action,
+			Buckets: make([]uint64, len(LatencyBuckets)),
+		}
+		m.calls[key] = stats
+	}
+	stats.Count++
+	stats.Sum += d
+	if failed {
+		stats.Errors++
+	}
+	for i, le := range LatencyBuckets {
+		if d.Seconds() <= le {
+			stats.Buckets[i]++
+			break
+		}
+	}
+}
+
+// Calls returns the statistics of every SOAP action called so far, ordered
+// by service and action.
+func (m *Metrics) Calls() []CallStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([]CallStats, 0, len(m.calls))
+	for _, stats := range m.calls {
+		c := *stats
+		c.Buckets = append([]uint64(nil), stats.Buckets...)
+		calls = append(calls, c)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Service != calls[j].Service {
+			return calls[i].Service < calls[j].Service
+		}
+		return calls[i].Action < calls[j].Action
+	})
+	return calls
+}
+
+// Events returns the number of events received per service.
+func (m *Metrics) Events() map[string]uint64 {
+	return m.copyCounts(m.events)
+}
+
+// RenewFailures returns the number of failed subscription renewals per
+// service.
+func (m *Metrics) RenewFailures() map[string]uint64 {
+	return m.copyCounts(m.renewFailures)
+}
+
+func (m *Metrics) copyCounts(counts map[string]uint64) map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := make(map[string]uint64, len(counts))
+	for k, v := range counts {
+		c[k] = v
+	}
+	return c
+}
+
+func (m *Metrics) count(counts map[string]uint64, service SonosService) {
+	m.mu.Lock()
+	counts[serviceName(service)]++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) event(service SonosService) {
+	if m != nil {
+		m.count(m.events, service)
+	}
+}
+
+func (m *Metrics) renewFailed(service SonosService) {
+	if m != nil {
+		m.count(m.renewFailures, service)
+	}
+}
+
+// serviceName returns the name of the service, e.g. AVTransport, from its
+// event endpoint.
+func serviceName(service SonosService) string {
+	return path.Base(path.Dir(service.EventEndpoint().Path))
+}
+
+// ActiveSubscriptions returns the number of subscriptions made with
+// Subscribe and not yet unsubscribed, per service.
+func (s *Sonos) ActiveSubscriptions() map[string]int {
+	active := make(map[string]int)
+	s.subscriptions.Range(func(_, sub interface{}) bool {
+		active[serviceName(sub.(*subscription).service)]++
+		return true
+	})
+	return active
+}
@@ -0,0 +1,77 @@
+package sonos
+
+import "sort"
+
+// Capabilities are the hardware features of a model.
+type Capabilities struct {
+	// LineIn is an analogue input.
+	LineIn bool
+	// TV is an HDMI or optical input.
+	TV bool
+	// Battery powered, portable model.
+	Battery bool
+	// Microphone for voice control.
+	Microphone bool
+	// HomeTheater models accept a Sub and surrounds.
+	HomeTheater bool
+	// StereoPair models can be paired with another of the same model.
+	StereoPair bool
+	// AirPlay 2 target.
+	AirPlay bool
+	// Subwoofer models are bonded to a room as its Sub.
+	Subwoofer bool
+}
+
+// Model is an entry of the model catalogue.
+type Model struct {
+	// Number is the modelNumber of the device description, e.g. S14.
+	Number string
+	// Name is the marketing name, e.g. Beam.
+	Name string
+	Capabilities
+}
+
+var models = map[string]Model{
+	"ZP80":  {"ZP80", "Connect (ZP80)", Capabilities{LineIn: true}},
+	"ZP90":  {"ZP90", "Connect (ZP90)", Capabilities{LineIn: true}},
+	"ZP100": {"ZP100", "Connect:Amp (ZP100)", Capabilities{LineIn: true, StereoPair: true}},
+	"ZP120": {"ZP120", "Connect:Amp (ZP120)", Capabilities{LineIn: true, StereoPair: true}},
+	"S1":    {"S1", "Play:1", Capabilities{StereoPair: true}},
+	"S3":    {"S3", "Play:3", Capabilities{StereoPair: true}},
+	"S5":    {"S5", "Play:5 (Gen 1)", Capabilities{LineIn: true, StereoPair: true}},
+	"S6":    {"S6", "Play:5 (Gen 2)", Capabilities{LineIn: true, StereoPair: true, AirPlay: true}},
+	"S9":    {"S9", "Playbar", Capabilities{TV: true, HomeTheater: true}},
+	"S11":   {"S11", "Playbase", Capabilities{TV: true, HomeTheater: true, AirPlay: true}},
+	"S13":   {"S13", "One", Capabilities{Microphone: true, StereoPair: true, AirPlay: true}},
+	"S14":   {"S14", "Beam", Capabilities{TV: true, Microphone: true, HomeTheater: true, AirPlay: true}},
+	"S15":   {"S15", "Connect", Capabilities{LineIn: true, AirPlay: true}},
+	"S16":   {"S16", "Amp", Capabilities{LineIn: true, TV: true, HomeTheater: true, StereoPair: true, AirPlay: true}},
+	"S17":   {"S17", "Move", Capabilities{Battery: true, Microphone: true, StereoPair: true, AirPlay: true}},
+	"S18":   {"S18", "One SL", Capabilities{StereoPair: true, AirPlay: true}},
+	"S19":   {"S19", "Arc", Capabilities{TV: true, Microphone: true, HomeTheater: true, AirPlay: true}},
+	"S20":   {"S20", "SYMFONISK Table Lamp", Capabilities{StereoPair: true, AirPlay: true}},
+	"S21":   {"S21", "SYMFONISK Bookshelf", Capabilities{StereoPair: true, AirPlay: true}},
+	"S22":   {"S22", "One (Gen 2)", Capabilities{Microphone: true, StereoPair: true, AirPlay: true}},
+	"S23":   {"S23", "Port", Capabilities{LineIn: true, AirPlay: true}},
+	"S27":   {"S27", "Roam", Capabilities{Battery: true, Microphone: true, StereoPair: true, AirPlay: true}},
+	"S31":   {"S31", "Beam (Gen 2)", Capabilities{TV: true, Microphone: true, HomeTheater: true, AirPlay: true}},
+	"Sub":   {"Sub", "Sub", Capabilities{Subwoofer: true}},
+}
+
+// LookupModel returns the catalogue entry for a model number.
+func LookupModel(number string) (Model, bool) {
+	m, ok := models[number]
+	return m, ok
+}
+
+// Models returns every model of the catalogue, ordered by model number.
+func Models() []Model {
+	all := make([]Model, 0, len(models))
+	for _, m := range models {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Number < all[j].Number
+	})
+	return all
+}
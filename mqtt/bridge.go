@@ -0,0 +1,585 @@
+// Package mqtt bridges Sonos players to an MQTT broker. Player state is
+// published to a topic tree and commands are accepted on .../set topics.
+// Home Assistant discovery payloads are published so that the players show
+// up as devices without configuration.
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caglar10ur/sonos"
+)
+
+const (
+	// DefaultTopicPrefix is the root of the topic tree.
+	DefaultTopicPrefix = "sonos"
+	// DefaultDiscoveryPrefix is the Home Assistant discovery prefix.
+	DefaultDiscoveryPrefix = "homeassistant"
+
+	online  = "online"
+	offline = "offline"
+)
+
+// Bridge publishes the state of the players of a household to MQTT and maps
+// commands to player operations. Topics are rooted at
+// <prefix>/<room>/, where room is the slug of the room name:
+//
+//	state               JSON document with the whole state
+//	<field>             current value of a single field, e.g. volume
+//	event               JSON {"type", "old", "new"} for every change
+//	availability        online or offline
+//	<field>/set         commands, see Bridge.command
+//
+// Every topic except event is retained.
+type Bridge struct {
+	sonos  *sonos.Sonos
+	client Client
+
+	prefix          string
+	discoveryPrefix string
+	errorHandler    func(error)
+
+	subscriptions *sonos.SubscriptionSet
+
+	mu      sync.Mutex
+	players map[string]*player
+}
+
+// player is a player handled by the bridge.
+type player struct {
+	zp   *sonos.ZonePlayer
+	room string
+}
+
+// Option configures a Bridge.
+type Option func(*Bridge)
+
+// WithTopicPrefix sets the root of the topic tree.
+func WithTopicPrefix(prefix string) Option {
+	return func(b *Bridge) {
+		b.prefix = prefix
+	}
+}
+
+// WithDiscoveryPrefix sets the Home Assistant discovery prefix. An empty
+// prefix disables discovery.
+func WithDiscoveryPrefix(prefix string) Option {
+	return func(b *Bridge) {
+		b.discoveryPrefix = prefix
+	}
+}
+
+// WithErrorHandler is called with errors that cannot be returned, such as
+// failed commands.
+func WithErrorHandler(h func(error)) Option {
+	return func(b *Bridge) {
+		b.errorHandler = h
+	}
+}
+
+// NewBridge returns a bridge publishing the players found by s to client.
+func NewBridge(s *sonos.Sonos, client Client, opts ...Option) *Bridge {
+	b := &Bridge{
+		sonos:           s,
+		client:          client,
+		prefix:          DefaultTopicPrefix,
+		discoveryPrefix: DefaultDiscoveryPrefix,
+		errorHandler:    func(error) {},
+		players:         make(map[string]*player),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.subscriptions = sonos.NewSubscriptionSet(s, b.services,
+		sonos.WithPlayerAdded(b.add),
+		sonos.WithPlayerRemoved(b.remove),
+		sonos.WithRenewed(b.renewed),
+		sonos.WithSubscriptionErrorHandler(b.errorHandler),
+	)
+	return b
+}
+
+// AvailabilityTopic returns the topic the availability of the bridge itself
+// is published to under prefix. Use it as the will of the MQTT connection.
+func AvailabilityTopic(prefix string) string {
+	return prefix + "/bridge/availability"
+}
+
+// Slug turns a room name into a topic level, e.g. "Living Room" into
+// living_room.
+func Slug(room string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(room) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func (b *Bridge) topic(p *player, parts ...string) string {
+	return strings.Join(append([]string{b.prefix, p.room}, parts...), "/")
+}
+
+// Run searches for players, adds them to the bridge along with the other
+// visible members of their household and keeps their event subscriptions
+// alive until ctx is done, adding and removing players as the topology
+// changes. The players and the bridge are then reported offline.
+func (b *Bridge) Run(ctx context.Context) error {
+	if err := b.client.Publish(AvailabilityTopic(b.prefix), []byte(online), true); err != nil {
+		return err
+	}
+	err := b.subscriptions.Run(ctx)
+	b.client.Publish(AvailabilityTopic(b.prefix), []byte(offline), true)
+	return err
+}
+
+// Add subscribes to the events of zp and starts publishing its state and
+// accepting commands for it. If adding fails, the subscriptions made are
+// cancelled and zp can be added again.
+func (b *Bridge) Add(ctx context.Context, zp *sonos.ZonePlayer) error {
+	return b.subscriptions.Add(ctx, zp)
+}
+
+// add starts publishing the state of a player that was subscribed to.
+func (b *Bridge) add(ctx context.Context, zp *sonos.ZonePlayer) error {
+	p := &player{
+		zp:   zp,
+		room: Slug(zp.RoomName()),
+	}
+
+	b.mu.Lock()
+	b.players[zp.UUID()] = p
+	b.mu.Unlock()
+
+	if err := b.publishPlayer(ctx, p); err != nil {
+		b.forget(p)
+		return err
+	}
+	return nil
+}
+
+func (b *Bridge) publishPlayer(ctx context.Context, p *player) error {
+	zp := p.zp
+	state := zp.GetState()
+	state.OnChange(func(_ *sonos.ZonePlayer, old, updated sonos.PlayerState) {
+		if b.added(p) {
+			b.publishChanges(p, old, updated)
+		}
+	})
+	if err := state.Populate(); err != nil {
+		return err
+	}
+
+	if err := b.client.Subscribe(b.topic(p, "+", "set"), func(topic string, payload []byte) {
+		if !b.added(p) {
+			return
+		}
+		field := strings.TrimSuffix(strings.TrimPrefix(topic, b.topic(p)+"/"), "/set")
+		if err := b.command(ctx, p, field, string(payload)); err != nil {
+			b.errorHandler(fmt.Errorf("%s: %s: %w", zp.RoomName(), field, err))
+		}
+	}); err != nil {
+		return err
+	}
+
+	if b.discoveryPrefix != "" {
+		if err := b.publishDiscovery(p); err != nil {
+			return err
+		}
+	}
+	if err := b.publishState(p, state.Snapshot()); err != nil {
+		return err
+	}
+	return b.client.Publish(b.topic(p, "availability"), []byte(online), true)
+}
+
+// added reports whether p is still handled by the bridge. The state and
+// command handlers of a player that was removed stay registered, so they
+// check it first.
+func (b *Bridge) added(p *player) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.players[p.zp.UUID()] == p
+}
+
+// forget drops p, unless it was replaced.
+func (b *Bridge) forget(p *player) {
+	b.mu.Lock()
+	if b.players[p.zp.UUID()] == p {
+		delete(b.players, p.zp.UUID())
+	}
+	b.mu.Unlock()
+}
+
+func (b *Bridge) lookup(zp *sonos.ZonePlayer) (*player, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p, ok := b.players[zp.UUID()]
+	return p, ok
+}
+
+// remove reports a player that was removed offline.
+func (b *Bridge) remove(zp *sonos.ZonePlayer) {
+	p, ok := b.lookup(zp)
+	if !ok {
+		return
+	}
+	b.forget(p)
+	b.client.Publish(b.topic(p, "availability"), []byte(offline), true)
+}
+
+// renewed reports a player whose subscriptions cannot be made offline
+// until it responds again.
+func (b *Bridge) renewed(zp *sonos.ZonePlayer, err error) {
+	p, ok := b.lookup(zp)
+	if !ok {
+		return
+	}
+	availability := online
+	if err != nil {
+		availability = offline
+	}
+	b.client.Publish(b.topic(p, "availability"), []byte(availability), true)
+}
+
+func (b *Bridge) services(zp *sonos.ZonePlayer) []sonos.SonosService {
+	services := []sonos.SonosService{
+		zp.AVTransport(),
+		zp.RenderingControl(),
+		zp.ZoneGroupTopology(),
+		zp.DeviceProperties(),
+	}
+	if zp.IsCoordinator() {
+		services = append(services, zp.GroupRenderingControl())
+	}
+	// AudioIn reports whether a source is plugged into the line-in. If the
+	// probe fails, it is tried again when the subscriptions are renewed.
+	if lineIn, err := zp.HasLineIn(); err == nil && lineIn {
+		services = append(services, zp.AudioIn())
+	}
+	return services
+}
+
+func (b *Bridge) list() []*player {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	players := make([]*player, 0, len(b.players))
+	for _, p := range b.players {
+		players = append(players, p)
+	}
+	return players
+}
+
+// field is a single value of the state published to its own topic.
+type field struct {
+	name  string
+	value func(sonos.PlayerState) interface{}
+}
+
+var fields = []field{
+	{"transport", func(s sonos.PlayerState) interface{} { return s.TransportState }},
+	{"play_mode", func(s sonos.PlayerState) interface{} { return string(s.PlayMode) }},
+	{"volume", func(s sonos.PlayerState) interface{} { return s.Volume }},
+	{"mute", func(s sonos.PlayerState) interface{} { return s.Mute }},
+	{"group_volume", func(s sonos.PlayerState) interface{} { return s.GroupVolume }},
+	{"group_mute", func(s sonos.PlayerState) interface{} { return s.GroupMute }},
+	{"track", func(s sonos.PlayerState) interface{} { return trackTitle(s.Track) }},
+	{"line_in_connected", func(s sonos.PlayerState) interface{} { return s.LineInConnected }},
+}
+
+func trackTitle(m sonos.MediaObject) string {
+	if m.Creator == "" {
+		return m.Title
+	}
+	return m.Creator + " - " + m.Title
+}
+
+// statePayload is the document published to the state topic.
+type statePayload struct {
+	Room            string       `json:"room"`
+	Transport       string       `json:"transport"`
+	PlayMode        string       `json:"play_mode"`
+	Volume          int          `json:"volume"`
+	Mute            bool         `json:"mute"`
+	GroupVolume     int          `json:"group_volume"`
+	GroupMute       bool         `json:"group_mute"`
+	LineInConnected bool         `json:"line_in_connected"`
+	Track           trackPayload `json:"track"`
+}
+
+type trackPayload struct {
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	Album       string `json:"album"`
+	AlbumArtURI string `json:"album_art_uri"`
+	URI         string `json:"uri"`
+	Duration    int    `json:"duration"`
+	Number      int    `json:"number"`
+}
+
+func newStatePayload(s sonos.PlayerState) *statePayload {
+	return &statePayload{
+		Room:            s.RoomName,
+		Transport:       s.TransportState,
+		PlayMode:        string(s.PlayMode),
+		Volume:          s.Volume,
+		Mute:            s.Mute,
+		GroupVolume:     s.GroupVolume,
+		GroupMute:       s.GroupMute,
+		LineInConnected: s.LineInConnected,
+		Track: trackPayload{
+			Title:       s.Track.Title,
+			Artist:      s.Track.Creator,
+			Album:       s.Track.Album,
+			AlbumArtURI: s.Track.AlbumArtURI,
+			URI:         s.TrackURI,
+			Duration:    int(s.TrackDuration / time.Second),
+			Number:      s.CurrentTrack,
+		},
+	}
+}
+
+// publishState publishes the state document and every field.
+func (b *Bridge) publishState(p *player, s sonos.PlayerState) error {
+	data, err := json.Marshal(newStatePayload(s))
+	if err != nil {
+		return err
+	}
+	if err := b.client.Publish(b.topic(p, "state"), data, true); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if err := b.client.Publish(b.topic(p, f.name), []byte(fmt.Sprint(f.value(s))), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishChanges publishes the state document, the fields that changed and
+// an event for each of them.
+func (b *Bridge) publishChanges(p *player, old, updated sonos.PlayerState) {
+	data, err := json.Marshal(newStatePayload(updated))
+	if err != nil {
+		b.errorHandler(err)
+		return
+	}
+	b.client.Publish(b.topic(p, "state"), data, true)
+
+	for _, f := range fields {
+		o, n := f.value(old), f.value(updated)
+		if o == n {
+			continue
+		}
+		b.client.Publish(b.topic(p, f.name), []byte(fmt.Sprint(n)), true)
+
+		event, err := json.Marshal(map[string]interface{}{
+			"type": f.name,
+			"old":  o,
+			"new":  n,
+		})
+		if err != nil {
+			continue
+		}
+		b.client.Publish(b.topic(p, "event"), event, false)
+	}
+}
+
+// command executes a command received on <field>/set:
+//
+//	volume        0-100
+//	mute          true or false
+//	group_volume  0-100
+//	transport     PLAY, PAUSE, STOP, NEXT or PREVIOUS
+//	play_mode     NORMAL, REPEAT_ALL, SHUFFLE and so on
+//	uri           URI to play
+//	favorite      name of a Sonos favorite to play
+func (b *Bridge) command(ctx context.Context, p *player, name, payload string) error {
+	zp := p.zp
+	payload = strings.TrimSpace(payload)
+
+	switch name {
+	case "volume":
+		v, err := strconv.Atoi(payload)
+		if err != nil {
+			return err
+		}
+		return zp.SetVolume(v)
+
+	case "mute":
+		v, err := parseBool(payload)
+		if err != nil {
+			return err
+		}
+		return zp.SetMute(v)
+
+	case "group_volume":
+		v, err := strconv.Atoi(payload)
+		if err != nil {
+			return err
+		}
+		g, err := zp.Group()
+		if err != nil {
+			return err
+		}
+		return g.SetGroupVolume(v)
+
+	case "transport":
+		switch strings.ToUpper(payload) {
+		case "PLAY":
+			return zp.Play()
+		case "PAUSE":
+			return zp.Pause()
+		case "STOP":
+			return zp.Stop()
+		case "NEXT":
+			return zp.Next()
+		case "PREVIOUS":
+			return zp.Previous()
+		}
+		return fmt.Errorf("unknown transport command %q", payload)
+
+	case "play_mode":
+		return zp.SetPlayMode(sonos.PlayMode(strings.ToUpper(payload)))
+
+	case "uri":
+		if err := zp.SetAVTransportURI(payload); err != nil {
+			return err
+		}
+		return zp.Play()
+
+	case "favorite":
+		return zp.PlayFavorite(ctx, payload)
+	}
+	return fmt.Errorf("unknown command")
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// discoveryDevice is the device block of a Home Assistant discovery payload.
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model,omitempty"`
+}
+
+type discoveryAvailability struct {
+	Topic string `json:"topic"`
+}
+
+// discoveryConfig is a Home Assistant MQTT discovery payload.
+type discoveryConfig struct {
+	Name             string                  `json:"name"`
+	UniqueID         string                  `json:"unique_id"`
+	StateTopic       string                  `json:"state_topic,omitempty"`
+	CommandTopic     string                  `json:"command_topic,omitempty"`
+	PayloadPress     string                  `json:"payload_press,omitempty"`
+	PayloadOn        string                  `json:"payload_on,omitempty"`
+	PayloadOff       string                  `json:"payload_off,omitempty"`
+	Min              *int                    `json:"min,omitempty"`
+	Max              *int                    `json:"max,omitempty"`
+	Icon             string                  `json:"icon,omitempty"`
+	Availability     []discoveryAvailability `json:"availability"`
+	AvailabilityMode string                  `json:"availability_mode"`
+	Device           discoveryDevice         `json:"device"`
+}
+
+// publishDiscovery publishes the Home Assistant discovery payloads of p to
+// <discovery prefix>/<component>/<uuid>/<object>/config.
+func (b *Bridge) publishDiscovery(p *player) error {
+	uuid := p.zp.UUID()
+	device := discoveryDevice{
+		Identifiers:  []string{uuid},
+		Name:         p.zp.RoomName(),
+		Manufacturer: "Sonos",
+		Model:        p.zp.Model().Name,
+	}
+	availability := []discoveryAvailability{
+		{Topic: b.topic(p, "availability")},
+		{Topic: AvailabilityTopic(b.prefix)},
+	}
+	min, max := 0, sonos.MaxVolume
+
+	configs := map[string]discoveryConfig{
+		"number/volume": {
+			Name:         "Volume",
+			StateTopic:   b.topic(p, "volume"),
+			CommandTopic: b.topic(p, "volume", "set"),
+			Min:          &min,
+			Max:          &max,
+			Icon:         "mdi:volume-high",
+		},
+		"switch/mute": {
+			Name:         "Mute",
+			StateTopic:   b.topic(p, "mute"),
+			CommandTopic: b.topic(p, "mute", "set"),
+			PayloadOn:    "true",
+			PayloadOff:   "false",
+			Icon:         "mdi:volume-mute",
+		},
+		"sensor/transport": {
+			Name:       "Transport",
+			StateTopic: b.topic(p, "transport"),
+			Icon:       "mdi:play-pause",
+		},
+		"sensor/track": {
+			Name:       "Track",
+			StateTopic: b.topic(p, "track"),
+			Icon:       "mdi:music",
+		},
+	}
+	for _, cmd := range []struct{ name, payload, icon string }{
+		{"Play", "PLAY", "mdi:play"},
+		{"Pause", "PAUSE", "mdi:pause"},
+		{"Next", "NEXT", "mdi:skip-next"},
+		{"Previous", "PREVIOUS", "mdi:skip-previous"},
+	} {
+		configs["button/"+strings.ToLower(cmd.payload)] = discoveryConfig{
+			Name:         cmd.name,
+			CommandTopic: b.topic(p, "transport", "set"),
+			PayloadPress: cmd.payload,
+			Icon:         cmd.icon,
+		}
+	}
+
+	for key, config := range configs {
+		parts := strings.SplitN(key, "/", 2)
+		component, object := parts[0], parts[1]
+
+		config.UniqueID = uuid + "_" + object
+		config.Availability = availability
+		config.AvailabilityMode = "all"
+		config.Device = device
+
+		data, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+		topic := strings.Join([]string{b.discoveryPrefix, component, uuid, object, "config"}, "/")
+		if err := b.client.Publish(topic, data, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
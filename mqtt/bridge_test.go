@@ -0,0 +1,442 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/caglar10ur/sonos"
+)
+
+const (
+	testUUID = "RINCON_000E58TEST01400"
+	testRoom = "Living Room"
+)
+
+// fakePlayer is a player served from a loopback HTTP server. It answers
+// every SOAP action, with canned results for the ones the bridge reads, and
+// records the actions it receives.
+type fakePlayer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	actions       []string
+	subscriptions map[string]bool
+	sids          int
+	// failSubscribe fails every SUBSCRIBE after that many succeeded; a
+	// negative value never fails.
+	failSubscribe int
+	// faults are the actions answered with a UPnP fault.
+	faults map[string]bool
+}
+
+func newFakePlayer() *fakePlayer {
+	f := &fakePlayer{
+		subscriptions: make(map[string]bool),
+		failSubscribe: -1,
+		faults:        make(map[string]bool),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	return f
+}
+
+func (f *fakePlayer) location() *url.URL {
+	u, _ := url.Parse(f.URL + "/xml/device_description.xml")
+	return u
+}
+
+func (f *fakePlayer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		fmt.Fprintf(w, `<root xmlns="urn:schemas-upnp-org:device-1-0"><device>`+
+			`<modelNumber>S6</modelNumber><modelName>Sonos Play:5</modelName>`+
+			`<serialNum>00-0E-58-TE-ST-01:4</serialNum><UDN>uuid:%s</UDN>`+
+			`<roomName>%s</roomName></device></root>`, testUUID, testRoom)
+
+	case "SUBSCRIBE":
+		if f.failSubscribe >= 0 && f.sids >= f.failSubscribe {
+			http.Error(w, "subscribe failed", http.StatusInternalServerError)
+			return
+		}
+		f.sids++
+		sid := fmt.Sprintf("uuid:sub-%d", f.sids)
+		f.subscriptions[sid] = true
+		w.Header().Set("SID", sid)
+
+	case "UNSUBSCRIBE":
+		delete(f.subscriptions, r.Header.Get("SID"))
+
+	case http.MethodPost:
+		soapAction := strings.Trim(r.Header.Get("SOAPAction"), `"`)
+		i := strings.LastIndex(soapAction, "#")
+		urn, action := soapAction[:i], soapAction[i+1:]
+
+		var body struct {
+			Inner []byte `xml:",innerxml"`
+		}
+		xml.NewDecoder(r.Body).Decode(&body)
+		f.actions = append(f.actions, action+" "+string(body.Inner))
+
+		if f.faults[action] {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>`+
+				`<faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`+
+				`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>401</errorCode></UPnPError>`+
+				`</detail></s:Fault></s:Body></s:Envelope>`)
+			return
+		}
+
+		fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+			`<u:%sResponse xmlns:u="%s">%s</u:%sResponse></s:Body></s:Envelope>`,
+			action, urn, fakeResults[action], action)
+	}
+}
+
+// fakeResults are the results of the actions the bridge reads.
+var fakeResults = map[string]string{
+	"GetTransportInfo":     "<CurrentTransportState>PLAYING</CurrentTransportState>",
+	"GetTransportSettings": "<PlayMode>NORMAL</PlayMode>",
+	"GetVolume":            "<CurrentVolume>25</CurrentVolume>",
+	"GetZoneAttributes":    "<CurrentZoneName>" + testRoom + "</CurrentZoneName>",
+	"GetZoneGroupState": "<ZoneGroupState>" + escape(fmt.Sprintf(
+		`<ZoneGroupState><ZoneGroups><ZoneGroup Coordinator="%s" ID="%s:1">`+
+			`<ZoneGroupMember UUID="%s" ZoneName="%s"/></ZoneGroup></ZoneGroups></ZoneGroupState>`,
+		testUUID, testUUID, testUUID, testRoom)) + "</ZoneGroupState>",
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// lastAction returns the last action received.
+func (f *fakePlayer) lastAction() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.actions) == 0 {
+		return ""
+	}
+	return f.actions[len(f.actions)-1]
+}
+
+func (f *fakePlayer) activeSubscriptions() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.subscriptions)
+}
+
+// testBridge is a bridge publishing to a MemoryBroker and a player served
+// by a fakePlayer.
+type testBridge struct {
+	*Bridge
+	broker *MemoryBroker
+	player *fakePlayer
+	zp     *sonos.ZonePlayer
+}
+
+func newTestBridge(t *testing.T, opts ...Option) *testBridge {
+	f := newFakePlayer()
+	s, err := sonos.NewSonos()
+	if err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	zp, err := sonos.NewZonePlayer(sonos.WithLocation(f.location()))
+	if err != nil {
+		s.Close()
+		f.Close()
+		t.Fatal(err)
+	}
+
+	broker := NewMemoryBroker()
+	return &testBridge{
+		Bridge: NewBridge(s, broker, opts...),
+		broker: broker,
+		player: f,
+		zp:     zp,
+	}
+}
+
+func (tb *testBridge) close() {
+	tb.sonos.Close()
+	tb.player.Close()
+}
+
+func TestBridgeAddPublishes(t *testing.T) {
+	b := newTestBridge(t)
+	defer b.close()
+	f, broker, zp := b.player, b.broker, b.zp
+
+	if err := b.Add(context.Background(), zp); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		topic   string
+		payload string
+	}{
+		{"sonos/living_room/availability", "online"},
+		{"sonos/living_room/volume", "25"},
+		{"sonos/living_room/mute", "false"},
+		{"sonos/living_room/transport", "PLAYING"},
+		{"sonos/living_room/play_mode", "NORMAL"},
+	} {
+		payload, ok := broker.Retained(tt.topic)
+		if !ok {
+			t.Errorf("%s: not retained", tt.topic)
+			continue
+		}
+		if string(payload) != tt.payload {
+			t.Errorf("%s = %q, want %q", tt.topic, payload, tt.payload)
+		}
+	}
+
+	payload, _ := broker.Retained("sonos/living_room/state")
+	var state statePayload
+	if err := json.Unmarshal(payload, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Room != testRoom || state.Volume != 25 || state.Transport != "PLAYING" {
+		t.Errorf("state = %+v", state)
+	}
+
+	if n := f.activeSubscriptions(); n != len(b.services(zp)) {
+		t.Errorf("%d subscriptions, want one per service", n)
+	}
+}
+
+func TestBridgeDiscovery(t *testing.T) {
+	b := newTestBridge(t)
+	defer b.close()
+	broker, zp := b.broker, b.zp
+
+	if err := b.Add(context.Background(), zp); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		component    string
+		object       string
+		stateTopic   string
+		commandTopic string
+	}{
+		{"number", "volume", "sonos/living_room/volume", "sonos/living_room/volume/set"},
+		{"switch", "mute", "sonos/living_room/mute", "sonos/living_room/mute/set"},
+		{"sensor", "transport", "sonos/living_room/transport", ""},
+	} {
+		topic := "homeassistant/" + tt.component + "/" + testUUID + "/" + tt.object + "/config"
+		payload, ok := broker.Retained(topic)
+		if !ok {
+			t.Errorf("%s: not retained", topic)
+			continue
+		}
+		var config discoveryConfig
+		if err := json.Unmarshal(payload, &config); err != nil {
+			t.Errorf("%s: %v", topic, err)
+			continue
+		}
+		if config.StateTopic != tt.stateTopic || config.CommandTopic != tt.commandTopic {
+			t.Errorf("%s: topics %q, %q, want %q, %q", topic,
+				config.StateTopic, config.CommandTopic, tt.stateTopic, tt.commandTopic)
+		}
+		if len(config.Device.Identifiers) != 1 || config.Device.Identifiers[0] != testUUID {
+			t.Errorf("%s: device %+v", topic, config.Device)
+		}
+		if len(config.Availability) != 2 {
+			t.Errorf("%s: availability %+v", topic, config.Availability)
+		}
+	}
+}
+
+func TestBridgeCommands(t *testing.T) {
+	var errs []error
+	b := newTestBridge(t, WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer b.close()
+	f, broker, zp := b.player, b.broker, b.zp
+
+	if err := b.Add(context.Background(), zp); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		topic   string
+		payload string
+		action  string
+		arg     string
+	}{
+		{"volume/set", "30", "SetVolume", "<DesiredVolume>30</DesiredVolume>"},
+		{"mute/set", "on", "SetMute", "<DesiredMute>true</DesiredMute>"},
+		{"transport/set", "pause", "Pause", ""},
+		{"transport/set", "NEXT", "Next", ""},
+		{"play_mode/set", "shuffle", "SetPlayMode", "<NewPlayMode>SHUFFLE</NewPlayMode>"},
+		{"uri/set", "x-rincon-mp3radio://example.com/stream", "Play", ""},
+	} {
+		errs = nil
+		broker.Publish("sonos/living_room/"+tt.topic, []byte(tt.payload), false)
+
+		if len(errs) > 0 {
+			t.Errorf("%s %s: %v", tt.topic, tt.payload, errs)
+			continue
+		}
+		action := f.lastAction()
+		if !strings.HasPrefix(action, tt.action+" ") || !strings.Contains(action, tt.arg) {
+			t.Errorf("%s %s: got %q, want %s %s", tt.topic, tt.payload, action, tt.action, tt.arg)
+		}
+	}
+
+	for _, tt := range []struct {
+		topic   string
+		payload string
+	}{
+		{"volume/set", "loud"},
+		{"mute/set", "maybe"},
+		{"transport/set", "rewind"},
+		{"unknown/set", "1"},
+	} {
+		errs = nil
+		broker.Publish("sonos/living_room/"+tt.topic, []byte(tt.payload), false)
+		if len(errs) != 1 {
+			t.Errorf("%s %s: %d errors, want 1", tt.topic, tt.payload, len(errs))
+		}
+	}
+}
+
+func TestBridgePublishChanges(t *testing.T) {
+	b := newTestBridge(t)
+	defer b.close()
+	broker, zp := b.broker, b.zp
+
+	if err := b.Add(context.Background(), zp); err != nil {
+		t.Fatal(err)
+	}
+	p := b.list()[0]
+
+	type event struct {
+		Type string      `json:"type"`
+		Old  interface{} `json:"old"`
+		New  interface{} `json:"new"`
+	}
+	var events []event
+	broker.Subscribe("sonos/living_room/event", func(_ string, payload []byte) {
+		var e event
+		if err := json.Unmarshal(payload, &e); err != nil {
+			t.Error(err)
+		}
+		events = append(events, e)
+	})
+
+	old := zp.GetState().Snapshot()
+	for _, tt := range []struct {
+		name   string
+		change func(*sonos.PlayerState)
+		events []string
+		topic  string
+		value  string
+	}{
+		{"volume", func(s *sonos.PlayerState) { s.Volume = 40 }, []string{"volume"}, "volume", "40"},
+		{"mute", func(s *sonos.PlayerState) { s.Mute = true }, []string{"mute"}, "mute", "true"},
+		{"transport and track", func(s *sonos.PlayerState) {
+			s.TransportState = "PAUSED_PLAYBACK"
+			s.Track = sonos.MediaObject{Title: "Song", Creator: "Artist"}
+		}, []string{"transport", "track"}, "track", "Artist - Song"},
+		{"unpublished field", func(s *sonos.PlayerState) { s.Bass = 3 }, nil, "volume", "25"},
+	} {
+		events = nil
+		updated := old
+		tt.change(&updated)
+		b.publishChanges(p, old, updated)
+
+		var types []string
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		if fmt.Sprint(types) != fmt.Sprint(tt.events) {
+			t.Errorf("%s: events %v, want %v", tt.name, types, tt.events)
+		}
+		if payload, _ := broker.Retained("sonos/living_room/" + tt.topic); string(payload) != tt.value {
+			t.Errorf("%s: %s = %q, want %q", tt.name, tt.topic, payload, tt.value)
+		}
+
+		// Put the retained fields back for the next case.
+		b.publishState(p, old)
+	}
+}
+
+func TestBridgeAddFailure(t *testing.T) {
+	b := newTestBridge(t)
+	defer b.close()
+	f, broker, zp := b.player, b.broker, b.zp
+
+	f.mu.Lock()
+	f.failSubscribe = 2
+	f.mu.Unlock()
+
+	if err := b.Add(context.Background(), zp); err == nil {
+		t.Fatal("Add succeeded, want the subscription error")
+	}
+	if n := len(b.list()); n != 0 {
+		t.Errorf("%d players after a failed Add, want 0", n)
+	}
+	if n := f.activeSubscriptions(); n != 0 {
+		t.Errorf("%d subscriptions left after a failed Add, want 0", n)
+	}
+	if _, ok := broker.Retained("sonos/living_room/availability"); ok {
+		t.Error("availability published for a failed Add")
+	}
+
+	f.mu.Lock()
+	f.failSubscribe = -1
+	f.mu.Unlock()
+
+	if err := b.Add(context.Background(), zp); err != nil {
+		t.Fatalf("Add after a failure: %v", err)
+	}
+	if n := len(b.list()); n != 1 {
+		t.Errorf("%d players, want 1", n)
+	}
+	if payload, _ := broker.Retained("sonos/living_room/availability"); string(payload) != "online" {
+		t.Errorf("availability = %q, want online", payload)
+	}
+}
+
+func TestBridgeLineInSubscription(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		lineIn bool
+	}{
+		{"line-in", true},
+		{"no line-in", false},
+	} {
+		b := newTestBridge(t)
+		b.player.mu.Lock()
+		b.player.faults["GetAudioInputAttributes"] = !tt.lineIn
+		b.player.mu.Unlock()
+
+		subscribed := false
+		for _, service := range b.services(b.zp) {
+			if service == b.zp.AudioIn() {
+				subscribed = true
+			}
+		}
+		b.close()
+
+		if subscribed != tt.lineIn {
+			t.Errorf("%s: AudioIn subscribed %v, want %v", tt.name, subscribed, tt.lineIn)
+		}
+	}
+}
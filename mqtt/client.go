@@ -0,0 +1,391 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Control packet types of MQTT 3.1.1.
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetSubscribe  = 8
+	packetSuback     = 9
+	packetPingreq    = 12
+	packetPingresp   = 13
+	packetDisconnect = 14
+)
+
+const (
+	defaultKeepAlive = 30 * time.Second
+	// maxRemainingLength is the largest packet body MQTT can encode.
+	maxRemainingLength = 268435455
+)
+
+// Client is what the bridge needs from an MQTT connection. Conn implements
+// it; tests and embedders can provide their own broker stand-in.
+type Client interface {
+	// Publish sends payload to topic at QoS 0.
+	Publish(topic string, payload []byte, retain bool) error
+	// Subscribe calls handler for every message matching filter, which may
+	// contain the + and # wildcards.
+	Subscribe(filter string, handler MessageHandler) error
+}
+
+// MessageHandler is called for every message received on a subscription.
+type MessageHandler func(topic string, payload []byte)
+
+// Conn is a minimal MQTT 3.1.1 client supporting QoS 0, retained messages
+// and a last will.
+type Conn struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	handlers map[string]MessageHandler
+	packetID uint16
+
+	done chan struct{}
+	err  error
+}
+
+// ConnOption configures a Conn.
+type ConnOption func(*connectOptions)
+
+type connectOptions struct {
+	clientID    string
+	username    string
+	password    string
+	keepAlive   time.Duration
+	willTopic   string
+	willPayload []byte
+	willRetain  bool
+}
+
+// WithClientID sets the client identifier sent to the broker.
+func WithClientID(id string) ConnOption {
+	return func(o *connectOptions) {
+		o.clientID = id
+	}
+}
+
+// WithCredentials authenticates with the broker.
+func WithCredentials(username, password string) ConnOption {
+	return func(o *connectOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithKeepAlive sets the keep alive interval; the default is 30 seconds.
+func WithKeepAlive(d time.Duration) ConnOption {
+	return func(o *connectOptions) {
+		o.keepAlive = d
+	}
+}
+
+// WithWill sets the message the broker publishes when the connection is
+// lost without a clean disconnect.
+func WithWill(topic string, payload []byte, retain bool) ConnOption {
+	return func(o *connectOptions) {
+		o.willTopic = topic
+		o.willPayload = payload
+		o.willRetain = retain
+	}
+}
+
+// Dial connects to the broker at addr (host:port).
+func Dial(ctx context.Context, addr string, opts ...ConnOption) (*Conn, error) {
+	o := &connectOptions{
+		clientID:  fmt.Sprintf("sonos-%d", time.Now().UnixNano()),
+		keepAlive: defaultKeepAlive,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		conn:     nc,
+		handlers: make(map[string]MessageHandler),
+		done:     make(chan struct{}),
+	}
+
+	if err := c.write(packetConnect, 0, connectPayload(o)); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	r := bufio.NewReader(nc)
+	header, body, err := readPacket(r)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if header>>4 != packetConnack || len(body) != 2 {
+		nc.Close()
+		return nil, errors.New("mqtt: unexpected response to CONNECT")
+	}
+	if body[1] != 0 {
+		nc.Close()
+		return nil, fmt.Errorf("mqtt: connection refused, return code %d", body[1])
+	}
+
+	go c.readLoop(r)
+	go c.keepAlive(o.keepAlive)
+
+	return c, nil
+}
+
+func connectPayload(o *connectOptions) []byte {
+	var flags byte = 0x02 // clean session
+	var p []byte
+	p = appendString(p, "MQTT")
+	p = append(p, 4) // protocol level 3.1.1
+
+	if o.willTopic != "" {
+		flags |= 0x04
+		if o.willRetain {
+			flags |= 0x20
+		}
+	}
+	if o.username != "" {
+		flags |= 0x80
+		if o.password != "" {
+			flags |= 0x40
+		}
+	}
+	keepAlive := uint16(o.keepAlive / time.Second)
+	p = append(p, flags, byte(keepAlive>>8), byte(keepAlive))
+
+	p = appendString(p, o.clientID)
+	if o.willTopic != "" {
+		p = appendString(p, o.willTopic)
+		p = appendBytes(p, o.willPayload)
+	}
+	if o.username != "" {
+		p = appendString(p, o.username)
+		if o.password != "" {
+			p = appendString(p, o.password)
+		}
+	}
+	return p
+}
+
+// Publish sends payload to topic at QoS 0.
+func (c *Conn) Publish(topic string, payload []byte, retain bool) error {
+	var flags byte
+	if retain {
+		flags |= 0x01
+	}
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return c.write(packetPublish, flags, body)
+}
+
+// Subscribe calls handler for every message matching filter.
+func (c *Conn) Subscribe(filter string, handler MessageHandler) error {
+	c.mu.Lock()
+	c.handlers[filter] = handler
+	c.packetID++
+	if c.packetID == 0 {
+		c.packetID = 1
+	}
+	id := c.packetID
+	c.mu.Unlock()
+
+	body := []byte{byte(id >> 8), byte(id)}
+	body = appendString(body, filter)
+	body = append(body, 0) // QoS 0
+	return c.write(packetSubscribe, 0x02, body)
+}
+
+// Done is closed when the connection is lost or closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the connection ended, once Done is closed.
+func (c *Conn) Err() error {
+	<-c.done
+	return c.err
+}
+
+// Close disconnects cleanly, so the will is not published.
+func (c *Conn) Close() error {
+	c.write(packetDisconnect, 0, nil)
+	return c.conn.Close()
+}
+
+func (c *Conn) write(packetType, flags byte, body []byte) error {
+	if len(body) > maxRemainingLength {
+		return errors.New("mqtt: packet too large")
+	}
+
+	packet := []byte{packetType<<4 | flags}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+func (c *Conn) readLoop(r *bufio.Reader) {
+	defer close(c.done)
+
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			c.err = err
+			c.conn.Close()
+			return
+		}
+
+		switch header >> 4 {
+		case packetPublish:
+			c.dispatch(header, body)
+		case packetSuback, packetPingresp:
+		default:
+			c.err = fmt.Errorf("mqtt: unexpected packet type %d", header>>4)
+			c.conn.Close()
+			return
+		}
+	}
+}
+
+func (c *Conn) dispatch(header byte, body []byte) {
+	if len(body) < 2 {
+		return
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+n {
+		return
+	}
+	topic := string(body[2 : 2+n])
+	payload := body[2+n:]
+	if qos := header >> 1 & 0x03; qos > 0 {
+		// We only subscribe at QoS 0, skip the packet identifier anyway.
+		if len(payload) < 2 {
+			return
+		}
+		payload = payload[2:]
+	}
+
+	c.mu.Lock()
+	var handlers []MessageHandler
+	for filter, h := range c.handlers {
+		if Match(filter, topic) {
+			handlers = append(handlers, h)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, h := range handlers {
+		h(topic, payload)
+	}
+}
+
+func (c *Conn) keepAlive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.write(packetPingreq, 0, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var n, shift int
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+		if shift > 21 {
+			return 0, nil, errors.New("mqtt: malformed remaining length")
+		}
+	}
+
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	return appendBytes(b, []byte(s))
+}
+
+func appendBytes(b []byte, p []byte) []byte {
+	b = append(b, byte(len(p)>>8), byte(len(p)))
+	return append(b, p...)
+}
+
+// Match reports whether topic matches filter, which may contain the + and #
+// wildcards.
+func Match(filter, topic string) bool {
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	for i, level := range f {
+		if level == "#" {
+			return true
+		}
+		if i >= len(t) {
+			return false
+		}
+		if level != "+" && level != t[i] {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
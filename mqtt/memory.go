@@ -0,0 +1,76 @@
+package mqtt
+
+import "sync"
+
+// MemoryBroker is an in-process Client that delivers messages to its own
+// subscribers and keeps retained messages. It stands in for a broker when
+// running a Bridge without a network, e.g. in tests.
+type MemoryBroker struct {
+	mu       sync.Mutex
+	retained map[string][]byte
+	subs     []memorySubscription
+}
+
+type memorySubscription struct {
+	filter  string
+	handler MessageHandler
+}
+
+// NewMemoryBroker returns an empty MemoryBroker.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{retained: make(map[string][]byte)}
+}
+
+// Publish delivers payload to the matching subscribers and, if retain is
+// set, keeps it for Retained and later subscribers. An empty retained
+// payload clears the topic.
+func (m *MemoryBroker) Publish(topic string, payload []byte, retain bool) error {
+	m.mu.Lock()
+	if retain {
+		if len(payload) == 0 {
+			delete(m.retained, topic)
+		} else {
+			m.retained[topic] = append([]byte(nil), payload...)
+		}
+	}
+	var handlers []MessageHandler
+	for _, sub := range m.subs {
+		if Match(sub.filter, topic) {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, h := range handlers {
+		h(topic, payload)
+	}
+	return nil
+}
+
+// Subscribe registers handler and delivers the retained messages matching
+// filter to it.
+func (m *MemoryBroker) Subscribe(filter string, handler MessageHandler) error {
+	m.mu.Lock()
+	m.subs = append(m.subs, memorySubscription{filter, handler})
+	retained := make(map[string][]byte)
+	for topic, payload := range m.retained {
+		if Match(filter, topic) {
+			retained[topic] = payload
+		}
+	}
+	m.mu.Unlock()
+
+	for topic, payload := range retained {
+		handler(topic, payload)
+	}
+	return nil
+}
+
+// Retained returns the retained message of topic.
+func (m *MemoryBroker) Retained(topic string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	payload, ok := m.retained[topic]
+	return payload, ok
+}
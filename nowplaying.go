@@ -0,0 +1,48 @@
+package sonos
+
+import (
+	"time"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+)
+
+// NowPlaying describes what a player is currently playing.
+type NowPlaying struct {
+	TransportState string
+	PlayMode       PlayMode
+	// TrackNumber is the position of the track in the queue, starting at 1.
+	TrackNumber int
+	TrackURI    string
+	Track       MediaObject
+	Position    time.Duration
+	Duration    time.Duration
+}
+
+// NowPlaying returns the current track and the position in it. Members of a
+// group report the track of their coordinator.
+func (z *ZonePlayer) NowPlaying() (*NowPlaying, error) {
+	info, err := z.AVTransport().GetTransportInfo(&avt.GetTransportInfoArgs{})
+	if err != nil {
+		return nil, err
+	}
+	settings, err := z.AVTransport().GetTransportSettings(&avt.GetTransportSettingsArgs{})
+	if err != nil {
+		return nil, err
+	}
+	position, err := z.AVTransport().GetPositionInfo(&avt.GetPositionInfoArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	n := &NowPlaying{
+		TransportState: info.CurrentTransportState,
+		PlayMode:       PlayMode(settings.PlayMode),
+		TrackNumber:    int(position.Track),
+		TrackURI:       position.TrackURI,
+		Track:          trackMetadata(position.TrackMetaData),
+	}
+	// Streams have no position or duration; both are then zero.
+	n.Position, _ = ParseDuration(position.RelTime)
+	n.Duration, _ = ParseDuration(position.TrackDuration)
+	return n, nil
+}
@@ -0,0 +1,126 @@
+package sonos
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// errDrop makes testPlayer close the connection without a response.
+var errDrop = errors.New("drop connection")
+
+// testFault makes testPlayer answer with a UPnP fault of that code.
+type testFault int
+
+func (f testFault) Error() string {
+	return fmt.Sprintf("UPnP error %d", int(f))
+}
+
+// testPlayer is a player served from a loopback HTTP server. Every SOAP
+// action is passed to respond, which returns the inner XML of the response
+// or an error: testFault for a fault, errDrop to close the connection.
+// Event subscriptions are accepted and recorded as "SUBSCRIBE <path>" and
+// "UNSUBSCRIBE <path>".
+type testPlayer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	uuid     string
+	model    string
+	display  string
+	respond  func(action, args string) (string, error)
+	requests []string
+	sids     int
+}
+
+const testPlayerUUID = "RINCON_1"
+
+func newTestPlayer(respond func(action, args string) (string, error)) *testPlayer {
+	p := &testPlayer{uuid: testPlayerUUID, respond: respond}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	return p
+}
+
+func (p *testPlayer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r.Method == http.MethodGet {
+		p.requests = append(p.requests, "GET "+r.URL.Path)
+		fmt.Fprintf(w, `<root><device><UDN>uuid:%s</UDN><roomName>Kitchen</roomName>`+
+			`<modelNumber>%s</modelNumber><displayName>%s</displayName></device></root>`,
+			p.uuid, p.model, p.display)
+		return
+	}
+	switch r.Method {
+	case "SUBSCRIBE":
+		p.requests = append(p.requests, "SUBSCRIBE "+r.URL.Path)
+		p.sids++
+		w.Header().Set("SID", fmt.Sprintf("uuid:%s-sub-%d", p.uuid, p.sids))
+		return
+	case "UNSUBSCRIBE":
+		p.requests = append(p.requests, "UNSUBSCRIBE "+r.URL.Path)
+		return
+	}
+
+	soapAction := strings.Trim(r.Header.Get("SOAPAction"), `"`)
+	i := strings.LastIndex(soapAction, "#")
+	urn, action := soapAction[:i], soapAction[i+1:]
+
+	var body struct {
+		Inner string `xml:",innerxml"`
+	}
+	xml.NewDecoder(r.Body).Decode(&body)
+	p.requests = append(p.requests, action+" "+body.Inner)
+
+	result, err := p.respond(action, body.Inner)
+	var fault testFault
+	switch {
+	case err == errDrop:
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	case errors.As(err, &fault):
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>`+
+			`<faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`+
+			`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode></UPnPError>`+
+			`</detail></s:Fault></s:Body></s:Envelope>`, int(fault))
+	default:
+		fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+			`<u:%sResponse xmlns:u="%s">%s</u:%sResponse></s:Body></s:Envelope>`, action, urn, result, action)
+	}
+}
+
+// actions returns the requests received for action.
+func (p *testPlayer) actions(action string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var requests []string
+	for _, r := range p.requests {
+		if strings.HasPrefix(r, action+" ") {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+func (p *testPlayer) zonePlayer(t *testing.T) *ZonePlayer {
+	u, _ := url.Parse(p.URL + "/xml/device_description.xml")
+	zp, err := NewZonePlayer(WithLocation(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zp
+}
+
+// testZoneGroupState is a household of the test player alone.
+var testZoneGroupState = "<ZoneGroupState>" + escapeXML(`<ZoneGroupState><ZoneGroups>`+
+	`<ZoneGroup Coordinator="`+testPlayerUUID+`"><ZoneGroupMember UUID="`+testPlayerUUID+`" ZoneName="Kitchen"/>`+
+	`</ZoneGroup></ZoneGroups></ZoneGroupState>`) + "</ZoneGroupState>"
@@ -0,0 +1,238 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+	dir "github.com/caglar10ur/sonos/services/ContentDirectory"
+)
+
+// Playlist is a Sonos playlist (a saved queue living under "SQ:"). It tracks
+// the UpdateID the device uses for optimistic locking so callers never have
+// to.
+type Playlist struct {
+	MediaObject
+
+	zp      *ZonePlayer
+	tracker updateIDTracker
+}
+
+func newPlaylist(zp *ZonePlayer, m MediaObject) *Playlist {
+	p := &Playlist{MediaObject: m, zp: zp}
+	p.tracker.fetch = p.fetchUpdateID
+	return p
+}
+
+// SonosPlaylists lists the Sonos playlists of the household.
+func (z *ZonePlayer) SonosPlaylists(ctx context.Context) ([]*Playlist, error) {
+	objects, err := z.Browse(ctx, SonosPlaylists).MediaObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]*Playlist, 0, len(objects))
+	for _, o := range objects {
+		playlists = append(playlists, newPlaylist(z, o))
+	}
+	return playlists, nil
+}
+
+// SonosPlaylist looks up a Sonos playlist by name, ignoring case.
+func (z *ZonePlayer) SonosPlaylist(ctx context.Context, name string) (*Playlist, error) {
+	m, err := z.findByTitle(ctx, SonosPlaylists, name)
+	if err != nil {
+		return nil, err
+	}
+	return newPlaylist(z, *m), nil
+}
+
+// CreateSonosPlaylist creates a new, empty Sonos playlist.
+func (z *ZonePlayer) CreateSonosPlaylist(title string) (*Playlist, error) {
+	res, err := z.AVTransport().CreateSavedQueue(&avt.CreateSavedQueueArgs{
+		Title: title,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPlaylist(z, MediaObject{
+		ID:          res.AssignedObjectID,
+		ParentID:    SonosPlaylists,
+		Title:       title,
+		Class:       "object.container.playlistContainer",
+		URI:         savedQueueURI(res.AssignedObjectID),
+		IsContainer: true,
+	})
+	p.tracker.set(res.NewUpdateID)
+
+	return p, nil
+}
+
+// PlaySonosPlaylist looks up a Sonos playlist by name and plays it, replacing
+// the current queue.
+func (z *ZonePlayer) PlaySonosPlaylist(ctx context.Context, name string) error {
+	p, err := z.SonosPlaylist(ctx, name)
+	if err != nil {
+		return err
+	}
+	return p.Play()
+}
+
+// Tracks lists the tracks of the playlist.
+func (p *Playlist) Tracks(ctx context.Context) ([]MediaObject, error) {
+	it := p.zp.Browse(ctx, p.ID)
+	objects, err := it.MediaObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	p.tracker.set(it.UpdateID())
+
+	return objects, nil
+}
+
+// Refresh fetches the current UpdateID of the playlist from the device.
+func (p *Playlist) Refresh() error {
+	updateID, err := p.fetchUpdateID()
+	if err != nil {
+		return err
+	}
+	p.tracker.set(updateID)
+	return nil
+}
+
+func (p *Playlist) fetchUpdateID() (uint32, error) {
+	res, err := p.zp.ContentDirectory().Browse(&dir.BrowseArgs{
+		ObjectID:       p.ID,
+		BrowseFlag:     "BrowseDirectChildren",
+		Filter:         "dc:title",
+		RequestedCount: 1,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.UpdateID, nil
+}
+
+// Add appends the given objects to the end of the playlist.
+func (p *Playlist) Add(objects ...MediaObject) error {
+	for _, m := range objects {
+		if err := p.Insert(math.MaxUint32, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Insert adds the object at the given zero based position.
+func (p *Playlist) Insert(index uint32, m MediaObject) error {
+	return p.tracker.do(func(updateID uint32) (*uint32, error) {
+		res, err := p.zp.AVTransport().AddURIToSavedQueue(&avt.AddURIToSavedQueueArgs{
+			ObjectID:            p.ID,
+			UpdateID:            updateID,
+			EnqueuedURI:         m.URI,
+			EnqueuedURIMetaData: m.Metadata(),
+			AddAtIndex:          index,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &res.NewUpdateID, nil
+	})
+}
+
+// Move moves the track at the zero based index from to position to.
+func (p *Playlist) Move(from, to int) error {
+	return p.Reorder([]int{from}, []int{to})
+}
+
+// Reorder moves each track in tracks to the matching position in positions.
+// Both are zero based indices.
+func (p *Playlist) Reorder(tracks, positions []int) error {
+	if len(tracks) != len(positions) {
+		return errors.New("tracks and positions must have the same length")
+	}
+	return p.reorder(joinInts(tracks), joinInts(positions))
+}
+
+// Remove removes the tracks at the given zero based indices.
+func (p *Playlist) Remove(tracks ...int) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+	return p.reorder(joinInts(tracks), "")
+}
+
+func (p *Playlist) reorder(trackList, newPositionList string) error {
+	return p.tracker.do(func(updateID uint32) (*uint32, error) {
+		res, err := p.zp.AVTransport().ReorderTracksInSavedQueue(&avt.ReorderTracksInSavedQueueArgs{
+			ObjectID:        p.ID,
+			UpdateID:        updateID,
+			TrackList:       trackList,
+			NewPositionList: newPositionList,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &res.NewUpdateID, nil
+	})
+}
+
+// Rename changes the title of the playlist.
+func (p *Playlist) Rename(title string) error {
+	_, err := p.zp.ContentDirectory().UpdateObject(&dir.UpdateObjectArgs{
+		ObjectID:        p.ID,
+		CurrentTagValue: "<dc:title>" + escapeXML(p.Title) + "</dc:title>",
+		NewTagValue:     "<dc:title>" + escapeXML(title) + "</dc:title>",
+	})
+	if err != nil {
+		return err
+	}
+
+	p.Title = title
+	p.tracker.invalidate()
+
+	return nil
+}
+
+// Delete removes the playlist from the household.
+func (p *Playlist) Delete() error {
+	_, err := p.zp.ContentDirectory().DestroyObject(&dir.DestroyObjectArgs{
+		ObjectID: p.ID,
+	})
+	return err
+}
+
+// Play replaces the queue with the playlist and starts playing it.
+func (p *Playlist) Play() error {
+	_, err := p.zp.AVTransport().RemoveAllTracksFromQueue(&avt.RemoveAllTracksFromQueueArgs{})
+	if err != nil {
+		return err
+	}
+	_, err = p.zp.AVTransport().AddURIToQueue(&avt.AddURIToQueueArgs{
+		EnqueuedURI:         p.URI,
+		EnqueuedURIMetaData: p.Metadata(),
+	})
+	if err != nil {
+		return err
+	}
+	return p.zp.playFromQueue(1)
+}
+
+// savedQueueURI returns the URI used to enqueue the Sonos playlist with the
+// given "SQ:" object ID.
+func savedQueueURI(objectID string) string {
+	return "file:///jffs/settings/savedqueues.rsq#" + strings.TrimPrefix(objectID, SonosPlaylists)
+}
+
+func joinInts(values []int) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ",")
+}
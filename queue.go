@@ -0,0 +1,267 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+	que "github.com/caglar10ur/sonos/services/Queue"
+)
+
+// Queue manipulates the play queue of a player. All indices are zero based;
+// the translation to the 1 based track numbers of the UPnP actions and the
+// UpdateID bookkeeping are done internally.
+type Queue struct {
+	zp      *ZonePlayer
+	tracker updateIDTracker
+}
+
+// GetQueue returns the queue of the player. The same instance is returned on
+// every call so that the UpdateID learnt from events is shared.
+func (z *ZonePlayer) GetQueue() *Queue {
+	z.queueOnce.Do(func() {
+		z.queue = &Queue{zp: z}
+		z.queue.tracker.fetch = z.queue.fetchUpdateID
+	})
+	return z.queue
+}
+
+func (q *Queue) fetchUpdateID() (uint32, error) {
+	_, updateID, err := q.status()
+	return updateID, err
+}
+
+// status returns the current length and UpdateID of the queue.
+func (q *Queue) status() (uint32, uint32, error) {
+	res, err := q.zp.Queue().Browse(&que.BrowseArgs{
+		RequestedCount: 1,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return res.TotalMatches, res.UpdateID, nil
+}
+
+// handleLastChange keeps the UpdateID in sync with Queue LastChange events.
+func (q *Queue) handleLastChange(e *QueueLastChange) {
+	for _, queue := range e.QueueID {
+		if queue.Value != "0" || queue.UpdateID.Value == "" {
+			continue
+		}
+		updateID, err := strconv.ParseUint(queue.UpdateID.Value, 10, 32)
+		if err != nil {
+			continue
+		}
+		q.setUpdateID(uint32(updateID))
+	}
+}
+
+// sync fetches the UpdateID of the queue, e.g. after missed events.
+func (q *Queue) sync() error {
+	updateID, err := q.fetchUpdateID()
+	if err != nil {
+		return err
+	}
+	q.setUpdateID(updateID)
+	return nil
+}
+
+// setUpdateID records an UpdateID learnt from the device and reports it in
+// the state of the player.
+func (q *Queue) setUpdateID(updateID uint32) {
+	q.tracker.set(updateID)
+	q.zp.GetState().update(func(state *PlayerState) {
+		state.QueueUpdateID = updateID
+	})
+}
+
+// List returns every track in the queue.
+func (q *Queue) List(ctx context.Context) ([]MediaObject, error) {
+	it := q.zp.BrowseQueue(ctx)
+	tracks, err := it.MediaObjects()
+	if err != nil {
+		return nil, err
+	}
+	q.tracker.set(it.UpdateID())
+	return tracks, nil
+}
+
+// Add appends the objects to the end of the queue and returns the index of
+// the first one added.
+func (q *Queue) Add(objects ...MediaObject) (int, error) {
+	return q.add(0, objects)
+}
+
+// Insert adds the objects at the given index and returns the index of the
+// first one added.
+func (q *Queue) Insert(index int, objects ...MediaObject) (int, error) {
+	if index < 0 {
+		return 0, errors.New("index out of range")
+	}
+	return q.add(uint32(index)+1, objects)
+}
+
+// add enqueues objects starting at the 1 based track number desired, or at
+// the end of the queue when desired is 0.
+func (q *Queue) add(desired uint32, objects []MediaObject) (int, error) {
+	if len(objects) == 0 {
+		return 0, errors.New("nothing to add")
+	}
+	defer q.tracker.invalidate()
+
+	first := -1
+	for _, m := range objects {
+		res, err := q.zp.AVTransport().AddURIToQueue(&avt.AddURIToQueueArgs{
+			EnqueuedURI:                     m.URI,
+			EnqueuedURIMetaData:             m.Metadata(),
+			DesiredFirstTrackNumberEnqueued: desired,
+		})
+		if err != nil {
+			return first, err
+		}
+		if first < 0 {
+			first = int(res.FirstTrackNumberEnqueued) - 1
+		}
+		if desired > 0 {
+			desired = res.FirstTrackNumberEnqueued + res.NumTracksAdded
+		}
+	}
+	return first, nil
+}
+
+// Move moves the track at index from so that it ends up at index to.
+func (q *Queue) Move(from, to int) error {
+	if from < 0 || to < 0 {
+		return errors.New("index out of range")
+	}
+	if from == to {
+		return nil
+	}
+
+	// InsertBefore refers to the numbering before the track is taken out.
+	insertBefore := uint32(to) + 1
+	if to > from {
+		insertBefore++
+	}
+
+	return q.tracker.do(func(updateID uint32) (*uint32, error) {
+		_, err := q.zp.AVTransport().ReorderTracksInQueue(&avt.ReorderTracksInQueueArgs{
+			StartingIndex:  uint32(from) + 1,
+			NumberOfTracks: 1,
+			InsertBefore:   insertBefore,
+			UpdateID:       updateID,
+		})
+		return nil, err
+	})
+}
+
+// Remove removes the track at index.
+func (q *Queue) Remove(index int) error {
+	return q.RemoveRange(index, 1)
+}
+
+// RemoveRange removes count tracks starting at index.
+func (q *Queue) RemoveRange(index, count int) error {
+	if index < 0 || count <= 0 {
+		return errors.New("index out of range")
+	}
+
+	return q.tracker.do(func(updateID uint32) (*uint32, error) {
+		res, err := q.zp.AVTransport().RemoveTrackRangeFromQueue(&avt.RemoveTrackRangeFromQueueArgs{
+			UpdateID:       updateID,
+			StartingIndex:  uint32(index) + 1,
+			NumberOfTracks: uint32(count),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &res.NewUpdateID, nil
+	})
+}
+
+// Clear removes every track from the queue.
+func (q *Queue) Clear() error {
+	defer q.tracker.invalidate()
+
+	_, err := q.zp.AVTransport().RemoveAllTracksFromQueue(&avt.RemoveAllTracksFromQueueArgs{})
+	return err
+}
+
+// Shuffle turns shuffle on or off while keeping the current repeat setting.
+func (q *Queue) Shuffle(enabled bool) error {
+	res, err := q.zp.AVTransport().GetTransportSettings(&avt.GetTransportSettingsArgs{})
+	if err != nil {
+		return err
+	}
+
+	return q.zp.SetPlayMode(shufflePlayMode(PlayMode(res.PlayMode), enabled))
+}
+
+// PlayMode is the play mode of the AVTransport service, combining the
+// shuffle and repeat settings.
+type PlayMode string
+
+const (
+	PlayModeNormal           PlayMode = "NORMAL"
+	PlayModeRepeatAll        PlayMode = "REPEAT_ALL"
+	PlayModeRepeatOne        PlayMode = "REPEAT_ONE"
+	PlayModeShuffleNoRepeat  PlayMode = "SHUFFLE_NOREPEAT"
+	PlayModeShuffle          PlayMode = "SHUFFLE"
+	PlayModeShuffleRepeatOne PlayMode = "SHUFFLE_REPEAT_ONE"
+)
+
+// shufflePlayMode returns the play mode with the same repeat setting as
+// mode and the requested shuffle setting.
+func shufflePlayMode(mode PlayMode, shuffle bool) PlayMode {
+	var repeatAll, repeatOne bool
+	switch mode {
+	case PlayModeRepeatAll, PlayModeShuffle:
+		repeatAll = true
+	case PlayModeRepeatOne, PlayModeShuffleRepeatOne:
+		repeatOne = true
+	}
+
+	switch {
+	case shuffle && repeatAll:
+		return PlayModeShuffle
+	case shuffle && repeatOne:
+		return PlayModeShuffleRepeatOne
+	case shuffle:
+		return PlayModeShuffleNoRepeat
+	case repeatAll:
+		return PlayModeRepeatAll
+	case repeatOne:
+		return PlayModeRepeatOne
+	default:
+		return PlayModeNormal
+	}
+}
+
+// PlayFrom switches the player to the queue and starts playing the track at
+// index.
+func (q *Queue) PlayFrom(index int) error {
+	if index < 0 {
+		return errors.New("index out of range")
+	}
+	return q.zp.playFromQueue(uint32(index) + 1)
+}
+
+// SaveAs stores the queue as a new Sonos playlist.
+func (q *Queue) SaveAs(title string) (*Playlist, error) {
+	res, err := q.zp.AVTransport().SaveQueue(&avt.SaveQueueArgs{
+		Title: title,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newPlaylist(q.zp, MediaObject{
+		ID:          res.AssignedObjectID,
+		ParentID:    SonosPlaylists,
+		Title:       title,
+		Class:       "object.container.playlistContainer",
+		URI:         savedQueueURI(res.AssignedObjectID),
+		IsContainer: true,
+	}), nil
+}
@@ -0,0 +1,203 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRoomNotFound is returned when no room matches the requested name.
+var ErrRoomNotFound = errors.New("room not found")
+
+// AmbiguousRoomError is returned when a name matches several rooms, e.g. a
+// partial name or the same room name in two households.
+type AmbiguousRoomError struct {
+	Room    string
+	Matches []string
+}
+
+func (e *AmbiguousRoomError) Error() string {
+	return fmt.Sprintf("room %q is ambiguous: matches %s", e.Room, strings.Join(e.Matches, ", "))
+}
+
+// RoomOption changes how FindRoom resolves a room.
+type RoomOption func(*roomQuery)
+
+type roomQuery struct {
+	member bool
+}
+
+// WithRoomMember makes FindRoom return the player of the room itself
+// rather than the coordinator of the group the room is part of.
+func WithRoomMember() RoomOption {
+	return func(q *roomQuery) {
+		q.member = true
+	}
+}
+
+type roomMatch struct {
+	via    *ZonePlayer
+	group  ZoneGroup
+	member ZoneGroupMember
+}
+
+// FindRoom returns the coordinator of the group the room with the given name
+// is part of. Names are matched case-insensitively; if no room matches
+// exactly, a unique partial match is accepted. Players found earlier, or
+// remembered by the household cache, are used before searching.
+func (s *Sonos) FindRoom(ctx context.Context, room string, opts ...RoomOption) (*ZonePlayer, error) {
+	return s.findRoom(ctx, room, s.wantHousehold, opts)
+}
+
+func (s *Sonos) findRoom(ctx context.Context, room string, inHousehold func(string) bool, opts []RoomOption) (*ZonePlayer, error) {
+	q := &roomQuery{}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	zp, err := s.resolveRoom(room, inHousehold, q)
+	if !errors.Is(err, ErrRoomNotFound) {
+		return zp, err
+	}
+
+	// The channel is never closed: Search may report players after we
+	// returned.
+	found := make(chan struct{}, 1)
+	err = s.Search(ctx, func(*Sonos, *ZonePlayer) {
+		select {
+		case found <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("room %q: %w", room, ErrRoomNotFound)
+		case <-found:
+			zp, err := s.resolveRoom(room, inHousehold, q)
+			if !errors.Is(err, ErrRoomNotFound) {
+				return zp, err
+			}
+		}
+	}
+}
+
+// resolveRoom looks room up in the topology of every household known so
+// far: first in the topology kept by the state of the players, then, if the
+// room is not found there, in the one the players report.
+func (s *Sonos) resolveRoom(room string, inHousehold func(string) bool, q *roomQuery) (*ZonePlayer, error) {
+	zp, err := s.matchRoom(room, inHousehold, q, cachedTopology)
+	if errors.Is(err, ErrRoomNotFound) {
+		zp, err = s.matchRoom(room, inHousehold, q, currentTopology)
+	}
+	return zp, err
+}
+
+// topologyFunc returns the topology of the household of players and the
+// player it was seen through, or nil if it is not available.
+type topologyFunc func(players []*ZonePlayer) (*ZonePlayer, *ZoneGroupState)
+
+// cachedTopology returns the topology last seen in the state of a player,
+// kept up to date by ZoneGroupTopology events.
+func cachedTopology(players []*ZonePlayer) (*ZonePlayer, *ZoneGroupState) {
+	for _, zp := range players {
+		if topology := zp.GetState().Snapshot().Topology; topology != nil {
+			return zp, topology
+		}
+	}
+	return nil, nil
+}
+
+// currentTopology asks the first player for the topology.
+func currentTopology(players []*ZonePlayer) (*ZonePlayer, *ZoneGroupState) {
+	zoneGroupState, err := players[0].GetZoneGroupState()
+	if err != nil {
+		return nil, nil
+	}
+	return players[0], zoneGroupState
+}
+
+func (s *Sonos) matchRoom(room string, inHousehold func(string) bool, q *roomQuery, topology topologyFunc) (*ZonePlayer, error) {
+	var exact, partial []roomMatch
+	for _, h := range s.Households() {
+		if !inHousehold(h.ID) {
+			continue
+		}
+		players := h.Players()
+		if len(players) == 0 {
+			continue
+		}
+		via, zoneGroupState := topology(players)
+		if zoneGroupState == nil {
+			continue
+		}
+
+		for _, group := range zoneGroupState.ZoneGroups {
+			for _, member := range group.ZoneGroupMember {
+				// Invisible members are the secondary speakers of bonded
+				// rooms; the room resolves to its primary.
+				if member.Invisible == "1" {
+					continue
+				}
+				m := roomMatch{via: via, group: group, member: member}
+				switch {
+				case strings.EqualFold(member.ZoneName, room):
+					exact = append(exact, m)
+				case strings.Contains(strings.ToLower(member.ZoneName), strings.ToLower(room)):
+					partial = append(partial, m)
+				}
+			}
+		}
+	}
+
+	matches := exact
+	if len(matches) == 0 {
+		matches = partial
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("room %q: %w", room, ErrRoomNotFound)
+	case 1:
+	default:
+		err := &AmbiguousRoomError{Room: room}
+		for _, m := range matches {
+			err.Matches = append(err.Matches, m.member.ZoneName)
+		}
+		return nil, err
+	}
+
+	m := matches[0]
+	uuid := m.group.Coordinator
+	if q.member {
+		uuid = m.member.UUID
+	}
+	return s.player(m.via, &m.group, uuid)
+}
+
+// player returns the known player with the given UUID, or creates one for
+// the member of group.
+func (s *Sonos) player(via *ZonePlayer, group *ZoneGroup, uuid string) (*ZonePlayer, error) {
+	var known *ZonePlayer
+	s.zonePlayers.Range(func(_, p interface{}) bool {
+		if zp := p.(*ZonePlayer); zp.UUID() == uuid {
+			known = zp
+			return false
+		}
+		return true
+	})
+	if known != nil {
+		return known, nil
+	}
+
+	for i := range group.ZoneGroupMember {
+		if member := &group.ZoneGroupMember[i]; member.UUID == uuid {
+			return via.peer(member)
+		}
+	}
+	return nil, fmt.Errorf("player %s: %w", uuid, ErrNotFound)
+}
@@ -0,0 +1,55 @@
+package sonos
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestResolveRoomTopology(t *testing.T) {
+	p := newTestPlayer(func(action, _ string) (string, error) {
+		if action != "GetZoneGroupState" {
+			return "", nil
+		}
+		return "<ZoneGroupState>" + escapeXML(fmt.Sprintf(`<ZoneGroupState><ZoneGroups>`+
+			`<ZoneGroup Coordinator="%s"><ZoneGroupMember UUID="%s" ZoneName="Kitchen"/>`+
+			`<ZoneGroupMember UUID="RINCON_2" ZoneName="Den"/>`+
+			`</ZoneGroup></ZoneGroups></ZoneGroupState>`, testPlayerUUID, testPlayerUUID)) + "</ZoneGroupState>", nil
+	})
+	defer p.Close()
+
+	// The cached topology predates the Den.
+	cached := &ZoneGroupState{ZoneGroups: []ZoneGroup{{
+		Coordinator:     testPlayerUUID,
+		ZoneGroupMember: []ZoneGroupMember{{UUID: testPlayerUUID, ZoneName: "Kitchen"}},
+	}}}
+
+	for _, tt := range []struct {
+		name     string
+		topology *ZoneGroupState
+		room     string
+		fetches  int
+	}{
+		{"cached", cached, "kitchen", 0},
+		{"missing from the cache", cached, "den", 1},
+		{"not cached", nil, "kitchen", 1},
+	} {
+		zp := p.zonePlayer(t)
+		zp.setHouseholdID("Sonos_1")
+		zp.GetState().update(func(state *PlayerState) {
+			state.Topology = tt.topology
+		})
+		s := &Sonos{peers: newPeers()}
+		s.zonePlayers.Store(zp.SerialNum(), zp)
+		before := len(p.actions("GetZoneGroupState"))
+
+		found, err := s.resolveRoom(tt.room, func(string) bool { return true }, &roomQuery{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if found != zp {
+			t.Errorf("%s: resolved to %v, want the coordinator", tt.name, found)
+		}
+		if fetches := len(p.actions("GetZoneGroupState")) - before; fetches != tt.fetches {
+			t.Errorf("%s: %d GetZoneGroupState calls, want %d", tt.name, fetches, tt.fetches)
+		}
+	}
+}
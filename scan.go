@@ -0,0 +1,269 @@
+package sonos
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Discovery selects the strategies Search uses to find players.
+type Discovery int
+
+const (
+	// DiscoverSSDP multicasts an M-SEARCH request.
+	DiscoverSSDP Discovery = 1 << iota
+	// DiscoverScan probes addresses one by one, for networks that block
+	// multicast. See Scan.
+	DiscoverScan
+)
+
+const (
+	// DefaultScanConcurrency is the number of addresses probed at once.
+	DefaultScanConcurrency = 32
+	// scanTimeout bounds a single probe.
+	scanTimeout = 2 * time.Second
+	// maxScanHosts bounds the number of addresses a CIDR target expands to.
+	maxScanHosts = 1 << 16
+)
+
+// WithDiscovery selects the discovery strategies used by Search; they can be
+// combined, e.g. DiscoverSSDP|DiscoverScan. The default is DiscoverSSDP.
+func WithDiscovery(d Discovery) SonosOption {
+	return func(s *Sonos) {
+		s.discovery = d
+	}
+}
+
+// WithScanTargets sets the CIDR ranges and hosts probed by DiscoverScan.
+// By default the /24 networks of the local interfaces are probed.
+func WithScanTargets(targets ...string) SonosOption {
+	return func(s *Sonos) {
+		s.scanTargets = targets
+	}
+}
+
+// WithScanConcurrency bounds the number of addresses probed at once.
+func WithScanConcurrency(n int) SonosOption {
+	return func(s *Sonos) {
+		s.scanConcurrency = n
+	}
+}
+
+// Scan probes the given CIDR ranges and hosts for a player. The whole
+// household is then expanded from the ZoneGroupState of the first player
+// that responds and its coordinators are reported to foundFn, like Search
+// does. Without targets the local networks are probed. Scan blocks until a
+// household has been found, every address was probed or ctx is done.
+func (s *Sonos) Scan(ctx context.Context, foundFn FoundZonePlayer, targets ...string) error {
+	if len(targets) == 0 {
+		var err error
+		if targets, err = localScanTargets(); err != nil {
+			return err
+		}
+	}
+
+	var hosts []string
+	for _, target := range targets {
+		h, err := expandScanTarget(target)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, h...)
+	}
+
+	concurrency := s.scanConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultScanConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan string)
+	found := make(chan *url.URL, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range jobs {
+				location, err := probe(ctx, host)
+				if err != nil {
+					continue
+				}
+				if s.household != "" {
+					if id, err := householdOf(location); err != nil || !s.wantHousehold(id) {
+						continue
+					}
+				}
+				select {
+				case found <- location:
+				default:
+				}
+				cancel()
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, host := range hosts {
+			select {
+			case jobs <- host:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	select {
+	case location := <-found:
+		return s.expandHousehold(location, foundFn)
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.New("no player found")
+}
+
+// probe checks whether a player answers on host.
+func probe(ctx context.Context, host string) (*url.URL, error) {
+	location, err := FromEndpoint(host)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", host, res.Status)
+	}
+	return location, nil
+}
+
+// expandHousehold reports every coordinator of the household of the player
+// at location.
+func (s *Sonos) expandHousehold(location *url.URL, foundFn FoundZonePlayer) error {
+	zp, err := NewZonePlayer(s.playerOptions(WithLocation(location))...)
+	if err != nil {
+		return err
+	}
+	zoneGroupState, err := zp.GetZoneGroupState()
+	if err != nil {
+		return err
+	}
+
+	for _, group := range zoneGroupState.ZoneGroups {
+		for _, member := range group.ZoneGroupMember {
+			if member.UUID != group.Coordinator {
+				continue
+			}
+			coordinator := zp
+			if member.UUID != zp.UUID() {
+				if coordinator, err = zp.peer(&member); err != nil {
+					continue
+				}
+			}
+			s.found(coordinator, foundFn)
+		}
+	}
+	return nil
+}
+
+// found registers a coordinator found by discovery and reports it unless it
+// was known already or belongs to another household.
+func (s *Sonos) found(zp *ZonePlayer, foundFn FoundZonePlayer) {
+	id, err := zp.HouseholdID()
+	if err != nil || !s.wantHousehold(id) {
+		return
+	}
+	if _, loaded := s.zonePlayers.LoadOrStore(zp.SerialNum(), zp); loaded {
+		return
+	}
+	s.remember(zp)
+	foundFn(s, zp)
+}
+
+// expandScanTarget returns the hosts of a CIDR range, or the target itself
+// if it is a single host.
+func expandScanTarget(target string) ([]string, error) {
+	ip, ipnet, err := net.ParseCIDR(target)
+	if err != nil {
+		return []string{target}, nil
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("%s: only IPv4 ranges can be scanned", target)
+	}
+
+	ones, bits := ipnet.Mask.Size()
+	size := 1 << uint(bits-ones)
+	if size > maxScanHosts {
+		return nil, fmt.Errorf("%s: range too large to scan", target)
+	}
+
+	start := binary.BigEndian.Uint32(ipnet.IP.To4())
+	first, last := 0, size
+	if size > 2 {
+		// Skip the network and broadcast addresses.
+		first, last = 1, size-1
+	}
+
+	hosts := make([]string, 0, last-first)
+	for i := first; i < last; i++ {
+		b := make(net.IP, 4)
+		binary.BigEndian.PutUint32(b, start+uint32(i))
+		hosts = append(hosts, b.String())
+	}
+	return hosts, nil
+}
+
+// localScanTargets returns the /24 networks of the local IPv4 addresses.
+func localScanTargets() ([]string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var targets []string
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.To4() == nil {
+			continue
+		}
+		ones, _ := ipnet.Mask.Size()
+		if ones < 24 {
+			ones = 24
+		}
+		network := &net.IPNet{IP: ipnet.IP.To4().Mask(net.CIDRMask(ones, 32)), Mask: net.CIDRMask(ones, 32)}
+		if !seen[network.String()] {
+			seen[network.String()] = true
+			targets = append(targets, network.String())
+		}
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no local network to scan")
+	}
+	return targets, nil
+}
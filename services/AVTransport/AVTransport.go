@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -38,8 +39,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	LastChange *LastChange
-
 	location *url.URL
 	client   *http.Client
 }
@@ -155,6 +154,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                            xml.Name                                    `xml:"Body"`
+	Fault                              *fault                                      `xml:"Fault,omitempty"`
 	SetAVTransportURI                  *SetAVTransportURIResponse                  `xml:"SetAVTransportURIResponse,omitempty"`
 	SetNextAVTransportURI              *SetNextAVTransportURIResponse              `xml:"SetNextAVTransportURIResponse,omitempty"`
 	AddURIToQueue                      *AddURIToQueueResponse                      `xml:"AddURIToQueueResponse,omitempty"`
@@ -224,9 +224,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("avtransport.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("avtransport.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type SetAVTransportURIArgs struct {
 	Xmlns              string `xml:"xmlns:u,attr"`
 	InstanceID         uint32 `xml:"InstanceID"`
@@ -1458,7 +1496,6 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.LastChange != nil:
-			zp.LastChange = prop.LastChange
 			events = append(events, *prop.LastChange)
 		}
 	}
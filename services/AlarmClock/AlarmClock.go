@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -44,14 +45,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	TimeZone              *TimeZone
-	TimeServer            *TimeServer
-	TimeGeneration        *TimeGeneration
-	AlarmListVersion      *AlarmListVersion
-	DailyIndexRefreshTime *DailyIndexRefreshTime
-	TimeFormat            *TimeFormat
-	DateFormat            *DateFormat
-
 	location *url.URL
 	client   *http.Client
 }
@@ -142,6 +135,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                  xml.Name                          `xml:"Body"`
+	Fault                    *fault                            `xml:"Fault,omitempty"`
 	SetFormat                *SetFormatResponse                `xml:"SetFormatResponse,omitempty"`
 	GetFormat                *GetFormatResponse                `xml:"GetFormatResponse,omitempty"`
 	SetTimeZone              *SetTimeZoneResponse              `xml:"SetTimeZoneResponse,omitempty"`
@@ -186,9 +180,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("alarmclock.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("alarmclock.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type SetFormatArgs struct {
 	Xmlns             string `xml:"xmlns:u,attr"`
 	DesiredTimeFormat string `xml:"DesiredTimeFormat"`
@@ -690,25 +722,18 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.TimeZone != nil:
-			zp.TimeZone = prop.TimeZone
 			events = append(events, *prop.TimeZone)
 		case prop.TimeServer != nil:
-			zp.TimeServer = prop.TimeServer
 			events = append(events, *prop.TimeServer)
 		case prop.TimeGeneration != nil:
-			zp.TimeGeneration = prop.TimeGeneration
 			events = append(events, *prop.TimeGeneration)
 		case prop.AlarmListVersion != nil:
-			zp.AlarmListVersion = prop.AlarmListVersion
 			events = append(events, *prop.AlarmListVersion)
 		case prop.DailyIndexRefreshTime != nil:
-			zp.DailyIndexRefreshTime = prop.DailyIndexRefreshTime
 			events = append(events, *prop.DailyIndexRefreshTime)
 		case prop.TimeFormat != nil:
-			zp.TimeFormat = prop.TimeFormat
 			events = append(events, *prop.TimeFormat)
 		case prop.DateFormat != nil:
-			zp.DateFormat = prop.DateFormat
 			events = append(events, *prop.DateFormat)
 		}
 	}
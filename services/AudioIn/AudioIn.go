@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -43,13 +44,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	AudioInputName   *AudioInputName
-	Icon             *Icon
-	LineInConnected  *LineInConnected
-	LeftLineInLevel  *LeftLineInLevel
-	RightLineInLevel *RightLineInLevel
-	Playing          *Playing
-
 	location *url.URL
 	client   *http.Client
 }
@@ -130,6 +124,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                  xml.Name                          `xml:"Body"`
+	Fault                    *fault                            `xml:"Fault,omitempty"`
 	StartTransmissionToGroup *StartTransmissionToGroupResponse `xml:"StartTransmissionToGroupResponse,omitempty"`
 	StopTransmissionToGroup  *StopTransmissionToGroupResponse  `xml:"StopTransmissionToGroupResponse,omitempty"`
 	SetAudioInputAttributes  *SetAudioInputAttributesResponse  `xml:"SetAudioInputAttributesResponse,omitempty"`
@@ -164,9 +159,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("audioin.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("audioin.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type StartTransmissionToGroupArgs struct {
 	Xmlns         string `xml:"xmlns:u,attr"`
 	CoordinatorID string `xml:"CoordinatorID"`
@@ -373,22 +406,16 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.AudioInputName != nil:
-			zp.AudioInputName = prop.AudioInputName
 			events = append(events, *prop.AudioInputName)
 		case prop.Icon != nil:
-			zp.Icon = prop.Icon
 			events = append(events, *prop.Icon)
 		case prop.LineInConnected != nil:
-			zp.LineInConnected = prop.LineInConnected
 			events = append(events, *prop.LineInConnected)
 		case prop.LeftLineInLevel != nil:
-			zp.LeftLineInLevel = prop.LeftLineInLevel
 			events = append(events, *prop.LeftLineInLevel)
 		case prop.RightLineInLevel != nil:
-			zp.RightLineInLevel = prop.RightLineInLevel
 			events = append(events, *prop.RightLineInLevel)
 		case prop.Playing != nil:
-			zp.Playing = prop.Playing
 			events = append(events, *prop.Playing)
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -40,10 +41,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	SourceProtocolInfo   *SourceProtocolInfo
-	SinkProtocolInfo     *SinkProtocolInfo
-	CurrentConnectionIDs *CurrentConnectionIDs
-
 	location *url.URL
 	client   *http.Client
 }
@@ -120,6 +117,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                  xml.Name                          `xml:"Body"`
+	Fault                    *fault                            `xml:"Fault,omitempty"`
 	GetProtocolInfo          *GetProtocolInfoResponse          `xml:"GetProtocolInfoResponse,omitempty"`
 	GetCurrentConnectionIDs  *GetCurrentConnectionIDsResponse  `xml:"GetCurrentConnectionIDsResponse,omitempty"`
 	GetCurrentConnectionInfo *GetCurrentConnectionInfoResponse `xml:"GetCurrentConnectionInfoResponse,omitempty"`
@@ -150,9 +148,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("connectionmanager.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("connectionmanager.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type GetProtocolInfoArgs struct {
 	Xmlns string `xml:"xmlns:u,attr"`
 }
@@ -259,13 +295,10 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.SourceProtocolInfo != nil:
-			zp.SourceProtocolInfo = prop.SourceProtocolInfo
 			events = append(events, *prop.SourceProtocolInfo)
 		case prop.SinkProtocolInfo != nil:
-			zp.SinkProtocolInfo = prop.SinkProtocolInfo
 			events = append(events, *prop.SinkProtocolInfo)
 		case prop.CurrentConnectionIDs != nil:
-			zp.CurrentConnectionIDs = prop.CurrentConnectionIDs
 			events = append(events, *prop.CurrentConnectionIDs)
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -50,20 +51,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	SystemUpdateID          *SystemUpdateID
-	ContainerUpdateIDs      *ContainerUpdateIDs
-	ShareIndexInProgress    *ShareIndexInProgress
-	ShareIndexLastError     *ShareIndexLastError
-	UserRadioUpdateID       *UserRadioUpdateID
-	SavedQueuesUpdateID     *SavedQueuesUpdateID
-	ShareListUpdateID       *ShareListUpdateID
-	RecentlyPlayedUpdateID  *RecentlyPlayedUpdateID
-	Browseable              *Browseable
-	RadioFavoritesUpdateID  *RadioFavoritesUpdateID
-	RadioLocationUpdateID   *RadioLocationUpdateID
-	FavoritesUpdateID       *FavoritesUpdateID
-	FavoritePresetsUpdateID *FavoritePresetsUpdateID
-
 	location *url.URL
 	client   *http.Client
 }
@@ -153,6 +140,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                     xml.Name                             `xml:"Body"`
+	Fault                       *fault                               `xml:"Fault,omitempty"`
 	GetSearchCapabilities       *GetSearchCapabilitiesResponse       `xml:"GetSearchCapabilitiesResponse,omitempty"`
 	GetSortCapabilities         *GetSortCapabilitiesResponse         `xml:"GetSortCapabilitiesResponse,omitempty"`
 	GetSystemUpdateID           *GetSystemUpdateIDResponse           `xml:"GetSystemUpdateIDResponse,omitempty"`
@@ -196,9 +184,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("contentdirectory.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("contentdirectory.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type GetSearchCapabilitiesArgs struct {
 	Xmlns string `xml:"xmlns:u,attr"`
 }
@@ -654,43 +680,30 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.SystemUpdateID != nil:
-			zp.SystemUpdateID = prop.SystemUpdateID
 			events = append(events, *prop.SystemUpdateID)
 		case prop.ContainerUpdateIDs != nil:
-			zp.ContainerUpdateIDs = prop.ContainerUpdateIDs
 			events = append(events, *prop.ContainerUpdateIDs)
 		case prop.ShareIndexInProgress != nil:
-			zp.ShareIndexInProgress = prop.ShareIndexInProgress
 			events = append(events, *prop.ShareIndexInProgress)
 		case prop.ShareIndexLastError != nil:
-			zp.ShareIndexLastError = prop.ShareIndexLastError
 			events = append(events, *prop.ShareIndexLastError)
 		case prop.UserRadioUpdateID != nil:
-			zp.UserRadioUpdateID = prop.UserRadioUpdateID
 			events = append(events, *prop.UserRadioUpdateID)
 		case prop.SavedQueuesUpdateID != nil:
-			zp.SavedQueuesUpdateID = prop.SavedQueuesUpdateID
 			events = append(events, *prop.SavedQueuesUpdateID)
 		case prop.ShareListUpdateID != nil:
-			zp.ShareListUpdateID = prop.ShareListUpdateID
 			events = append(events, *prop.ShareListUpdateID)
 		case prop.RecentlyPlayedUpdateID != nil:
-			zp.RecentlyPlayedUpdateID = prop.RecentlyPlayedUpdateID
 			events = append(events, *prop.RecentlyPlayedUpdateID)
 		case prop.Browseable != nil:
-			zp.Browseable = prop.Browseable
 			events = append(events, *prop.Browseable)
 		case prop.RadioFavoritesUpdateID != nil:
-			zp.RadioFavoritesUpdateID = prop.RadioFavoritesUpdateID
 			events = append(events, *prop.RadioFavoritesUpdateID)
 		case prop.RadioLocationUpdateID != nil:
-			zp.RadioLocationUpdateID = prop.RadioLocationUpdateID
 			events = append(events, *prop.RadioLocationUpdateID)
 		case prop.FavoritesUpdateID != nil:
-			zp.FavoritesUpdateID = prop.FavoritesUpdateID
 			events = append(events, *prop.FavoritesUpdateID)
 		case prop.FavoritePresetsUpdateID != nil:
-			zp.FavoritePresetsUpdateID = prop.FavoritePresetsUpdateID
 			events = append(events, *prop.FavoritePresetsUpdateID)
 		}
 	}
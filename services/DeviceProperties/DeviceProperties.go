@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -69,39 +70,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	SettingsReplicationState *SettingsReplicationState
-	ZoneName                 *ZoneName
-	Icon                     *Icon
-	Configuration            *Configuration
-	Invisible                *Invisible
-	IsZoneBridge             *IsZoneBridge
-	AirPlayEnabled           *AirPlayEnabled
-	SupportsAudioIn          *SupportsAudioIn
-	SupportsAudioClip        *SupportsAudioClip
-	IsIdle                   *IsIdle
-	MoreInfo                 *MoreInfo
-	ChannelMapSet            *ChannelMapSet
-	HTSatChanMapSet          *HTSatChanMapSet
-	HTFreq                   *HTFreq
-	HTBondedZoneCommitState  *HTBondedZoneCommitState
-	Orientation              *Orientation
-	LastChangedPlayState     *LastChangedPlayState
-	RoomCalibrationState     *RoomCalibrationState
-	AvailableRoomCalibration *AvailableRoomCalibration
-	TVConfigurationError     *TVConfigurationError
-	HdmiCecAvailable         *HdmiCecAvailable
-	WirelessMode             *WirelessMode
-	WirelessLeafOnly         *WirelessLeafOnly
-	HasConfiguredSSID        *HasConfiguredSSID
-	ChannelFreq              *ChannelFreq
-	BehindWifiExtender       *BehindWifiExtender
-	WifiEnabled              *WifiEnabled
-	EthLink                  *EthLink
-	ConfigMode               *ConfigMode
-	SecureRegState           *SecureRegState
-	VoiceConfigState         *VoiceConfigState
-	MicEnabled               *MicEnabled
-
 	location *url.URL
 	client   *http.Client
 }
@@ -203,6 +171,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                    xml.Name                            `xml:"Body"`
+	Fault                      *fault                              `xml:"Fault,omitempty"`
 	SetLEDState                *SetLEDStateResponse                `xml:"SetLEDStateResponse,omitempty"`
 	GetLEDState                *GetLEDStateResponse                `xml:"GetLEDStateResponse,omitempty"`
 	AddBondedZones             *AddBondedZonesResponse             `xml:"AddBondedZonesResponse,omitempty"`
@@ -258,9 +227,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("deviceproperties.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("deviceproperties.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type SetLEDStateArgs struct {
 	Xmlns string `xml:"xmlns:u,attr"`
 	// Allowed Value: On
@@ -1048,100 +1055,68 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.SettingsReplicationState != nil:
-			zp.SettingsReplicationState = prop.SettingsReplicationState
 			events = append(events, *prop.SettingsReplicationState)
 		case prop.ZoneName != nil:
-			zp.ZoneName = prop.ZoneName
 			events = append(events, *prop.ZoneName)
 		case prop.Icon != nil:
-			zp.Icon = prop.Icon
 			events = append(events, *prop.Icon)
 		case prop.Configuration != nil:
-			zp.Configuration = prop.Configuration
 			events = append(events, *prop.Configuration)
 		case prop.Invisible != nil:
-			zp.Invisible = prop.Invisible
 			events = append(events, *prop.Invisible)
 		case prop.IsZoneBridge != nil:
-			zp.IsZoneBridge = prop.IsZoneBridge
 			events = append(events, *prop.IsZoneBridge)
 		case prop.AirPlayEnabled != nil:
-			zp.AirPlayEnabled = prop.AirPlayEnabled
 			events = append(events, *prop.AirPlayEnabled)
 		case prop.SupportsAudioIn != nil:
-			zp.SupportsAudioIn = prop.SupportsAudioIn
 			events = append(events, *prop.SupportsAudioIn)
 		case prop.SupportsAudioClip != nil:
-			zp.SupportsAudioClip = prop.SupportsAudioClip
 			events = append(events, *prop.SupportsAudioClip)
 		case prop.IsIdle != nil:
-			zp.IsIdle = prop.IsIdle
 			events = append(events, *prop.IsIdle)
 		case prop.MoreInfo != nil:
-			zp.MoreInfo = prop.MoreInfo
 			events = append(events, *prop.MoreInfo)
 		case prop.ChannelMapSet != nil:
-			zp.ChannelMapSet = prop.ChannelMapSet
 			events = append(events, *prop.ChannelMapSet)
 		case prop.HTSatChanMapSet != nil:
-			zp.HTSatChanMapSet = prop.HTSatChanMapSet
 			events = append(events, *prop.HTSatChanMapSet)
 		case prop.HTFreq != nil:
-			zp.HTFreq = prop.HTFreq
 			events = append(events, *prop.HTFreq)
 		case prop.HTBondedZoneCommitState != nil:
-			zp.HTBondedZoneCommitState = prop.HTBondedZoneCommitState
 			events = append(events, *prop.HTBondedZoneCommitState)
 		case prop.Orientation != nil:
-			zp.Orientation = prop.Orientation
 			events = append(events, *prop.Orientation)
 		case prop.LastChangedPlayState != nil:
-			zp.LastChangedPlayState = prop.LastChangedPlayState
 			events = append(events, *prop.LastChangedPlayState)
 		case prop.RoomCalibrationState != nil:
-			zp.RoomCalibrationState = prop.RoomCalibrationState
 			events = append(events, *prop.RoomCalibrationState)
 		case prop.AvailableRoomCalibration != nil:
-			zp.AvailableRoomCalibration = prop.AvailableRoomCalibration
 			events = append(events, *prop.AvailableRoomCalibration)
 		case prop.TVConfigurationError != nil:
-			zp.TVConfigurationError = prop.TVConfigurationError
 			events = append(events, *prop.TVConfigurationError)
 		case prop.HdmiCecAvailable != nil:
-			zp.HdmiCecAvailable = prop.HdmiCecAvailable
 			events = append(events, *prop.HdmiCecAvailable)
 		case prop.WirelessMode != nil:
-			zp.WirelessMode = prop.WirelessMode
 			events = append(events, *prop.WirelessMode)
 		case prop.WirelessLeafOnly != nil:
-			zp.WirelessLeafOnly = prop.WirelessLeafOnly
 			events = append(events, *prop.WirelessLeafOnly)
 		case prop.HasConfiguredSSID != nil:
-			zp.HasConfiguredSSID = prop.HasConfiguredSSID
 			events = append(events, *prop.HasConfiguredSSID)
 		case prop.ChannelFreq != nil:
-			zp.ChannelFreq = prop.ChannelFreq
 			events = append(events, *prop.ChannelFreq)
 		case prop.BehindWifiExtender != nil:
-			zp.BehindWifiExtender = prop.BehindWifiExtender
 			events = append(events, *prop.BehindWifiExtender)
 		case prop.WifiEnabled != nil:
-			zp.WifiEnabled = prop.WifiEnabled
 			events = append(events, *prop.WifiEnabled)
 		case prop.EthLink != nil:
-			zp.EthLink = prop.EthLink
 			events = append(events, *prop.EthLink)
 		case prop.ConfigMode != nil:
-			zp.ConfigMode = prop.ConfigMode
 			events = append(events, *prop.ConfigMode)
 		case prop.SecureRegState != nil:
-			zp.SecureRegState = prop.SecureRegState
 			events = append(events, *prop.SecureRegState)
 		case prop.VoiceConfigState != nil:
-			zp.VoiceConfigState = prop.VoiceConfigState
 			events = append(events, *prop.VoiceConfigState)
 		case prop.MicEnabled != nil:
-			zp.MicEnabled = prop.MicEnabled
 			events = append(events, *prop.MicEnabled)
 		}
 	}
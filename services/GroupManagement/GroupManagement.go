@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -42,12 +43,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	GroupCoordinatorIsLocal *GroupCoordinatorIsLocal
-	LocalGroupUUID          *LocalGroupUUID
-	VirtualLineInGroupID    *VirtualLineInGroupID
-	ResetVolumeAfter        *ResetVolumeAfter
-	VolumeAVTransportURI    *VolumeAVTransportURI
-
 	location *url.URL
 	client   *http.Client
 }
@@ -125,6 +120,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                    xml.Name                            `xml:"Body"`
+	Fault                      *fault                              `xml:"Fault,omitempty"`
 	AddMember                  *AddMemberResponse                  `xml:"AddMemberResponse,omitempty"`
 	RemoveMember               *RemoveMemberResponse               `xml:"RemoveMemberResponse,omitempty"`
 	ReportTrackBufferingResult *ReportTrackBufferingResultResponse `xml:"ReportTrackBufferingResultResponse,omitempty"`
@@ -156,9 +152,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("groupmanagement.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("groupmanagement.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type AddMemberArgs struct {
 	Xmlns    string `xml:"xmlns:u,attr"`
 	MemberID string `xml:"MemberID"`
@@ -291,19 +325,14 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.GroupCoordinatorIsLocal != nil:
-			zp.GroupCoordinatorIsLocal = prop.GroupCoordinatorIsLocal
 			events = append(events, *prop.GroupCoordinatorIsLocal)
 		case prop.LocalGroupUUID != nil:
-			zp.LocalGroupUUID = prop.LocalGroupUUID
 			events = append(events, *prop.LocalGroupUUID)
 		case prop.VirtualLineInGroupID != nil:
-			zp.VirtualLineInGroupID = prop.VirtualLineInGroupID
 			events = append(events, *prop.VirtualLineInGroupID)
 		case prop.ResetVolumeAfter != nil:
-			zp.ResetVolumeAfter = prop.ResetVolumeAfter
 			events = append(events, *prop.ResetVolumeAfter)
 		case prop.VolumeAVTransportURI != nil:
-			zp.VolumeAVTransportURI = prop.VolumeAVTransportURI
 			events = append(events, *prop.VolumeAVTransportURI)
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -40,10 +41,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	GroupMute             *GroupMute
-	GroupVolume           *GroupVolume
-	GroupVolumeChangeable *GroupVolumeChangeable
-
 	location *url.URL
 	client   *http.Client
 }
@@ -123,6 +120,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                xml.Name                        `xml:"Body"`
+	Fault                  *fault                          `xml:"Fault,omitempty"`
 	GetGroupMute           *GetGroupMuteResponse           `xml:"GetGroupMuteResponse,omitempty"`
 	SetGroupMute           *SetGroupMuteResponse           `xml:"SetGroupMuteResponse,omitempty"`
 	GetGroupVolume         *GetGroupVolumeResponse         `xml:"GetGroupVolumeResponse,omitempty"`
@@ -156,9 +154,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("grouprenderingcontrol.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("grouprenderingcontrol.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type GetGroupMuteArgs struct {
 	Xmlns      string `xml:"xmlns:u,attr"`
 	InstanceID uint32 `xml:"InstanceID"`
@@ -339,13 +375,10 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.GroupMute != nil:
-			zp.GroupMute = prop.GroupMute
 			events = append(events, *prop.GroupMute)
 		case prop.GroupVolume != nil:
-			zp.GroupVolume = prop.GroupVolume
 			events = append(events, *prop.GroupVolume)
 		case prop.GroupVolumeChangeable != nil:
-			zp.GroupVolumeChangeable = prop.GroupVolumeChangeable
 			events = append(events, *prop.GroupVolumeChangeable)
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -42,12 +43,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	CustomerID     *CustomerID
-	UpdateID       *UpdateID
-	UpdateIDX      *UpdateIDX
-	VoiceUpdateID  *VoiceUpdateID
-	ThirdPartyHash *ThirdPartyHash
-
 	location *url.URL
 	client   *http.Client
 }
@@ -138,6 +133,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                            xml.Name                                    `xml:"Body"`
+	Fault                              *fault                                      `xml:"Fault,omitempty"`
 	SetString                          *SetStringResponse                          `xml:"SetStringResponse,omitempty"`
 	GetString                          *GetStringResponse                          `xml:"GetStringResponse,omitempty"`
 	Remove                             *RemoveResponse                             `xml:"RemoveResponse,omitempty"`
@@ -182,9 +178,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("systemproperties.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("systemproperties.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type SetStringArgs struct {
 	Xmlns        string `xml:"xmlns:u,attr"`
 	VariableName string `xml:"VariableName"`
@@ -667,19 +701,14 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.CustomerID != nil:
-			zp.CustomerID = prop.CustomerID
 			events = append(events, *prop.CustomerID)
 		case prop.UpdateID != nil:
-			zp.UpdateID = prop.UpdateID
 			events = append(events, *prop.UpdateID)
 		case prop.UpdateIDX != nil:
-			zp.UpdateIDX = prop.UpdateIDX
 			events = append(events, *prop.UpdateIDX)
 		case prop.VoiceUpdateID != nil:
-			zp.VoiceUpdateID = prop.VoiceUpdateID
 			events = append(events, *prop.VoiceUpdateID)
 		case prop.ThirdPartyHash != nil:
-			zp.ThirdPartyHash = prop.ThirdPartyHash
 			events = append(events, *prop.ThirdPartyHash)
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -38,8 +39,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	CurrentTrackMetaData *CurrentTrackMetaData
-
 	location *url.URL
 	client   *http.Client
 }
@@ -121,6 +120,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName           xml.Name                   `xml:"Body"`
+	Fault             *fault                     `xml:"Fault,omitempty"`
 	StartTransmission *StartTransmissionResponse `xml:"StartTransmissionResponse,omitempty"`
 	StopTransmission  *StopTransmissionResponse  `xml:"StopTransmissionResponse,omitempty"`
 	Play              *PlayResponse              `xml:"PlayResponse,omitempty"`
@@ -156,9 +156,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("virtuallinein.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("virtuallinein.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type StartTransmissionArgs struct {
 	Xmlns         string `xml:"xmlns:u,attr"`
 	InstanceID    uint32 `xml:"InstanceID"`
@@ -385,7 +423,6 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.CurrentTrackMetaData != nil:
-			zp.CurrentTrackMetaData = prop.CurrentTrackMetaData
 			events = append(events, *prop.CurrentTrackMetaData)
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -48,18 +49,6 @@ type Service struct {
 	controlEndpoint *url.URL
 	eventEndpoint   *url.URL
 
-	AvailableSoftwareUpdate *AvailableSoftwareUpdate
-	ZoneGroupState          *ZoneGroupState
-	ThirdPartyMediaServersX *ThirdPartyMediaServersX
-	AlarmRunSequence        *AlarmRunSequence
-	MuseHouseholdId         *MuseHouseholdId
-	ZoneGroupName           *ZoneGroupName
-	ZoneGroupID             *ZoneGroupID
-	ZonePlayerUUIDsInGroup  *ZonePlayerUUIDsInGroup
-	AreasUpdateID           *AreasUpdateID
-	SourceAreasUpdateID     *SourceAreasUpdateID
-	NetsettingsUpdateID     *NetsettingsUpdateID
-
 	location *url.URL
 	client   *http.Client
 }
@@ -141,6 +130,7 @@ type envelopeResponse struct {
 // internal use only
 type bodyResponse struct {
 	XMLName                   xml.Name                           `xml:"Body"`
+	Fault                     *fault                             `xml:"Fault,omitempty"`
 	CheckForUpdate            *CheckForUpdateResponse            `xml:"CheckForUpdateResponse,omitempty"`
 	BeginSoftwareUpdate       *BeginSoftwareUpdateResponse       `xml:"BeginSoftwareUpdateResponse,omitempty"`
 	ReportUnresponsiveDevice  *ReportUnresponsiveDeviceResponse  `xml:"ReportUnresponsiveDeviceResponse,omitempty"`
@@ -176,9 +166,47 @@ func (s *Service) exec(actionName string, envelope *envelope) (*envelopeResponse
 	if err != nil {
 		return nil, err
 	}
+	if f := envelopeResponse.Body.Fault; f != nil {
+		return nil, &UPnPError{
+			Action:      actionName,
+			Code:        f.Detail.UPnPError.ErrorCode,
+			Description: f.Detail.UPnPError.ErrorDescription,
+		}
+	}
 	return &envelopeResponse, nil
 }
 
+// internal use only
+type fault struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	Detail      struct {
+		UPnPError struct {
+			ErrorCode        int    `xml:"errorCode"`
+			ErrorDescription string `xml:"errorDescription"`
+		} `xml:"UPnPError"`
+	} `xml:"detail"`
+}
+
+// UPnPError is the fault returned by the player when an action fails.
+type UPnPError struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *UPnPError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("zonegrouptopology.%s: UPnP error %d: %s", e.Action, e.Code, e.Description)
+	}
+	return fmt.Sprintf("zonegrouptopology.%s: UPnP error %d", e.Action, e.Code)
+}
+
+// UPnPErrorCode returns the UPnP error code of the fault.
+func (e *UPnPError) UPnPErrorCode() int {
+	return e.Code
+}
+
 type CheckForUpdateArgs struct {
 	Xmlns string `xml:"xmlns:u,attr"`
 	// Allowed Value: All
@@ -427,37 +455,26 @@ func (zp *Service) ParseEvent(body []byte) []interface{} {
 		_ = prop
 		switch {
 		case prop.AvailableSoftwareUpdate != nil:
-			zp.AvailableSoftwareUpdate = prop.AvailableSoftwareUpdate
 			events = append(events, *prop.AvailableSoftwareUpdate)
 		case prop.ZoneGroupState != nil:
-			zp.ZoneGroupState = prop.ZoneGroupState
 			events = append(events, *prop.ZoneGroupState)
 		case prop.ThirdPartyMediaServersX != nil:
-			zp.ThirdPartyMediaServersX = prop.ThirdPartyMediaServersX
 			events = append(events, *prop.ThirdPartyMediaServersX)
 		case prop.AlarmRunSequence != nil:
-			zp.AlarmRunSequence = prop.AlarmRunSequence
 			events = append(events, *prop.AlarmRunSequence)
 		case prop.MuseHouseholdId != nil:
-			zp.MuseHouseholdId = prop.MuseHouseholdId
 			events = append(events, *prop.MuseHouseholdId)
 		case prop.ZoneGroupName != nil:
-			zp.ZoneGroupName = prop.ZoneGroupName
 			events = append(events, *prop.ZoneGroupName)
 		case prop.ZoneGroupID != nil:
-			zp.ZoneGroupID = prop.ZoneGroupID
 			events = append(events, *prop.ZoneGroupID)
 		case prop.ZonePlayerUUIDsInGroup != nil:
-			zp.ZonePlayerUUIDsInGroup = prop.ZonePlayerUUIDsInGroup
 			events = append(events, *prop.ZonePlayerUUIDsInGroup)
 		case prop.AreasUpdateID != nil:
-			zp.AreasUpdateID = prop.AreasUpdateID
 			events = append(events, *prop.AreasUpdateID)
 		case prop.SourceAreasUpdateID != nil:
-			zp.SourceAreasUpdateID = prop.SourceAreasUpdateID
 			events = append(events, *prop.SourceAreasUpdateID)
 		case prop.NetsettingsUpdateID != nil:
-			zp.NetsettingsUpdateID = prop.NetsettingsUpdateID
 			events = append(events, *prop.NetsettingsUpdateID)
 		}
 	}
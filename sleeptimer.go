@@ -0,0 +1,134 @@
+package sonos
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+)
+
+// SleepTimer is the live sleep timer of a player. The remaining duration is
+// counted down locally and refreshed from the player whenever an AVTransport
+// LastChange event reports a new SleepTimerGeneration.
+type SleepTimer struct {
+	zp *ZonePlayer
+
+	mu         sync.Mutex
+	remaining  time.Duration
+	fetched    time.Time
+	generation uint32
+	valid      bool
+}
+
+// GetSleepTimer returns the live sleep timer of the player.
+func (z *ZonePlayer) GetSleepTimer() *SleepTimer {
+	z.sleepTimerOnce.Do(func() {
+		z.sleepTimer = &SleepTimer{zp: z}
+	})
+	return z.sleepTimer
+}
+
+// SetSleepTimer stops playback after d.
+func (z *ZonePlayer) SetSleepTimer(d time.Duration) error {
+	_, err := z.AVTransport().ConfigureSleepTimer(&avt.ConfigureSleepTimerArgs{
+		NewSleepTimerDuration: FormatDuration(d),
+	})
+	if err != nil {
+		return err
+	}
+	return z.GetSleepTimer().Refresh()
+}
+
+// CancelSleepTimer turns the sleep timer off.
+func (z *ZonePlayer) CancelSleepTimer() error {
+	_, err := z.AVTransport().ConfigureSleepTimer(&avt.ConfigureSleepTimerArgs{})
+	if err != nil {
+		return err
+	}
+	return z.GetSleepTimer().Refresh()
+}
+
+// SleepTimer returns the time left until playback stops, or zero when no
+// sleep timer is set.
+func (z *ZonePlayer) SleepTimer() (time.Duration, error) {
+	t := z.GetSleepTimer()
+	if err := t.Refresh(); err != nil {
+		return 0, err
+	}
+	return t.Remaining(), nil
+}
+
+// Refresh fetches the remaining duration from the player.
+func (t *SleepTimer) Refresh() error {
+	res, err := t.zp.AVTransport().GetRemainingSleepTimerDuration(&avt.GetRemainingSleepTimerDurationArgs{})
+	if err != nil {
+		return err
+	}
+	remaining, err := ParseDuration(res.RemainingSleepTimerDuration)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.remaining = remaining
+	t.fetched = time.Now()
+	t.generation = res.CurrentSleepTimerGeneration
+	t.valid = true
+	t.mu.Unlock()
+
+	return nil
+}
+
+// Remaining returns the time left until playback stops, counted down from
+// the last value fetched from the player. It returns zero when no sleep
+// timer is set or the timer has not been fetched yet.
+func (t *SleepTimer) Remaining() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.valid || t.remaining == 0 {
+		return 0
+	}
+	left := t.remaining - time.Since(t.fetched)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// Active reports whether a sleep timer is running.
+func (t *SleepTimer) Active() bool {
+	return t.Remaining() > 0
+}
+
+// Generation returns the generation counter of the timer; it changes every
+// time the timer is set or cancelled.
+func (t *SleepTimer) Generation() uint32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.generation
+}
+
+// handleGeneration refreshes the timer when an event reports a generation
+// other than the one last fetched.
+func (t *SleepTimer) handleGeneration(value string) {
+	if value == "" {
+		return
+	}
+	generation, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	changed := !t.valid || uint32(generation) != t.generation
+	t.mu.Unlock()
+
+	if changed {
+		// Events are delivered from the HTTP handler; don't block it on a
+		// round trip to the player.
+		go t.Refresh()
+	}
+}
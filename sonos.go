@@ -9,7 +9,9 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 type Sonos struct {
@@ -17,26 +19,94 @@ type Sonos struct {
 	tcpListener net.Listener
 
 	zonePlayers sync.Map
+
+	// subscriptions maps SIDs to their subscription. subscribing counts the
+	// Subscribe calls in flight, whose initial event may arrive before
+	// their SID is known.
+	subscriptions sync.Map
+	subscribing   int32
+
+	cache *HouseholdCache
+
+	// household filters discovery, see WithHousehold.
+	household string
+
+	discovery       Discovery
+	scanTargets     []string
+	scanConcurrency int
+
+	listenAddress string
+	callbackURL   *url.URL
+	mux           *http.ServeMux
+	muxPattern    string
+
+	metrics      *Metrics
+	errorHandler func(error)
+	// peers is shared by the players created by discovery.
+	peers *peers
 }
 
 type FoundZonePlayer func(*Sonos, *ZonePlayer)
 
-func NewSonos() (*Sonos, error) {
+type SonosOption func(*Sonos)
+
+// WithHouseholdCache makes Search report the players remembered in c right
+// away. They are verified in the background and evicted if they no longer
+// respond. Players found by discovery are added to c.
+func WithHouseholdCache(c *HouseholdCache) SonosOption {
+	return func(s *Sonos) {
+		s.cache = c
+	}
+}
+
+// WithErrorHandler is called with the events the players found by
+// discovery fail to decode.
+func WithErrorHandler(h func(error)) SonosOption {
+	return func(s *Sonos) {
+		s.errorHandler = h
+	}
+}
+
+func NewSonos(opts ...SonosOption) (*Sonos, error) {
 	// Create listener for M-SEARCH
 	udpListener, err := net.ListenUDP("udp", &net.UDPAddr{IP: []byte{0, 0, 0, 0}, Port: 0, Zone: ""})
 	if err != nil {
 		return nil, err
 	}
 
-	// create listener for events
-	tcpListener, err := net.Listen("tcp", ":0")
-	if err != nil {
-		return nil, err
+	s := &Sonos{
+		udpListener:   udpListener,
+		discovery:     DiscoverSSDP,
+		listenAddress: ":0",
+		peers:         newPeers(),
 	}
 
-	s := &Sonos{
-		udpListener: udpListener,
-		tcpListener: tcpListener,
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.mux != nil {
+		if s.callbackURL == nil {
+			udpListener.Close()
+			return nil, errors.New("WithServeMux requires WithCallbackURL")
+		}
+		if s.callbackURL.Path == "" {
+			u := *s.callbackURL
+			u.Path = s.muxPattern
+			s.callbackURL = &u
+		}
+		s.mux.Handle(s.muxPattern, s)
+		return s, nil
+	}
+
+	// create listener for events
+	if s.tcpListener == nil {
+		tcpListener, err := net.Listen("tcp", s.listenAddress)
+		if err != nil {
+			udpListener.Close()
+			return nil, err
+		}
+		s.tcpListener = tcpListener
 	}
 
 	go func() {
@@ -48,10 +118,23 @@ func NewSonos() (*Sonos, error) {
 
 func (s *Sonos) Close() {
 	s.udpListener.Close()
-	s.tcpListener.Close()
+	if s.tcpListener != nil {
+		s.tcpListener.Close()
+	}
 }
 
 func (s *Sonos) Search(ctx context.Context, foundFn FoundZonePlayer) error {
+	if s.cache != nil {
+		s.searchCache(foundFn)
+	}
+
+	if s.discovery&DiscoverScan != 0 {
+		go s.Scan(ctx, foundFn, s.scanTargets...)
+	}
+	if s.discovery&DiscoverSSDP == 0 {
+		return nil
+	}
+
 	go func(ctx context.Context) {
 		for {
 			if ctx.Err() != nil {
@@ -62,19 +145,24 @@ func (s *Sonos) Search(ctx context.Context, foundFn FoundZonePlayer) error {
 				continue
 			}
 
+			household := response.Header.Get("X-RINCON-HOUSEHOLD")
+			if household != "" && !s.wantHousehold(household) {
+				continue
+			}
+
 			location, err := url.Parse(response.Header.Get("Location"))
 			if err != nil {
 				continue
 			}
-			zp, err := NewZonePlayer(WithLocation(location))
+			zp, err := NewZonePlayer(s.playerOptions(WithLocation(location))...)
 			if err != nil {
 				continue
 			}
+			if household != "" {
+				zp.setHouseholdID(household)
+			}
 			if zp.IsCoordinator() {
-				zp, loaded := s.zonePlayers.LoadOrStore(zp.SerialNum(), zp)
-				if !loaded {
-					foundFn(s, zp.(*ZonePlayer))
-				}
+				s.found(zp, foundFn)
 			}
 		}
 	}(ctx)
@@ -95,6 +183,18 @@ func (s *Sonos) Search(ctx context.Context, foundFn FoundZonePlayer) error {
 	return nil
 }
 
+// playerOptions are the options of the players created by discovery.
+func (s *Sonos) playerOptions(opts ...ZonePlayerOption) []ZonePlayerOption {
+	opts = append(opts, withPeers(s.peers))
+	if s.metrics != nil {
+		opts = append(opts, WithClient(s.metrics.client))
+	}
+	if s.errorHandler != nil {
+		opts = append(opts, WithEventErrorHandler(s.errorHandler))
+	}
+	return opts
+}
+
 func (s *Sonos) Register(zp *ZonePlayer) error {
 	if zp.IsCoordinator() {
 		_, loaded := s.zonePlayers.LoadOrStore(zp.SerialNum(), zp)
@@ -107,20 +207,10 @@ func (s *Sonos) Register(zp *ZonePlayer) error {
 }
 
 func (s *Sonos) Subscribe(ctx context.Context, zp *ZonePlayer, service SonosService) (string, error) {
-	conn, err := net.Dial("tcp", service.EventEndpoint().Host)
+	calbackUrl, err := s.callback(zp, service)
 	if err != nil {
 		return "", err
 	}
-	defer conn.Close()
-
-	host := fmt.Sprintf("%s:%d", conn.LocalAddr().(*net.TCPAddr).IP.String(), s.tcpListener.Addr().(*net.TCPAddr).Port)
-
-	calbackUrl := url.URL{
-		Scheme:   "http",
-		Host:     host,
-		RawQuery: "sn=" + zp.SerialNum(),
-		Path:     service.EventEndpoint().Path,
-	}
 
 	req, err := http.NewRequestWithContext(ctx, "SUBSCRIBE", service.EventEndpoint().String(), nil)
 	if err != nil {
@@ -132,6 +222,9 @@ func (s *Sonos) Subscribe(ctx context.Context, zp *ZonePlayer, service SonosServ
 	req.Header.Add("NT", "upnp:event")
 	req.Header.Add("TIMEOUT", "Second-300")
 
+	atomic.AddInt32(&s.subscribing, 1)
+	defer atomic.AddInt32(&s.subscribing, -1)
+
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", err
@@ -149,10 +242,22 @@ func (s *Sonos) Subscribe(ctx context.Context, zp *ZonePlayer, service SonosServ
 		return "", errors.New(string(body))
 	}
 
-	return res.Header.Get("sid"), nil
+	sid := res.Header.Get("sid")
+	s.addSubscription(sid, zp, service)
+	return sid, nil
 }
 
 func (s *Sonos) Renew(ctx context.Context, zp *ZonePlayer, service SonosService, sid string) error {
+	err := s.renew(ctx, service, sid)
+	if err != nil {
+		// The player forgets the SID once it expires; subscribe again.
+		s.removeSubscription(sid)
+		s.metrics.renewFailed(service)
+	}
+	return err
+}
+
+func (s *Sonos) renew(ctx context.Context, service SonosService, sid string) error {
 	req, err := http.NewRequestWithContext(ctx, "SUBSCRIBE", service.EventEndpoint().String(), nil)
 	if err != nil {
 		return err
@@ -207,95 +312,52 @@ func (s *Sonos) Unsubscribe(ctx context.Context, zp *ZonePlayer, service SonosSe
 		return errors.New(string(body))
 	}
 
+	s.removeSubscription(sid)
 	return nil
 }
 
 func (s *Sonos) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	defer request.Body.Close()
 
-	query := request.URL.Query()
-	sn, ok := query["sn"]
+	sub, ok := s.awaitSubscription(request.Context(), request.Header.Get("SID"))
 	if !ok {
-		response.WriteHeader(http.StatusNotFound)
+		// Tells the player to drop the subscription.
+		response.WriteHeader(http.StatusPreconditionFailed)
 		return
 	}
 
-	p, ok := s.zonePlayers.Load(sn[0])
-	if !ok {
-		response.WriteHeader(http.StatusNotFound)
+	zonePlayer := sub.zp
+	path := strings.TrimPrefix(request.URL.Path, s.callbackPrefix())
+	if path != sub.service.EventEndpoint().Path || request.URL.Query().Get("sn") != zonePlayer.SerialNum() {
+		response.WriteHeader(http.StatusPreconditionFailed)
 		return
 	}
-	zonePlayer := p.(*ZonePlayer)
+
+	seq, err := parseSEQ(request.Header.Get("SEQ"))
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
 	data, err := ioutil.ReadAll(request.Body)
 	if err != nil {
 		response.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	var events []interface{}
-
-	if request.URL.Path == zonePlayer.AlarmClock.EventEndpoint().Path {
-		events = zonePlayer.AlarmClock.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.AVTransport.EventEndpoint().Path {
-		events = zonePlayer.AVTransport.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.ConnectionManager.EventEndpoint().Path {
-		events = zonePlayer.ConnectionManager.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.ContentDirectory.EventEndpoint().Path {
-		events = zonePlayer.ContentDirectory.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.DeviceProperties.EventEndpoint().Path {
-		events = zonePlayer.DeviceProperties.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.GroupManagement.EventEndpoint().Path {
-		events = zonePlayer.GroupManagement.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.GroupRenderingControl.EventEndpoint().Path {
-		events = zonePlayer.GroupRenderingControl.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.MusicServices.EventEndpoint().Path {
-		events = zonePlayer.MusicServices.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.Queue.EventEndpoint().Path {
-		events = zonePlayer.Queue.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.RenderingControl.EventEndpoint().Path {
-		events = zonePlayer.RenderingControl.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.SystemProperties.EventEndpoint().Path {
-		events = zonePlayer.SystemProperties.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.VirtualLineIn.EventEndpoint().Path {
-		events = zonePlayer.VirtualLineIn.ParseEvent(data)
-	}
-	if request.URL.Path == zonePlayer.ZoneGroupTopology.EventEndpoint().Path {
-		events = zonePlayer.ZoneGroupTopology.ParseEvent(data)
+	order := sub.check(seq)
+	if order == seqStale {
+		response.WriteHeader(http.StatusOK)
+		return
 	}
 
-	for _, evt := range events {
+	s.metrics.event(sub.service)
+	for _, evt := range sub.service.ParseEvent(data) {
 		zonePlayer.Event(evt)
 	}
 	response.WriteHeader(http.StatusOK)
-}
-
-func (s *Sonos) FindRoom(ctx context.Context, room string) (*ZonePlayer, error) {
-	c := make(chan *ZonePlayer)
-	defer close(c)
 
-	s.Search(ctx, func(s *Sonos, zp *ZonePlayer) {
-		if zp.RoomName() == room {
-			c <- zp
-		}
-	})
-
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, errors.New("timeout")
-		case zp := <-c:
-			return zp, nil
-		}
+	if order == seqGap {
+		go zonePlayer.Resync(sub.service)
 	}
 }
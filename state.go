@@ -0,0 +1,322 @@
+package sonos
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+	dev "github.com/caglar10ur/sonos/services/DeviceProperties"
+	rcg "github.com/caglar10ur/sonos/services/GroupRenderingControl"
+	ren "github.com/caglar10ur/sonos/services/RenderingControl"
+)
+
+// PlayerState is a snapshot of the evented state of a player.
+type PlayerState struct {
+	// Transport
+	TransportState string
+	PlayMode       PlayMode
+	CurrentTrack   int
+	NumberOfTracks int
+	TrackURI       string
+	TrackDuration  time.Duration
+	Track          MediaObject
+
+	// QueueUpdateID changes whenever the queue is modified.
+	QueueUpdateID uint32
+
+	// Rendering
+	Volume   int
+	Mute     bool
+	Bass     int
+	Treble   int
+	Loudness bool
+
+	// Group rendering, only maintained on group coordinators.
+	GroupVolume int
+	GroupMute   bool
+
+	// Topology is the last zone group topology seen. It is replaced, never
+	// modified, and must not be modified by readers either.
+	Topology *ZoneGroupState
+
+	// Device properties
+	RoomName string
+	Icon     string
+	// ConfigMode is set while a controller is setting the player up.
+	ConfigMode string
+	// SupportsAudioIn is nil until a DeviceProperties event reports it.
+	SupportsAudioIn *bool
+	LineInConnected bool
+	// LineInKnown is false until the first AudioIn event; the service has
+	// no action to read LineInConnected.
+	LineInKnown bool
+}
+
+// StateHandler is called with the state before and after a change. It is
+// called from the event handler and must not block.
+type StateHandler func(zp *ZonePlayer, old, updated PlayerState)
+
+// State is the live state of a player, kept up to date from the events of
+// the services subscribed to with Sonos.Subscribe. It is safe for concurrent
+// use.
+type State struct {
+	zp *ZonePlayer
+
+	mu       sync.RWMutex
+	state    PlayerState
+	handlers []StateHandler
+}
+
+// WithInitialState populates the state of the player with Get calls when
+// it is created, rather than waiting for the first events.
+func WithInitialState() ZonePlayerOption {
+	return func(z *ZonePlayer) {
+		z.populateState = true
+	}
+}
+
+// GetState returns the live state of the player.
+func (z *ZonePlayer) GetState() *State {
+	z.stateOnce.Do(func() {
+		z.state = &State{zp: z}
+	})
+	return z.state
+}
+
+// Snapshot returns a consistent copy of the current state.
+func (s *State) Snapshot() PlayerState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state
+}
+
+// OnChange registers h to be called whenever the state changes.
+func (s *State) OnChange(h StateHandler) {
+	s.mu.Lock()
+	s.handlers = append(s.handlers, h)
+	s.mu.Unlock()
+}
+
+// update applies fn to the state and notifies the handlers if it changed.
+func (s *State) update(fn func(*PlayerState)) {
+	s.mu.Lock()
+	old := s.state
+	fn(&s.state)
+	updated := s.state
+	handlers := append([]StateHandler(nil), s.handlers...)
+	s.mu.Unlock()
+
+	if reflect.DeepEqual(old, updated) {
+		return
+	}
+	for _, h := range handlers {
+		h(s.zp, old, updated)
+	}
+}
+
+// Populate fetches the whole state with Get calls.
+func (s *State) Populate() error {
+	for _, populate := range []func() error{
+		s.populateTransport,
+		s.populateQueue,
+		s.populateRendering,
+		s.populateTopology,
+		s.populateDevice,
+	} {
+		if err := populate(); err != nil {
+			return err
+		}
+	}
+	if s.zp.IsCoordinator() {
+		return s.populateGroupRendering()
+	}
+	return nil
+}
+
+func (s *State) populateTransport() error {
+	info, err := s.zp.AVTransport().GetTransportInfo(&avt.GetTransportInfoArgs{})
+	if err != nil {
+		return err
+	}
+	settings, err := s.zp.AVTransport().GetTransportSettings(&avt.GetTransportSettingsArgs{})
+	if err != nil {
+		return err
+	}
+	media, err := s.zp.AVTransport().GetMediaInfo(&avt.GetMediaInfoArgs{})
+	if err != nil {
+		return err
+	}
+	position, err := s.zp.AVTransport().GetPositionInfo(&avt.GetPositionInfoArgs{})
+	if err != nil {
+		return err
+	}
+	duration, _ := ParseDuration(position.TrackDuration)
+
+	s.update(func(state *PlayerState) {
+		state.TransportState = info.CurrentTransportState
+		state.PlayMode = PlayMode(settings.PlayMode)
+		state.NumberOfTracks = int(media.NrTracks)
+		state.CurrentTrack = int(position.Track)
+		state.TrackURI = position.TrackURI
+		state.TrackDuration = duration
+		state.Track = trackMetadata(position.TrackMetaData)
+	})
+	return nil
+}
+
+func (s *State) populateQueue() error {
+	return s.zp.GetQueue().sync()
+}
+
+func (s *State) populateRendering() error {
+	volume, err := s.zp.GetVolume()
+	if err != nil {
+		return err
+	}
+	mute, err := s.zp.GetMute()
+	if err != nil {
+		return err
+	}
+	bass, err := s.zp.RenderingControl().GetBass(&ren.GetBassArgs{})
+	if err != nil {
+		return err
+	}
+	treble, err := s.zp.RenderingControl().GetTreble(&ren.GetTrebleArgs{})
+	if err != nil {
+		return err
+	}
+	loudness, err := s.zp.RenderingControl().GetLoudness(&ren.GetLoudnessArgs{Channel: "Master"})
+	if err != nil {
+		return err
+	}
+
+	s.update(func(state *PlayerState) {
+		state.Volume = volume
+		state.Mute = mute
+		state.Bass = int(bass.CurrentBass)
+		state.Treble = int(treble.CurrentTreble)
+		state.Loudness = loudness.CurrentLoudness
+	})
+	return nil
+}
+
+func (s *State) populateGroupRendering() error {
+	volume, err := s.zp.GroupRenderingControl().GetGroupVolume(&rcg.GetGroupVolumeArgs{})
+	if err != nil {
+		return err
+	}
+	mute, err := s.zp.GroupRenderingControl().GetGroupMute(&rcg.GetGroupMuteArgs{})
+	if err != nil {
+		return err
+	}
+
+	s.update(func(state *PlayerState) {
+		state.GroupVolume = int(volume.CurrentVolume)
+		state.GroupMute = mute.CurrentMute
+	})
+	return nil
+}
+
+func (s *State) populateTopology() error {
+	zoneGroupState, err := s.zp.GetZoneGroupState()
+	if err != nil {
+		return err
+	}
+	s.update(func(state *PlayerState) {
+		state.Topology = zoneGroupState
+	})
+	return nil
+}
+
+func (s *State) populateDevice() error {
+	res, err := s.zp.DeviceProperties().GetZoneAttributes(&dev.GetZoneAttributesArgs{})
+	if err != nil {
+		return err
+	}
+	s.update(func(state *PlayerState) {
+		state.RoomName = res.CurrentZoneName
+		state.Icon = res.CurrentIcon
+	})
+	return nil
+}
+
+// handleAVTransport applies an AVTransport LastChange event. Only the
+// variables present in the event are changed.
+func (s *State) handleAVTransport(e *AVTransportLastChange) {
+	i := &e.InstanceID
+	s.update(func(state *PlayerState) {
+		if v := i.TransportState.Value; v != "" {
+			state.TransportState = v
+		}
+		if v := i.CurrentPlayMode.Value; v != "" {
+			state.PlayMode = PlayMode(v)
+		}
+		if v, err := strconv.Atoi(i.NumberOfTracks.Value); err == nil {
+			state.NumberOfTracks = v
+		}
+		if v, err := strconv.Atoi(i.CurrentTrack.Value); err == nil {
+			state.CurrentTrack = v
+		}
+		if v := i.CurrentTrackURI.Value; v != "" {
+			state.TrackURI = v
+		}
+		if v, err := ParseDuration(i.CurrentTrackDuration.Value); err == nil && i.CurrentTrackDuration.Value != "" {
+			state.TrackDuration = v
+		}
+		if v := i.CurrentTrackMetaData.Value; v != "" {
+			state.Track = trackMetadata(v)
+		}
+	})
+}
+
+// handleRenderingControl applies a RenderingControl LastChange event.
+func (s *State) handleRenderingControl(e *RenderingControlLastChange) {
+	i := &e.InstanceID
+	s.update(func(state *PlayerState) {
+		for _, v := range i.Volume {
+			if n, err := strconv.Atoi(v.Value); err == nil && v.Channel == "Master" {
+				state.Volume = n
+			}
+		}
+		for _, v := range i.Mute {
+			if v.Channel == "Master" && v.Value != "" {
+				state.Mute = v.Value == "1"
+			}
+		}
+		if n, err := strconv.Atoi(i.Bass.Value); err == nil {
+			state.Bass = n
+		}
+		if n, err := strconv.Atoi(i.Treble.Value); err == nil {
+			state.Treble = n
+		}
+		for _, v := range i.Loudness {
+			if v.Channel == "Master" && v.Value != "" {
+				state.Loudness = v.Value == "1"
+			}
+		}
+	})
+}
+
+func (s *State) handleTopology(raw string) *ZoneGroupState {
+	var zoneGroupState ZoneGroupState
+	if err := xml.Unmarshal([]byte(raw), &zoneGroupState); err != nil {
+		return nil
+	}
+	s.update(func(state *PlayerState) {
+		state.Topology = &zoneGroupState
+	})
+	return &zoneGroupState
+}
+
+// trackMetadata parses the DIDL-Lite metadata of the current track.
+func trackMetadata(raw string) MediaObject {
+	metadata, err := ParseDIDL(raw)
+	if err != nil || len(metadata.Item) == 0 {
+		return MediaObject{}
+	}
+	return NewMediaObject(BrowseObject{Item: &metadata.Item[0]})
+}
@@ -0,0 +1,153 @@
+package sonos
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	avt "github.com/caglar10ur/sonos/services/AVTransport"
+	ren "github.com/caglar10ur/sonos/services/RenderingControl"
+)
+
+func avtChange(inner string) avt.LastChange {
+	return avt.LastChange(`<Event xmlns="urn:schemas-upnp-org:metadata-1-0/AVT/"><InstanceID val="0">` +
+		inner + `</InstanceID></Event>`)
+}
+
+func renChange(inner string) ren.LastChange {
+	return ren.LastChange(`<Event xmlns="urn:schemas-upnp-org:metadata-1-0/RCS/"><InstanceID val="0">` +
+		inner + `</InstanceID></Event>`)
+}
+
+func TestStateEvents(t *testing.T) {
+	playing := avtChange(`<TransportState val="PLAYING"/><CurrentPlayMode val="SHUFFLE"/>` +
+		`<NumberOfTracks val="10"/><CurrentTrack val="3"/><CurrentTrackURI val="x-file-cifs://nas/3.flac"/>` +
+		`<CurrentTrackDuration val="0:03:20"/>`)
+	playingState := PlayerState{
+		TransportState: "PLAYING",
+		PlayMode:       PlayModeShuffle,
+		NumberOfTracks: 10,
+		CurrentTrack:   3,
+		TrackURI:       "x-file-cifs://nas/3.flac",
+		TrackDuration:  3*time.Minute + 20*time.Second,
+	}
+	rendering := renChange(`<Volume channel="Master" val="20"/><Volume channel="LF" val="100"/>` +
+		`<Mute channel="Master" val="0"/><Bass val="2"/><Treble val="-1"/><Loudness channel="Master" val="1"/>`)
+	renderingState := PlayerState{Volume: 20, Bass: 2, Treble: -1, Loudness: true}
+
+	tests := []struct {
+		name   string
+		events []interface{}
+		want   PlayerState
+		// notified is the number of changes the handlers are called with.
+		notified int
+	}{
+		{
+			name:     "full transport state",
+			events:   []interface{}{playing},
+			want:     playingState,
+			notified: 1,
+		},
+		{
+			name:   "partial transport state",
+			events: []interface{}{playing, avtChange(`<TransportState val="PAUSED_PLAYBACK"/>`)},
+			want: func() PlayerState {
+				s := playingState
+				s.TransportState = "PAUSED_PLAYBACK"
+				return s
+			}(),
+			notified: 2,
+		},
+		{
+			name:     "unchanged transport state",
+			events:   []interface{}{playing, avtChange(`<TransportState val="PLAYING"/><CurrentTrack val="3"/>`)},
+			want:     playingState,
+			notified: 1,
+		},
+		{
+			name:   "next track",
+			events: []interface{}{playing, avtChange(`<CurrentTrack val="4"/><CurrentTrackURI val="x-file-cifs://nas/4.flac"/>`)},
+			want: func() PlayerState {
+				s := playingState
+				s.CurrentTrack = 4
+				s.TrackURI = "x-file-cifs://nas/4.flac"
+				return s
+			}(),
+			notified: 2,
+		},
+		{
+			name:     "full rendering state",
+			events:   []interface{}{rendering},
+			want:     renderingState,
+			notified: 1,
+		},
+		{
+			name:   "partial rendering state",
+			events: []interface{}{rendering, renChange(`<Volume channel="Master" val="25"/>`), renChange(`<Mute channel="Master" val="1"/>`)},
+			want: func() PlayerState {
+				s := renderingState
+				s.Volume = 25
+				s.Mute = true
+				return s
+			}(),
+			notified: 3,
+		},
+		{
+			name:     "other channels",
+			events:   []interface{}{rendering, renChange(`<Volume channel="RF" val="90"/><Loudness channel="LF" val="0"/>`)},
+			want:     renderingState,
+			notified: 1,
+		},
+		{
+			name:   "transport and rendering",
+			events: []interface{}{playing, rendering, avtChange(`<TransportState val="STOPPED"/>`)},
+			want: func() PlayerState {
+				s := playingState
+				s.TransportState = "STOPPED"
+				s.Volume, s.Bass, s.Treble, s.Loudness = 20, 2, -1, true
+				return s
+			}(),
+			notified: 3,
+		},
+		{
+			name:     "malformed",
+			events:   []interface{}{playing, avt.LastChange(`<Event><InstanceID>`)},
+			want:     playingState,
+			notified: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlayer(func(action, args string) (string, error) {
+				return "", nil
+			})
+			defer p.Close()
+			zp := p.zonePlayer(t)
+
+			var notified int
+			var last PlayerState
+			zp.GetState().OnChange(func(_ *ZonePlayer, old, updated PlayerState) {
+				if reflect.DeepEqual(old, updated) {
+					t.Errorf("notified without a change: %+v", updated)
+				}
+				if !reflect.DeepEqual(old, last) {
+					t.Errorf("old state %+v, want %+v", old, last)
+				}
+				notified++
+				last = updated
+			})
+
+			for _, e := range tt.events {
+				zp.Event(e)
+			}
+
+			if got := zp.GetState().Snapshot(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("state %+v, want %+v", got, tt.want)
+			}
+			if notified != tt.notified {
+				t.Errorf("notified %d times, want %d", notified, tt.notified)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/caglar10ur/sonos"
+)
+
+// Event types.
+const (
+	// TypeSnapshot carries the whole state of a room. One is sent for every
+	// room when a client connects.
+	TypeSnapshot  = "snapshot"
+	TypeTransport = "transport"
+	TypeVolume    = "volume"
+	TypeQueue     = "queue"
+	TypeDevice    = "device"
+	// TypeTopology events are household wide and have no room.
+	TypeTopology = "topology"
+)
+
+// Event is a message sent to clients. Seq numbers the events of a
+// connection starting at 1; a gap means events were dropped because the
+// client did not keep up, and the client should reconnect to get a fresh
+// snapshot.
+type Event struct {
+	Seq  uint64      `json:"seq"`
+	Type string      `json:"type"`
+	Room string      `json:"room,omitempty"`
+	UUID string      `json:"uuid,omitempty"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Track is the current track of a room.
+type Track struct {
+	Title       string `json:"title"`
+	Artist      string `json:"artist,omitempty"`
+	Album       string `json:"album,omitempty"`
+	AlbumArtURI string `json:"album_art_uri,omitempty"`
+	URI         string `json:"uri,omitempty"`
+	// Duration is in seconds.
+	Duration int `json:"duration"`
+}
+
+// Transport is the data of transport events.
+type Transport struct {
+	State          string `json:"state"`
+	PlayMode       string `json:"play_mode"`
+	TrackNumber    int    `json:"track_number"`
+	NumberOfTracks int    `json:"number_of_tracks"`
+	Track          Track  `json:"track"`
+}
+
+// Volume is the data of volume events. The group values are only
+// maintained on group coordinators.
+type Volume struct {
+	Volume      int  `json:"volume"`
+	Mute        bool `json:"mute"`
+	Bass        int  `json:"bass"`
+	Treble      int  `json:"treble"`
+	Loudness    bool `json:"loudness"`
+	GroupVolume int  `json:"group_volume"`
+	GroupMute   bool `json:"group_mute"`
+}
+
+// Queue is the data of queue events. Clients fetch the queue again when
+// UpdateID changes.
+type Queue struct {
+	UpdateID       uint32 `json:"update_id"`
+	NumberOfTracks int    `json:"number_of_tracks"`
+}
+
+// Device is the data of device events.
+type Device struct {
+	RoomName        string `json:"room_name"`
+	Icon            string `json:"icon"`
+	LineInConnected bool   `json:"line_in_connected"`
+}
+
+// Snapshot is the data of snapshot events.
+type Snapshot struct {
+	Transport Transport `json:"transport"`
+	Volume    Volume    `json:"volume"`
+	Queue     Queue     `json:"queue"`
+	Device    Device    `json:"device"`
+}
+
+// Group is a group of the topology.
+type Group struct {
+	ID          string   `json:"id"`
+	Coordinator string   `json:"coordinator"`
+	Members     []string `json:"members"`
+}
+
+// Topology is the data of topology events.
+type Topology struct {
+	Groups []Group `json:"groups"`
+}
+
+func newTransport(s *sonos.PlayerState) Transport {
+	return Transport{
+		State:          s.TransportState,
+		PlayMode:       string(s.PlayMode),
+		TrackNumber:    s.CurrentTrack,
+		NumberOfTracks: s.NumberOfTracks,
+		Track: Track{
+			Title:       s.Track.Title,
+			Artist:      s.Track.Creator,
+			Album:       s.Track.Album,
+			AlbumArtURI: s.Track.AlbumArtURI,
+			URI:         s.TrackURI,
+			Duration:    int(s.TrackDuration / time.Second),
+		},
+	}
+}
+
+func newVolume(s *sonos.PlayerState) Volume {
+	return Volume{
+		Volume:      s.Volume,
+		Mute:        s.Mute,
+		Bass:        s.Bass,
+		Treble:      s.Treble,
+		Loudness:    s.Loudness,
+		GroupVolume: s.GroupVolume,
+		GroupMute:   s.GroupMute,
+	}
+}
+
+func newQueue(s *sonos.PlayerState) Queue {
+	return Queue{
+		UpdateID:       s.QueueUpdateID,
+		NumberOfTracks: s.NumberOfTracks,
+	}
+}
+
+func newDevice(s *sonos.PlayerState) Device {
+	return Device{
+		RoomName:        s.RoomName,
+		Icon:            s.Icon,
+		LineInConnected: s.LineInConnected,
+	}
+}
+
+func newSnapshot(s *sonos.PlayerState) Snapshot {
+	return Snapshot{
+		Transport: newTransport(s),
+		Volume:    newVolume(s),
+		Queue:     newQueue(s),
+		Device:    newDevice(s),
+	}
+}
+
+// newTopology lists the groups of the topology, leaving out the invisible
+// members of bonded rooms.
+func newTopology(state *sonos.ZoneGroupState) *Topology {
+	if state == nil {
+		return nil
+	}
+
+	t := &Topology{Groups: []Group{}}
+	for _, group := range state.ZoneGroups {
+		g := Group{ID: group.ID, Members: []string{}}
+		for _, member := range group.ZoneGroupMember {
+			if member.Invisible == "1" {
+				continue
+			}
+			if member.UUID == group.Coordinator {
+				g.Coordinator = member.ZoneName
+			}
+			g.Members = append(g.Members, member.ZoneName)
+		}
+		if len(g.Members) > 0 {
+			t.Groups = append(t.Groups, g)
+		}
+	}
+	return t
+}
+
+// changes returns the events for the sections that differ between old and
+// updated.
+func changes(old, updated *sonos.PlayerState) []Event {
+	var events []Event
+	if o, n := newTransport(old), newTransport(updated); o != n {
+		events = append(events, Event{Type: TypeTransport, Data: n})
+	}
+	if o, n := newVolume(old), newVolume(updated); o != n {
+		events = append(events, Event{Type: TypeVolume, Data: n})
+	}
+	if o, n := newQueue(old), newQueue(updated); o != n {
+		events = append(events, Event{Type: TypeQueue, Data: n})
+	}
+	if o, n := newDevice(old), newDevice(updated); o != n {
+		events = append(events, Event{Type: TypeDevice, Data: n})
+	}
+	return events
+}
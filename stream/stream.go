@@ -0,0 +1,372 @@
+// Package stream pushes the changes of a Sonos household to HTTP clients as
+// JSON events, over server-sent events or WebSocket. Clients may restrict
+// the rooms they receive events for with one or more room query
+// parameters, e.g. /events?room=Kitchen&room=Office. Every connection
+// starts with a snapshot of each room and of the topology.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caglar10ur/sonos"
+)
+
+const (
+	// DefaultBufferSize is the number of events queued per client before
+	// events are dropped.
+	DefaultBufferSize = 256
+	// DefaultKeepAlive is the interval of keep-alive messages.
+	DefaultKeepAlive = 30 * time.Second
+)
+
+// Handler streams the events of the players added to it.
+type Handler struct {
+	sonos *sonos.Sonos
+
+	bufferSize   int
+	keepAlive    time.Duration
+	errorHandler func(error)
+
+	subscriptions *sonos.SubscriptionSet
+
+	mu       sync.Mutex
+	players  map[string]*player
+	clients  map[*client]struct{}
+	topology *Topology
+}
+
+// player is a player whose events are streamed.
+type player struct {
+	zp *sonos.ZonePlayer
+}
+
+// client is a connected client.
+type client struct {
+	rooms  []string
+	seq    uint64
+	events chan Event
+}
+
+// wants reports whether the client receives events for room.
+func (c *client) wants(room string) bool {
+	if len(c.rooms) == 0 || room == "" {
+		return true
+	}
+	for _, r := range c.rooms {
+		if strings.EqualFold(r, room) {
+			return true
+		}
+	}
+	return false
+}
+
+// send numbers e and queues it, dropping it if the client is behind. The
+// sequence number is used either way so that the client sees the gap.
+func (c *client) send(e Event) {
+	c.seq++
+	e.Seq = c.seq
+	select {
+	case c.events <- e:
+	default:
+	}
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithBufferSize sets the number of events queued per client.
+func WithBufferSize(n int) Option {
+	return func(h *Handler) {
+		h.bufferSize = n
+	}
+}
+
+// WithKeepAlive sets the interval of the comments (server-sent events) or
+// pings (WebSocket) that keep idle connections open. Zero or less disables
+// them.
+func WithKeepAlive(d time.Duration) Option {
+	return func(h *Handler) {
+		h.keepAlive = d
+	}
+}
+
+// WithErrorHandler is called with errors that cannot be returned, such as
+// failed subscriptions.
+func WithErrorHandler(eh func(error)) Option {
+	return func(h *Handler) {
+		h.errorHandler = eh
+	}
+}
+
+// NewHandler returns a handler streaming the events of the players found
+// by s. Run must be called to find them.
+func NewHandler(s *sonos.Sonos, opts ...Option) *Handler {
+	h := &Handler{
+		sonos:        s,
+		bufferSize:   DefaultBufferSize,
+		keepAlive:    DefaultKeepAlive,
+		errorHandler: func(error) {},
+		players:      make(map[string]*player),
+		clients:      make(map[*client]struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.subscriptions = sonos.NewSubscriptionSet(s, h.services,
+		sonos.WithPlayerAdded(h.add),
+		sonos.WithPlayerRemoved(h.remove),
+		sonos.WithSubscriptionErrorHandler(h.errorHandler),
+	)
+	return h
+}
+
+// Run searches for players, adds them along with the other visible members
+// of their household and keeps their event subscriptions alive until ctx is
+// done, adding and removing players as the topology changes.
+func (h *Handler) Run(ctx context.Context) error {
+	return h.subscriptions.Run(ctx)
+}
+
+// Add subscribes to the events of zp and starts streaming them. If adding
+// fails, the subscriptions made are cancelled and zp can be added again.
+func (h *Handler) Add(ctx context.Context, zp *sonos.ZonePlayer) error {
+	return h.subscriptions.Add(ctx, zp)
+}
+
+// add starts streaming the events of a player that was subscribed to.
+func (h *Handler) add(_ context.Context, zp *sonos.ZonePlayer) error {
+	p := &player{zp: zp}
+
+	h.mu.Lock()
+	h.players[zp.UUID()] = p
+	h.mu.Unlock()
+
+	state := zp.GetState()
+	state.OnChange(func(zp *sonos.ZonePlayer, old, updated sonos.PlayerState) {
+		if h.added(p) {
+			h.publish(zp, &old, &updated)
+		}
+	})
+	if err := state.Populate(); err != nil {
+		h.forget(p)
+		return err
+	}
+	return nil
+}
+
+// added reports whether p is still handled. The state handler of a player
+// that was removed stays registered, so it checks it first.
+func (h *Handler) added(p *player) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.players[p.zp.UUID()] == p
+}
+
+// forget drops p, unless it was replaced.
+func (h *Handler) forget(p *player) {
+	h.mu.Lock()
+	if h.players[p.zp.UUID()] == p {
+		delete(h.players, p.zp.UUID())
+	}
+	h.mu.Unlock()
+}
+
+// remove stops streaming the events of a player that was removed.
+func (h *Handler) remove(zp *sonos.ZonePlayer) {
+	h.mu.Lock()
+	delete(h.players, zp.UUID())
+	h.mu.Unlock()
+}
+
+func (h *Handler) services(zp *sonos.ZonePlayer) []sonos.SonosService {
+	services := []sonos.SonosService{
+		zp.AVTransport(),
+		zp.RenderingControl(),
+		zp.ZoneGroupTopology(),
+		zp.DeviceProperties(),
+	}
+	if zp.IsCoordinator() {
+		services = append(services, zp.GroupRenderingControl(), zp.Queue())
+	}
+	// AudioIn reports whether a source is plugged into the line-in. If the
+	// probe fails, it is tried again when the subscriptions are renewed.
+	if lineIn, err := zp.HasLineIn(); err == nil && lineIn {
+		services = append(services, zp.AudioIn())
+	}
+	return services
+}
+
+// publish sends the events for a state change to the clients.
+func (h *Handler) publish(zp *sonos.ZonePlayer, old, updated *sonos.PlayerState) {
+	now := time.Now()
+	events := changes(old, updated)
+	for i := range events {
+		events[i].Room = updated.RoomName
+		events[i].UUID = zp.UUID()
+		events[i].Time = now
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Every player reports the same topology; only changes are sent.
+	if t := newTopology(updated.Topology); t != nil && !reflect.DeepEqual(t, h.topology) {
+		h.topology = t
+		events = append(events, Event{Type: TypeTopology, Time: now, Data: t})
+	}
+
+	for c := range h.clients {
+		for _, e := range events {
+			if c.wants(e.Room) {
+				c.send(e)
+			}
+		}
+	}
+}
+
+// connect registers a client and queues the snapshot, under the same lock
+// as publish so that no event is lost or sent before the snapshot.
+func (h *Handler) connect(rooms []string) *client {
+	c := &client{
+		rooms:  rooms,
+		events: make(chan Event, h.bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for _, p := range h.players {
+		state := p.zp.GetState().Snapshot()
+		if !c.wants(state.RoomName) {
+			continue
+		}
+		c.send(Event{
+			Type: TypeSnapshot,
+			Room: state.RoomName,
+			UUID: p.zp.UUID(),
+			Time: now,
+			Data: newSnapshot(&state),
+		})
+	}
+	if h.topology != nil {
+		c.send(Event{Type: TypeTopology, Time: now, Data: h.topology})
+	}
+
+	h.clients[c] = struct{}{}
+	return c
+}
+
+func (h *Handler) disconnect(c *client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+// ServeHTTP streams events over WebSocket if the request asks for an
+// upgrade and as server-sent events otherwise.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isWebSocket(r) {
+		h.serveWebSocket(w, r)
+		return
+	}
+	h.serveSSE(w, r)
+}
+
+// keepAliveTicker returns the ticks of the keep-alive interval and the
+// function stopping them. It never ticks when keep-alives are disabled.
+func (h *Handler) keepAliveTicker() (<-chan time.Time, func()) {
+	if h.keepAlive <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(h.keepAlive)
+	return ticker.C, ticker.Stop
+}
+
+func (h *Handler) serveSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	c := h.connect(r.URL.Query()["room"])
+	defer h.disconnect(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive, stop := h.keepAliveTicker()
+	defer stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case e := <-c.events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				h.errorHandler(err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (h *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	c := h.connect(r.URL.Query()["room"])
+	defer h.disconnect(c)
+
+	done := make(chan struct{})
+	go func() {
+		ws.readLoop()
+		close(done)
+	}()
+
+	keepAlive, stop := h.keepAliveTicker()
+	defer stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-keepAlive:
+			if err := ws.Ping(); err != nil {
+				return
+			}
+		case e := <-c.events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				h.errorHandler(err)
+				continue
+			}
+			if err := ws.WriteText(data); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caglar10ur/sonos"
+)
+
+func TestKeepAlive(t *testing.T) {
+	s, err := sonos.NewSonos(sonos.WithDiscovery(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, tt := range []struct {
+		keepAlive time.Duration
+		comments  bool
+	}{
+		{keepAlive: 5 * time.Millisecond, comments: true},
+		{keepAlive: 0},
+		{keepAlive: -time.Second},
+	} {
+		h := NewHandler(s, WithKeepAlive(tt.keepAlive))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		cancel()
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("keep-alive %v: status %d", tt.keepAlive, rec.Code)
+		}
+		if got := strings.Contains(rec.Body.String(), ": keep-alive"); got != tt.comments {
+			t.Errorf("keep-alive %v: comments sent = %v, want %v", tt.keepAlive, got, tt.comments)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// clientFrame returns a frame as a client sends it, masked with mask.
+func clientFrame(opcode byte, payload []byte, mask [4]byte) []byte {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(n))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// testWebSocket returns a websocket reading in and writing to out.
+func testWebSocket(in []byte, out *bytes.Buffer) *websocket {
+	return &websocket{rw: bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(in)), bufio.NewWriter(out))}
+}
+
+func TestReadFrame(t *testing.T) {
+	mask := [4]byte{0x37, 0xfa, 0x21, 0x3d}
+	long := bytes.Repeat([]byte("x"), 300)
+	huge := bytes.Repeat([]byte("y"), 70000)
+
+	for _, tt := range []struct {
+		name    string
+		in      []byte
+		opcode  byte
+		payload []byte
+		err     bool
+	}{
+		{"text", clientFrame(opText, []byte("Hello"), mask), opText, nil, false},
+		{"16 bit length", clientFrame(opText, long, mask), opText, nil, false},
+		{"64 bit length", clientFrame(opText, huge, mask), opText, nil, false},
+		{"ping", clientFrame(opPing, []byte("are you there"), mask), opPing, []byte("are you there"), false},
+		{"empty ping", clientFrame(opPing, nil, mask), opPing, []byte{}, false},
+		{"close", clientFrame(opClose, []byte{0x03, 0xe8, 'b', 'y', 'e'}, mask), opClose, []byte{0x03, 0xe8, 'b', 'y', 'e'}, false},
+		{"unmasked", []byte{0x81, 0x05, 'H', 'e', 'l', 'l', 'o'}, 0, nil, true},
+		{"control frame too long", clientFrame(opPing, long, mask), 0, nil, true},
+		{"truncated header", []byte{0x81}, 0, nil, true},
+		{"truncated length", []byte{0x81, 0x80 | 126, 0x01}, 0, nil, true},
+		{"truncated mask", []byte{0x81, 0x85, 0x37, 0xfa}, 0, nil, true},
+		{"truncated payload", clientFrame(opPing, []byte("Hello"), mask)[:8], 0, nil, true},
+	} {
+		ws := testWebSocket(tt.in, &bytes.Buffer{})
+		opcode, payload, err := ws.readFrame()
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: readFrame succeeded", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if opcode != tt.opcode || !bytes.Equal(payload, tt.payload) {
+			t.Errorf("%s: readFrame = %#x %q, want %#x %q", tt.name, opcode, payload, tt.opcode, tt.payload)
+		}
+		if _, err := ws.rw.ReadByte(); err != io.EOF {
+			t.Errorf("%s: frame not consumed entirely", tt.name)
+		}
+	}
+}
+
+func TestWriteFrame(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		length int
+		header []byte
+	}{
+		{"short", 5, []byte{0x81, 5}},
+		{"125", 125, []byte{0x81, 125}},
+		{"16 bit length", 126, []byte{0x81, 126, 0x00, 0x7e}},
+		{"largest 16 bit length", 0xffff, []byte{0x81, 126, 0xff, 0xff}},
+		{"64 bit length", 0x10000, []byte{0x81, 127, 0, 0, 0, 0, 0, 1, 0, 0}},
+	} {
+		var out bytes.Buffer
+		ws := testWebSocket(nil, &out)
+		payload := bytes.Repeat([]byte("z"), tt.length)
+		if err := ws.WriteText(payload); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		want := append(append([]byte{}, tt.header...), payload...)
+		if !bytes.Equal(out.Bytes(), want) {
+			t.Errorf("%s: header % x, want % x", tt.name, out.Bytes()[:len(tt.header)], tt.header)
+		}
+	}
+}
+
+func TestReadLoop(t *testing.T) {
+	mask := [4]byte{1, 2, 3, 4}
+	for _, tt := range []struct {
+		name string
+		in   [][]byte
+		out  []byte
+		err  error
+	}{
+		{
+			name: "ping",
+			in:   [][]byte{clientFrame(opPing, []byte("hi"), mask), clientFrame(opClose, []byte{0x03, 0xe8}, mask)},
+			out:  []byte{0x8a, 2, 'h', 'i', 0x88, 2, 0x03, 0xe8},
+			err:  io.EOF,
+		},
+		{
+			name: "close reason dropped",
+			in:   [][]byte{clientFrame(opText, []byte("ignored"), mask), clientFrame(opClose, []byte{0x03, 0xe8, 'o', 'k'}, mask)},
+			out:  []byte{0x88, 2, 0x03, 0xe8},
+			err:  io.EOF,
+		},
+		{
+			name: "close without status",
+			in:   [][]byte{clientFrame(opClose, nil, mask)},
+			out:  []byte{0x88, 0},
+			err:  io.EOF,
+		},
+		{
+			name: "connection lost",
+			in:   [][]byte{clientFrame(opPong, nil, mask)},
+			out:  nil,
+			err:  io.EOF,
+		},
+	} {
+		var out bytes.Buffer
+		ws := testWebSocket(bytes.Join(tt.in, nil), &out)
+		if err := ws.readLoop(); err != tt.err {
+			t.Errorf("%s: readLoop = %v, want %v", tt.name, err, tt.err)
+		}
+		if !bytes.Equal(out.Bytes(), tt.out) {
+			t.Errorf("%s: wrote % x, want % x", tt.name, out.Bytes(), tt.out)
+		}
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	var out bytes.Buffer
+	ws := testWebSocket(nil, &out)
+	if err := ws.writeFrame(opClose, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.WriteText([]byte("late")); err != errClosed {
+		t.Errorf("WriteText after close = %v, want errClosed", err)
+	}
+	if err := ws.writeFrame(opClose, nil); err != errClosed {
+		t.Errorf("second close frame = %v, want errClosed", err)
+	}
+	if !bytes.Equal(out.Bytes(), []byte{0x88, 0}) {
+		t.Errorf("wrote % x, want a single close frame", out.Bytes())
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	upgraded := make(chan *websocket, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrade(w, r)
+		if err != nil {
+			upgraded <- nil
+			return
+		}
+		upgraded <- ws
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		name    string
+		headers string
+		status  string
+		accept  string
+	}{
+		{
+			// The example of RFC 6455 section 1.3.
+			name: "handshake",
+			headers: "Connection: keep-alive, Upgrade\r\nUpgrade: websocket\r\n" +
+				"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n",
+			status: "HTTP/1.1 101 Switching Protocols",
+			accept: "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=",
+		},
+		{
+			name:    "missing key",
+			headers: "Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n",
+			status:  "HTTP/1.1 400 Bad Request",
+		},
+		{
+			name: "unsupported version",
+			headers: "Connection: Upgrade\r\nUpgrade: websocket\r\n" +
+				"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 8\r\n",
+			status: "HTTP/1.1 400 Bad Request",
+		},
+	} {
+		conn, err := net.Dial("tcp", server.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(conn, "GET /events HTTP/1.1\r\nHost: sonos\r\n"+tt.headers+"\r\n")
+
+		res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if status := res.Proto + " " + res.Status; status != tt.status {
+			t.Errorf("%s: status %q, want %q", tt.name, status, tt.status)
+		}
+		if accept := res.Header.Get("Sec-WebSocket-Accept"); accept != tt.accept {
+			t.Errorf("%s: accept %q, want %q", tt.name, accept, tt.accept)
+		}
+
+		if ws := <-upgraded; ws != nil {
+			ws.Close()
+		}
+		conn.Close()
+	}
+}
+
+func TestIsWebSocket(t *testing.T) {
+	for _, tt := range []struct {
+		connection string
+		upgrade    string
+		want       bool
+	}{
+		{"Upgrade", "websocket", true},
+		{"keep-alive, upgrade", "WebSocket", true},
+		{"keep-alive", "websocket", false},
+		{"Upgrade", "h2c", false},
+		{"", "", false},
+	} {
+		r := httptest.NewRequest("GET", "/events", nil)
+		r.Header.Set("Connection", tt.connection)
+		r.Header.Set("Upgrade", tt.upgrade)
+		if got := isWebSocket(r); got != tt.want {
+			t.Errorf("isWebSocket(%q, %q) = %v, want %v", tt.connection, tt.upgrade, got, tt.want)
+		}
+	}
+}
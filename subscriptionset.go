@@ -0,0 +1,333 @@
+package sonos
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// subscriptionRenewInterval is the interval SubscriptionSet renews its
+// subscriptions at; Subscribe makes them for 300 seconds.
+const subscriptionRenewInterval = 4 * time.Minute
+
+// SubscriptionSet keeps the event subscriptions to a set of players alive:
+// they are renewed before they expire and made again when renewing fails.
+// ZoneGroupTopology is always subscribed to, and the visible members of the
+// household of every player are added as the topology reports them, so
+// that the members of a group are added along with its coordinator. Players
+// no longer visible in the topology are removed.
+type SubscriptionSet struct {
+	sonos    *Sonos
+	services func(*ZonePlayer) []SonosService
+
+	added        func(context.Context, *ZonePlayer) error
+	removed      func(*ZonePlayer)
+	renewed      func(*ZonePlayer, error)
+	errorHandler func(error)
+
+	mu      sync.Mutex
+	players map[string]*subscribedPlayer
+	// topologies is the last topology handled of each household.
+	topologies map[string]*ZoneGroupState
+}
+
+// subscribedPlayer is a player of a SubscriptionSet and its SIDs.
+type subscribedPlayer struct {
+	zp *ZonePlayer
+	// added is set under SubscriptionSet.mu once the player is added.
+	added bool
+
+	mu   sync.Mutex
+	sids map[SonosService]string
+}
+
+// SubscriptionOption configures a SubscriptionSet.
+type SubscriptionOption func(*SubscriptionSet)
+
+// WithPlayerAdded is called once a player is subscribed to. If it fails, the
+// subscriptions are cancelled and the player is not added.
+func WithPlayerAdded(fn func(context.Context, *ZonePlayer) error) SubscriptionOption {
+	return func(s *SubscriptionSet) {
+		s.added = fn
+	}
+}
+
+// WithPlayerRemoved is called when a player is removed, including when the
+// set stops running.
+func WithPlayerRemoved(fn func(*ZonePlayer)) SubscriptionOption {
+	return func(s *SubscriptionSet) {
+		s.removed = fn
+	}
+}
+
+// WithRenewed is called after the subscriptions of a player are renewed,
+// with the error if some could not be made again.
+func WithRenewed(fn func(*ZonePlayer, error)) SubscriptionOption {
+	return func(s *SubscriptionSet) {
+		s.renewed = fn
+	}
+}
+
+// WithSubscriptionErrorHandler is called with errors that cannot be
+// returned, such as failed renewals.
+func WithSubscriptionErrorHandler(h func(error)) SubscriptionOption {
+	return func(s *SubscriptionSet) {
+		s.errorHandler = h
+	}
+}
+
+// NewSubscriptionSet returns an empty set subscribing to the services that
+// services returns for each player.
+func NewSubscriptionSet(s *Sonos, services func(*ZonePlayer) []SonosService, opts ...SubscriptionOption) *SubscriptionSet {
+	set := &SubscriptionSet{
+		sonos:        s,
+		services:     services,
+		errorHandler: func(error) {},
+		players:      make(map[string]*subscribedPlayer),
+		topologies:   make(map[string]*ZoneGroupState),
+	}
+	for _, opt := range opts {
+		opt(set)
+	}
+	return set
+}
+
+// Run searches for players, adds them and keeps their subscriptions alive
+// until ctx is done. The players are then removed.
+func (s *SubscriptionSet) Run(ctx context.Context) error {
+	err := s.sonos.Search(ctx, func(_ *Sonos, zp *ZonePlayer) {
+		go s.addOrReport(ctx, zp)
+	})
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(subscriptionRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			for _, p := range s.list() {
+				s.Remove(p.zp)
+			}
+			return nil
+		case <-ticker.C:
+			s.renew(ctx)
+		}
+	}
+}
+
+func (s *SubscriptionSet) addOrReport(ctx context.Context, zp *ZonePlayer) {
+	if err := s.Add(ctx, zp); err != nil {
+		s.errorHandler(fmt.Errorf("%s: %w", zp.RoomName(), err))
+	}
+}
+
+// Add subscribes to the events of zp. If subscribing fails, the
+// subscriptions made are cancelled and zp can be added again.
+func (s *SubscriptionSet) Add(ctx context.Context, zp *ZonePlayer) error {
+	p := &subscribedPlayer{
+		zp:   zp,
+		sids: make(map[SonosService]string),
+	}
+
+	s.mu.Lock()
+	if _, ok := s.players[zp.UUID()]; ok {
+		s.mu.Unlock()
+		return nil
+	}
+	s.players[zp.UUID()] = p
+	s.mu.Unlock()
+
+	// The handler stays registered if p is removed, so it checks that p is
+	// still part of the set first.
+	zp.GetState().OnChange(func(zp *ZonePlayer, old, updated PlayerState) {
+		if updated.Topology != nil && updated.Topology != old.Topology && s.contains(p) {
+			go s.syncTopology(ctx, zp, updated.Topology)
+		}
+	})
+
+	err := s.subscribe(ctx, p)
+	if err == nil && s.added != nil {
+		err = s.added(ctx, zp)
+	}
+	if err != nil {
+		s.drop(p)
+		return err
+	}
+
+	s.mu.Lock()
+	p.added = true
+	s.mu.Unlock()
+	return nil
+}
+
+// Remove cancels the subscriptions of zp and drops it from the set.
+func (s *SubscriptionSet) Remove(zp *ZonePlayer) {
+	s.mu.Lock()
+	p, ok := s.players[zp.UUID()]
+	added := ok && p.added
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	s.drop(p)
+	if added && s.removed != nil {
+		s.removed(zp)
+	}
+}
+
+// drop removes p from the set, unless it was replaced, and cancels its
+// subscriptions.
+func (s *SubscriptionSet) drop(p *subscribedPlayer) {
+	s.mu.Lock()
+	if s.players[p.zp.UUID()] == p {
+		delete(s.players, p.zp.UUID())
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for service, sid := range p.sids {
+		s.sonos.Unsubscribe(ctx, p.zp, service, sid)
+	}
+	p.sids = make(map[SonosService]string)
+}
+
+func (s *SubscriptionSet) contains(p *subscribedPlayer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.players[p.zp.UUID()] == p
+}
+
+func (s *SubscriptionSet) list() []*subscribedPlayer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	players := make([]*subscribedPlayer, 0, len(s.players))
+	for _, p := range s.players {
+		players = append(players, p)
+	}
+	return players
+}
+
+// subscribe subscribes to the services p has no subscription to and
+// cancels the subscriptions to services no longer wanted, e.g. group
+// services of a player that stopped being a coordinator.
+func (s *SubscriptionSet) subscribe(ctx context.Context, p *subscribedPlayer) error {
+	wanted := map[SonosService]bool{p.zp.ZoneGroupTopology(): true}
+	for _, service := range s.services(p.zp) {
+		wanted[service] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for service, sid := range p.sids {
+		if !wanted[service] {
+			s.sonos.Unsubscribe(ctx, p.zp, service, sid)
+			delete(p.sids, service)
+		}
+	}
+	for service := range wanted {
+		if _, ok := p.sids[service]; ok {
+			continue
+		}
+		sid, err := s.sonos.Subscribe(ctx, p.zp, service)
+		if err != nil {
+			return err
+		}
+		p.sids[service] = sid
+	}
+	return nil
+}
+
+// renew renews the subscriptions of every player, subscribing again when
+// renewing fails.
+func (s *SubscriptionSet) renew(ctx context.Context) {
+	for _, p := range s.list() {
+		p.mu.Lock()
+		for service, sid := range p.sids {
+			if err := s.sonos.Renew(ctx, p.zp, service, sid); err != nil {
+				delete(p.sids, service)
+			}
+		}
+		p.mu.Unlock()
+
+		err := s.subscribe(ctx, p)
+		if err != nil {
+			s.errorHandler(fmt.Errorf("%s: %w", p.zp.RoomName(), err))
+		}
+		if s.renewed != nil {
+			s.renewed(p.zp, err)
+		}
+	}
+}
+
+// syncTopology adds the visible members of the topology of the household
+// of via that are not part of the set yet, removes the players of the
+// household that are no longer visible and updates the services subscribed
+// to, which depend on which players are coordinators. Every player reports
+// the same topology, so it only acts on changes.
+func (s *SubscriptionSet) syncTopology(ctx context.Context, via *ZonePlayer, topology *ZoneGroupState) {
+	household, err := via.HouseholdID()
+	if err != nil {
+		s.errorHandler(fmt.Errorf("%s: %w", via.RoomName(), err))
+		return
+	}
+
+	s.mu.Lock()
+	if reflect.DeepEqual(s.topologies[household], topology) {
+		s.mu.Unlock()
+		return
+	}
+	s.topologies[household] = topology
+	s.mu.Unlock()
+
+	visible := make(map[string]bool)
+	for _, group := range topology.ZoneGroups {
+		for i := range group.ZoneGroupMember {
+			member := &group.ZoneGroupMember[i]
+			if member.Invisible == "1" {
+				continue
+			}
+			visible[member.UUID] = true
+
+			s.mu.Lock()
+			_, ok := s.players[member.UUID]
+			s.mu.Unlock()
+			if ok {
+				continue
+			}
+			zp, err := via.peer(member)
+			if err != nil {
+				s.errorHandler(fmt.Errorf("%s: %w", member.ZoneName, err))
+				continue
+			}
+			zp.setHouseholdID(household)
+			go s.addOrReport(ctx, zp)
+		}
+	}
+
+	for _, p := range s.list() {
+		if id, err := p.zp.HouseholdID(); err != nil || id != household {
+			continue
+		}
+		if !visible[p.zp.UUID()] {
+			s.Remove(p.zp)
+			continue
+		}
+		if err := s.subscribe(ctx, p); err != nil {
+			s.errorHandler(fmt.Errorf("%s: %w", p.zp.RoomName(), err))
+		}
+	}
+}
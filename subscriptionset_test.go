@@ -0,0 +1,158 @@
+package sonos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionSetAdd(t *testing.T) {
+	p := newTestPlayer(func(action, _ string) (string, error) {
+		if action == "GetZoneGroupState" {
+			return testZoneGroupState, nil
+		}
+		return "", nil
+	})
+	defer p.Close()
+	zp := p.zonePlayer(t)
+
+	s, err := NewSonos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	errAdd := errors.New("add failed")
+	for _, tt := range []struct {
+		name    string
+		added   error
+		removed int
+	}{
+		{"added", nil, 1},
+		{"added fails", errAdd, 0},
+	} {
+		var removed int
+		set := NewSubscriptionSet(s,
+			func(zp *ZonePlayer) []SonosService {
+				return []SonosService{zp.AVTransport(), zp.RenderingControl()}
+			},
+			WithPlayerAdded(func(context.Context, *ZonePlayer) error { return tt.added }),
+			WithPlayerRemoved(func(*ZonePlayer) { removed++ }),
+		)
+		before := len(p.actions("SUBSCRIBE"))
+
+		if err := set.Add(context.Background(), zp); err != tt.added {
+			t.Errorf("%s: Add = %v, want %v", tt.name, err, tt.added)
+		}
+		// ZoneGroupTopology is always subscribed to.
+		if n := len(p.actions("SUBSCRIBE")) - before; n != 3 {
+			t.Errorf("%s: %d subscriptions, want 3", tt.name, n)
+		}
+		set.Remove(zp)
+
+		if n := len(p.actions("SUBSCRIBE")) - len(p.actions("UNSUBSCRIBE")); n != 0 {
+			t.Errorf("%s: %d subscriptions left", tt.name, n)
+		}
+		if removed != tt.removed {
+			t.Errorf("%s: removed called %d times, want %d", tt.name, removed, tt.removed)
+		}
+		if n := len(set.list()); n != 0 {
+			t.Errorf("%s: %d players left", tt.name, n)
+		}
+	}
+}
+
+func TestSubscriptionSetTopology(t *testing.T) {
+	coordinator := newTestPlayer(func(string, string) (string, error) { return "", nil })
+	defer coordinator.Close()
+	member := newTestPlayer(func(string, string) (string, error) { return "", nil })
+	defer member.Close()
+	member.mu.Lock()
+	member.uuid = "RINCON_2"
+	member.mu.Unlock()
+
+	s, err := NewSonos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var mu sync.Mutex
+	var removed []string
+	set := NewSubscriptionSet(s,
+		func(zp *ZonePlayer) []SonosService { return []SonosService{zp.AVTransport()} },
+		WithPlayerRemoved(func(zp *ZonePlayer) {
+			mu.Lock()
+			removed = append(removed, zp.UUID())
+			mu.Unlock()
+		}),
+	)
+
+	zp := coordinator.zonePlayer(t)
+	zp.setHouseholdID("Sonos_1")
+	if err := set.Add(context.Background(), zp); err != nil {
+		t.Fatal(err)
+	}
+
+	kitchen := ZoneGroupMember{UUID: testPlayerUUID, ZoneName: "Kitchen"}
+	den := ZoneGroupMember{UUID: "RINCON_2", ZoneName: "Den", Location: member.URL + "/xml/device_description.xml"}
+	hiddenDen := den
+	hiddenDen.Invisible = "1"
+
+	for _, tt := range []struct {
+		name    string
+		members []ZoneGroupMember
+		players []string
+		removed []string
+	}{
+		{"coordinator alone", []ZoneGroupMember{kitchen}, []string{testPlayerUUID}, nil},
+		{"member joins", []ZoneGroupMember{kitchen, den}, []string{testPlayerUUID, "RINCON_2"}, nil},
+		{"member bonded", []ZoneGroupMember{kitchen, hiddenDen}, []string{testPlayerUUID}, []string{"RINCON_2"}},
+		{"member visible again", []ZoneGroupMember{kitchen, den}, []string{testPlayerUUID, "RINCON_2"}, []string{"RINCON_2"}},
+	} {
+		topology := &ZoneGroupState{ZoneGroups: []ZoneGroup{{Coordinator: testPlayerUUID, ZoneGroupMember: tt.members}}}
+		zp.GetState().update(func(state *PlayerState) {
+			state.Topology = topology
+		})
+
+		var players, dropped []string
+		deadline := time.Now().Add(time.Second)
+		for {
+			players = nil
+			for _, p := range set.list() {
+				players = append(players, p.zp.UUID())
+			}
+			sort.Strings(players)
+			mu.Lock()
+			dropped = append([]string(nil), removed...)
+			mu.Unlock()
+			if fmt.Sprint(players, dropped) == fmt.Sprint(tt.players, tt.removed) || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if fmt.Sprint(players) != fmt.Sprint(tt.players) {
+			t.Errorf("%s: players %v, want %v", tt.name, players, tt.players)
+		}
+		if fmt.Sprint(dropped) != fmt.Sprint(tt.removed) {
+			t.Errorf("%s: removed %v, want %v", tt.name, dropped, tt.removed)
+		}
+	}
+
+	// The member is subscribed to again once visible.
+	deadline := time.Now().Add(time.Second)
+	for {
+		n := len(member.actions("SUBSCRIBE")) - len(member.actions("UNSUBSCRIBE"))
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("member has %d subscriptions, want 2", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
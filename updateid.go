@@ -0,0 +1,78 @@
+package sonos
+
+import "sync"
+
+// updateIDTracker keeps the UpdateID Sonos uses for optimistic locking of
+// queues and saved queues, and serialises the operations that depend on it.
+type updateIDTracker struct {
+	mu       sync.Mutex
+	updateID uint32
+	synced   bool
+
+	// fetch retrieves the current UpdateID from the device.
+	fetch func() (uint32, error)
+}
+
+// set stores an UpdateID learnt from a Browse response or an event.
+func (t *updateIDTracker) set(updateID uint32) {
+	t.mu.Lock()
+	t.updateID = updateID
+	t.synced = true
+	t.mu.Unlock()
+}
+
+// invalidate forces the next operation to fetch the UpdateID first.
+func (t *updateIDTracker) invalidate() {
+	t.mu.Lock()
+	t.synced = false
+	t.mu.Unlock()
+}
+
+func (t *updateIDTracker) refresh() error {
+	updateID, err := t.fetch()
+	if err != nil {
+		return err
+	}
+	t.updateID = updateID
+	t.synced = true
+	return nil
+}
+
+// errCodeInvalidUpdateID is the UPnP error code a player faults with when the
+// UpdateID of a queue or saved queue action is not the current one.
+const errCodeInvalidUpdateID = 412
+
+// do runs fn with the current UpdateID and stores the one it returns; fn
+// returns nil when the action does not report the new UpdateID. When the
+// player rejects the UpdateID because someone else modified the list in the
+// meantime, fn is retried once with a freshly fetched one. Other errors are
+// returned as is, since the action may have been applied.
+func (t *updateIDTracker) do(fn func(updateID uint32) (*uint32, error)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.synced {
+		if err := t.refresh(); err != nil {
+			return err
+		}
+	}
+
+	newUpdateID, err := fn(t.updateID)
+	if code, ok := UPnPErrorCode(err); ok && code == errCodeInvalidUpdateID {
+		if rerr := t.refresh(); rerr != nil {
+			return err
+		}
+		newUpdateID, err = fn(t.updateID)
+	}
+	if err != nil {
+		t.synced = false
+		return err
+	}
+
+	if newUpdateID == nil {
+		t.synced = false
+		return nil
+	}
+	t.updateID = *newUpdateID
+	return nil
+}
@@ -0,0 +1,58 @@
+package sonos
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	que "github.com/caglar10ur/sonos/services/Queue"
+)
+
+func TestUpdateIDTrackerDo(t *testing.T) {
+	stale := &que.UPnPError{Action: "RemoveTrackRange", Code: errCodeInvalidUpdateID}
+	other := &que.UPnPError{Action: "RemoveTrackRange", Code: 701}
+	network := errors.New("connection reset")
+
+	for _, tt := range []struct {
+		name    string
+		results []error
+		calls   []uint32
+		err     error
+		synced  bool
+	}{
+		{"success", []error{nil}, []uint32{5}, nil, true},
+		{"stale UpdateID", []error{stale, nil}, []uint32{5, 9}, nil, true},
+		{"stale twice", []error{stale, stale}, []uint32{5, 9}, stale, false},
+		{"wrapped stale UpdateID", []error{fmt.Errorf("queue: %w", stale), nil}, []uint32{5, 9}, nil, true},
+		{"other fault", []error{other}, []uint32{5}, other, false},
+		{"network error", []error{network}, []uint32{5}, network, false},
+	} {
+		tracker := &updateIDTracker{fetch: func() (uint32, error) { return 9, nil }}
+		tracker.set(5)
+
+		var calls []uint32
+		err := tracker.do(func(updateID uint32) (*uint32, error) {
+			err := tt.results[len(calls)]
+			calls = append(calls, updateID)
+			if err != nil {
+				return nil, err
+			}
+			next := updateID + 1
+			return &next, nil
+		})
+
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+		if !reflect.DeepEqual(calls, tt.calls) {
+			t.Errorf("%s: called with %v, want %v", tt.name, calls, tt.calls)
+		}
+		if tracker.synced != tt.synced {
+			t.Errorf("%s: synced = %v, want %v", tt.name, tracker.synced, tt.synced)
+		}
+		if err == nil && tracker.updateID != calls[len(calls)-1]+1 {
+			t.Errorf("%s: UpdateID %d, want the one returned", tt.name, tracker.updateID)
+		}
+	}
+}
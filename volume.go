@@ -0,0 +1,225 @@
+package sonos
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	ren "github.com/caglar10ur/sonos/services/RenderingControl"
+)
+
+const (
+	// MaxVolume is the highest volume a player accepts.
+	MaxVolume = 100
+
+	// minFadeStep is the shortest interval between two volume changes of a
+	// client side fade.
+	minFadeStep = 100 * time.Millisecond
+)
+
+// RampType selects one of the volume ramps built into the players.
+type RampType string
+
+const (
+	// RampTypeSleepTimer ramps down slowly over about 12 seconds.
+	RampTypeSleepTimer RampType = "SLEEP_TIMER_RAMP_TYPE"
+	// RampTypeAlarm mutes, then ramps up over about 15 seconds.
+	RampTypeAlarm RampType = "ALARM_RAMP_TYPE"
+	// RampTypeAutoplay mutes, then ramps up quickly.
+	RampTypeAutoplay RampType = "AUTOPLAY_RAMP_TYPE"
+)
+
+// VolumeLimits holds maximum volumes per room. A single instance can be
+// shared between players with WithVolumeLimits so that the caps apply no
+// matter which player instance is used to change the volume.
+type VolumeLimits struct {
+	mu     sync.RWMutex
+	limits map[string]int
+}
+
+// NewVolumeLimits returns an empty set of volume caps.
+func NewVolumeLimits() *VolumeLimits {
+	return &VolumeLimits{limits: make(map[string]int)}
+}
+
+// Set caps the volume of room to max.
+func (l *VolumeLimits) Set(room string, max int) {
+	l.mu.Lock()
+	l.limits[room] = clamp(max, 0, MaxVolume)
+	l.mu.Unlock()
+}
+
+// Remove lifts the volume cap of room.
+func (l *VolumeLimits) Remove(room string) {
+	l.mu.Lock()
+	delete(l.limits, room)
+	l.mu.Unlock()
+}
+
+// Get returns the volume cap of room, if any.
+func (l *VolumeLimits) Get(room string) (int, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	max, ok := l.limits[room]
+	return max, ok
+}
+
+// Clamp limits volume to the valid range and the cap of room.
+func (l *VolumeLimits) Clamp(room string, volume int) int {
+	max := MaxVolume
+	if l != nil {
+		if limit, ok := l.Get(room); ok {
+			max = limit
+		}
+	}
+	return clamp(volume, 0, max)
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// MaxVolume returns the volume cap of the player's room.
+func (z *ZonePlayer) MaxVolume() int {
+	return z.volumeLimits.Clamp(z.RoomName(), MaxVolume)
+}
+
+// SetMaxVolume caps the volume of the player's room. Every volume change
+// made through the library is limited to it. If the room is currently
+// louder it is turned down.
+func (z *ZonePlayer) SetMaxVolume(max int) error {
+	z.volumeLimits.Set(z.RoomName(), max)
+
+	volume, err := z.GetVolume()
+	if err != nil {
+		return err
+	}
+	if volume > z.MaxVolume() {
+		return z.SetVolume(volume)
+	}
+	return nil
+}
+
+func (z *ZonePlayer) clampVolume(volume int) int {
+	return z.volumeLimits.Clamp(z.RoomName(), volume)
+}
+
+// AdjustVolume changes the volume by delta and returns the new volume.
+func (z *ZonePlayer) AdjustVolume(delta int) (int, error) {
+	volume, err := z.GetVolume()
+	if err != nil {
+		return 0, err
+	}
+	volume = z.clampVolume(volume + delta)
+	return volume, z.SetVolume(volume)
+}
+
+// GetMute reports whether the player is muted.
+func (z *ZonePlayer) GetMute() (bool, error) {
+	res, err := z.RenderingControl().GetMute(&ren.GetMuteArgs{Channel: "Master"})
+	if err != nil {
+		return false, err
+	}
+	return res.CurrentMute, nil
+}
+
+// SetMute mutes or unmutes the player.
+func (z *ZonePlayer) SetMute(mute bool) error {
+	_, err := z.RenderingControl().SetMute(&ren.SetMuteArgs{
+		Channel:     "Master",
+		DesiredMute: mute,
+	})
+	return err
+}
+
+// RampToVolume lets the player ramp to volume using one of its built-in
+// ramps and returns how long the ramp takes.
+func (z *ZonePlayer) RampToVolume(rampType RampType, volume int) (time.Duration, error) {
+	res, err := z.RenderingControl().RampToVolume(&ren.RampToVolumeArgs{
+		Channel:       "Master",
+		RampType:      string(rampType),
+		DesiredVolume: uint16(z.clampVolume(volume)),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(res.RampTime) * time.Second, nil
+}
+
+// Fade changes the volume to target in even steps over d. Unlike
+// RampToVolume it works for any duration.
+func (z *ZonePlayer) Fade(ctx context.Context, target int, d time.Duration) error {
+	target = z.clampVolume(target)
+
+	current, err := z.GetVolume()
+	if err != nil {
+		return err
+	}
+
+	steps := target - current
+	if steps < 0 {
+		steps = -steps
+	}
+	if steps == 0 {
+		return nil
+	}
+
+	interval := d / time.Duration(steps)
+	if interval < minFadeStep {
+		interval = minFadeStep
+	}
+	n := int(d / interval)
+	if n < 1 {
+		n = 1
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for i := 1; i <= n; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		volume := current + (target-current)*i/n
+		if err := z.SetVolume(volume); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FadeIn starts playback silently and fades up to target over d.
+func (z *ZonePlayer) FadeIn(ctx context.Context, target int, d time.Duration) error {
+	if err := z.SetVolume(0); err != nil {
+		return err
+	}
+	if err := z.Play(); err != nil {
+		return err
+	}
+	return z.Fade(ctx, target, d)
+}
+
+// FadeOut fades the volume down to silence over d, pauses playback and
+// restores the original volume so the next Play is not silent.
+func (z *ZonePlayer) FadeOut(ctx context.Context, d time.Duration) error {
+	volume, err := z.GetVolume()
+	if err != nil {
+		return err
+	}
+	if err := z.Fade(ctx, 0, d); err != nil {
+		return err
+	}
+	if err := z.Pause(); err != nil {
+		return err
+	}
+	return z.SetVolume(volume)
+}
@@ -2,10 +2,12 @@ package sonos
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	avt "github.com/caglar10ur/sonos/services/AVTransport"
@@ -120,46 +122,263 @@ func WithLocation(u *url.URL) ZonePlayerOption {
 	}
 }
 
+// WithEventErrorHandler is called with the events the player fails to
+// decode and with the errors of refreshing the device description in the
+// background. They are dropped otherwise.
+func WithEventErrorHandler(h func(error)) ZonePlayerOption {
+	return func(z *ZonePlayer) {
+		z.errorHandler = h
+	}
+}
+
+// withPeers shares the players of the other devices of the household.
+func withPeers(p *peers) ZonePlayerOption {
+	return func(z *ZonePlayer) {
+		z.peers = p
+	}
+}
+
+// WithVolumeLimits shares the given per room volume caps with the player.
+func WithVolumeLimits(l *VolumeLimits) ZonePlayerOption {
+	return func(z *ZonePlayer) {
+		z.volumeLimits = l
+	}
+}
+
 func FromEndpoint(endpoint string) (*url.URL, error) {
 	return url.Parse(fmt.Sprintf("http://%s:1400/xml/device_description.xml", endpoint))
 }
 
 type ZonePlayer struct {
-	Root *Root
+	// root is the device description. It is replaced when the description
+	// is refreshed.
+	root   *Root
+	rootMu sync.RWMutex
+	// descriptions caches the device description across players.
+	descriptions DescriptionCache
+	bootSeq      string
+	householdID  string
 
 	client *http.Client
 	// A URL that can be queried for device capabilities
 	location *url.URL
 
+	volumeLimits *VolumeLimits
+	errorHandler func(error)
+	peers        *peers
+
 	*Services
+
+	queueOnce sync.Once
+	queue     *Queue
+
+	alarms alarmCache
+
+	sleepTimerOnce sync.Once
+	sleepTimer     *SleepTimer
+
+	stateOnce     sync.Once
+	state         *State
+	populateState bool
 }
 
+// Services are the UPnP services of a player. Each is built the first time
+// it is used.
 type Services struct {
-	// services
-	AlarmClock            *clk.Service
-	AudioIn               *ain.Service
-	AVTransport           *avt.Service
-	ConnectionManager     *con.Service
-	ContentDirectory      *dir.Service
-	DeviceProperties      *dev.Service
-	GroupManagement       *gmn.Service
-	GroupRenderingControl *rcg.Service
-	MusicServices         *mus.Service
-	QPlay                 *ply.Service
-	Queue                 *que.Service
-	RenderingControl      *ren.Service
-	SystemProperties      *sys.Service
-	VirtualLineIn         *vli.Service
-	ZoneGroupTopology     *zgt.Service
+	location *url.URL
+	client   *http.Client
+
+	// mu guards the services built so far.
+	mu                    sync.Mutex
+	alarmClock            *clk.Service
+	audioIn               *ain.Service
+	avTransport           *avt.Service
+	connectionManager     *con.Service
+	contentDirectory      *dir.Service
+	deviceProperties      *dev.Service
+	groupManagement       *gmn.Service
+	groupRenderingControl *rcg.Service
+	musicServices         *mus.Service
+	qPlay                 *ply.Service
+	queue                 *que.Service
+	renderingControl      *ren.Service
+	systemProperties      *sys.Service
+	virtualLineIn         *vli.Service
+	zoneGroupTopology     *zgt.Service
+}
+
+// AlarmClock returns the AlarmClock service of the player.
+func (s *Services) AlarmClock() *clk.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.alarmClock == nil {
+		s.alarmClock = clk.NewService(clk.WithLocation(s.location), clk.WithClient(s.client))
+	}
+	return s.alarmClock
+}
+
+// AudioIn returns the AudioIn service of the player.
+func (s *Services) AudioIn() *ain.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.audioIn == nil {
+		s.audioIn = ain.NewService(ain.WithLocation(s.location), ain.WithClient(s.client))
+	}
+	return s.audioIn
+}
+
+// AVTransport returns the AVTransport service of the player.
+func (s *Services) AVTransport() *avt.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.avTransport == nil {
+		s.avTransport = avt.NewService(avt.WithLocation(s.location), avt.WithClient(s.client))
+	}
+	return s.avTransport
+}
+
+// ConnectionManager returns the ConnectionManager service of the player.
+func (s *Services) ConnectionManager() *con.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.connectionManager == nil {
+		s.connectionManager = con.NewService(con.WithLocation(s.location), con.WithClient(s.client))
+	}
+	return s.connectionManager
+}
+
+// ContentDirectory returns the ContentDirectory service of the player.
+func (s *Services) ContentDirectory() *dir.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.contentDirectory == nil {
+		s.contentDirectory = dir.NewService(dir.WithLocation(s.location), dir.WithClient(s.client))
+	}
+	return s.contentDirectory
+}
+
+// DeviceProperties returns the DeviceProperties service of the player.
+func (s *Services) DeviceProperties() *dev.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.deviceProperties == nil {
+		s.deviceProperties = dev.NewService(dev.WithLocation(s.location), dev.WithClient(s.client))
+	}
+	return s.deviceProperties
+}
+
+// GroupManagement returns the GroupManagement service of the player.
+func (s *Services) GroupManagement() *gmn.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.groupManagement == nil {
+		s.groupManagement = gmn.NewService(gmn.WithLocation(s.location), gmn.WithClient(s.client))
+	}
+	return s.groupManagement
+}
+
+// GroupRenderingControl returns the GroupRenderingControl service of the player.
+func (s *Services) GroupRenderingControl() *rcg.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.groupRenderingControl == nil {
+		s.groupRenderingControl = rcg.NewService(rcg.WithLocation(s.location), rcg.WithClient(s.client))
+	}
+	return s.groupRenderingControl
+}
+
+// MusicServices returns the MusicServices service of the player.
+func (s *Services) MusicServices() *mus.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.musicServices == nil {
+		s.musicServices = mus.NewService(mus.WithLocation(s.location), mus.WithClient(s.client))
+	}
+	return s.musicServices
+}
+
+// QPlay returns the QPlay service of the player.
+func (s *Services) QPlay() *ply.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.qPlay == nil {
+		s.qPlay = ply.NewService(ply.WithLocation(s.location), ply.WithClient(s.client))
+	}
+	return s.qPlay
+}
+
+// Queue returns the Queue service of the player.
+func (s *Services) Queue() *que.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.queue == nil {
+		s.queue = que.NewService(que.WithLocation(s.location), que.WithClient(s.client))
+	}
+	return s.queue
+}
+
+// RenderingControl returns the RenderingControl service of the player.
+func (s *Services) RenderingControl() *ren.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.renderingControl == nil {
+		s.renderingControl = ren.NewService(ren.WithLocation(s.location), ren.WithClient(s.client))
+	}
+	return s.renderingControl
+}
+
+// SystemProperties returns the SystemProperties service of the player.
+func (s *Services) SystemProperties() *sys.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.systemProperties == nil {
+		s.systemProperties = sys.NewService(sys.WithLocation(s.location), sys.WithClient(s.client))
+	}
+	return s.systemProperties
+}
+
+// VirtualLineIn returns the VirtualLineIn service of the player.
+func (s *Services) VirtualLineIn() *vli.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.virtualLineIn == nil {
+		s.virtualLineIn = vli.NewService(vli.WithLocation(s.location), vli.WithClient(s.client))
+	}
+	return s.virtualLineIn
+}
+
+// ZoneGroupTopology returns the ZoneGroupTopology service of the player.
+func (s *Services) ZoneGroupTopology() *zgt.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.zoneGroupTopology == nil {
+		s.zoneGroupTopology = zgt.NewService(zgt.WithLocation(s.location), zgt.WithClient(s.client))
+	}
+	return s.zoneGroupTopology
 }
 
 // NewZonePlayer returns a new ZonePlayer instance.
 func NewZonePlayer(opts ...ZonePlayerOption) (*ZonePlayer, error) {
 	zp := &ZonePlayer{
-		Root: &Root{},
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		volumeLimits: NewVolumeLimits(),
 	}
 
 	// Loop through each option
@@ -171,86 +390,31 @@ func NewZonePlayer(opts ...ZonePlayerOption) (*ZonePlayer, error) {
 	if zp.location == nil {
 		return nil, fmt.Errorf("Empty location")
 	}
-
-	resp, err := zp.client.Get(zp.location.String())
-	if err != nil {
-		return nil, err
+	if zp.peers == nil {
+		zp.peers = newPeers()
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if zp.root == nil {
+		root, err := zp.fetchDescription()
+		if err != nil {
+			return nil, err
+		}
+		zp.setRoot(root)
+	} else {
+		// The description came from a cache, make sure it is still current
+		// without holding up the caller.
+		go zp.refreshDescription()
 	}
-	defer resp.Body.Close()
 
-	err = xml.Unmarshal(body, zp.Root)
-	if err != nil {
-		return nil, err
-	}
+	zp.Services = &Services{location: zp.location, client: zp.client}
 
-	zp.Services = &Services{
-		AlarmClock: clk.NewService(
-			clk.WithLocation(zp.location),
-			clk.WithClient(zp.client),
-		),
-		AVTransport: avt.NewService(
-			avt.WithLocation(zp.location),
-			avt.WithClient(zp.client),
-		),
-		AudioIn: ain.NewService(
-			ain.WithLocation(zp.location),
-			ain.WithClient(zp.client),
-		),
-		ConnectionManager: con.NewService(
-			con.WithLocation(zp.location),
-			con.WithClient(zp.client),
-		),
-		ContentDirectory: dir.NewService(
-			dir.WithLocation(zp.location),
-			dir.WithClient(zp.client),
-		),
-		DeviceProperties: dev.NewService(
-			dev.WithLocation(zp.location),
-			dev.WithClient(zp.client),
-		),
-		GroupManagement: gmn.NewService(
-			gmn.WithLocation(zp.location),
-			gmn.WithClient(zp.client),
-		),
-		GroupRenderingControl: rcg.NewService(
-			rcg.WithLocation(zp.location),
-			rcg.WithClient(zp.client),
-		),
-		MusicServices: mus.NewService(
-			mus.WithLocation(zp.location),
-			mus.WithClient(zp.client),
-		),
-		QPlay: ply.NewService(
-			ply.WithLocation(zp.location),
-			ply.WithClient(zp.client),
-		),
-		Queue: que.NewService(
-			que.WithLocation(zp.location),
-			que.WithClient(zp.client),
-		),
-		RenderingControl: ren.NewService(
-			ren.WithLocation(zp.location),
-			ren.WithClient(zp.client),
-		),
-		SystemProperties: sys.NewService(
-			sys.WithLocation(zp.location),
-			sys.WithClient(zp.client),
-		),
-		VirtualLineIn: vli.NewService(
-			vli.WithLocation(zp.location),
-			vli.WithClient(zp.client),
-		),
-		ZoneGroupTopology: zgt.NewService(
-			zgt.WithLocation(zp.location),
-			zgt.WithClient(zp.client),
-		),
+	if zp.populateState {
+		if err := zp.GetState().Populate(); err != nil {
+			return nil, err
+		}
 	}
 
+	zp.peers.add(zp.UUID(), zp)
 	return zp, nil
 }
 
@@ -264,19 +428,25 @@ func (z *ZonePlayer) Location() *url.URL {
 }
 
 func (z *ZonePlayer) RoomName() string {
-	return z.Root.Device.RoomName
+	return z.device().RoomName
 }
 
 func (z *ZonePlayer) ModelName() string {
-	return z.Root.Device.ModelName
+	return z.device().ModelName
 }
 
 func (z *ZonePlayer) HardwareVersion() string {
-	return z.Root.Device.HardwareVersion
+	return z.device().HardwareVersion
 }
 
 func (z *ZonePlayer) SerialNum() string {
-	return z.Root.Device.SerialNum
+	return z.device().SerialNum
+}
+
+// UUID returns the RINCON_ identifier of the player, i.e. its UDN without the
+// "uuid:" prefix.
+func (z *ZonePlayer) UUID() string {
+	return strings.TrimPrefix(z.device().UDN, "uuid:")
 }
 
 func (z *ZonePlayer) IsCoordinator() bool {
@@ -285,7 +455,7 @@ func (z *ZonePlayer) IsCoordinator() bool {
 		return false
 	}
 	for _, group := range zoneGroupState.ZoneGroups {
-		if "uuid:"+group.Coordinator == z.Root.Device.UDN {
+		if group.Coordinator == z.UUID() {
 			return true
 		}
 	}
@@ -294,7 +464,7 @@ func (z *ZonePlayer) IsCoordinator() bool {
 }
 
 func (z *ZonePlayer) GetZoneGroupState() (*ZoneGroupState, error) {
-	zoneGroupStateResponse, err := z.ZoneGroupTopology.GetZoneGroupState(&zgt.GetZoneGroupStateArgs{})
+	zoneGroupStateResponse, err := z.ZoneGroupTopology().GetZoneGroupState(&zgt.GetZoneGroupStateArgs{})
 	if err != nil {
 		return nil, err
 	}
@@ -303,12 +473,13 @@ func (z *ZonePlayer) GetZoneGroupState() (*ZoneGroupState, error) {
 	if err != nil {
 		return nil, err
 	}
+	z.checkBootSeq(&zoneGroupState)
 
 	return &zoneGroupState, nil
 }
 
 func (z *ZonePlayer) GetVolume() (int, error) {
-	res, err := z.RenderingControl.GetVolume(&ren.GetVolumeArgs{Channel: "Master"})
+	res, err := z.RenderingControl().GetVolume(&ren.GetVolumeArgs{Channel: "Master"})
 	if err != nil {
 		return 0, err
 	}
@@ -317,27 +488,50 @@ func (z *ZonePlayer) GetVolume() (int, error) {
 }
 
 func (z *ZonePlayer) SetVolume(desiredVolume int) error {
-	_, err := z.RenderingControl.SetVolume(&ren.SetVolumeArgs{
+	_, err := z.RenderingControl().SetVolume(&ren.SetVolumeArgs{
 		Channel:       "Master",
-		DesiredVolume: uint16(desiredVolume),
+		DesiredVolume: uint16(z.clampVolume(desiredVolume)),
 	})
 	return err
 }
 
 func (z *ZonePlayer) Play() error {
-	_, err := z.AVTransport.Play(&avt.PlayArgs{
+	_, err := z.AVTransport().Play(&avt.PlayArgs{
 		Speed: "1",
 	})
 	return err
 }
 
+func (z *ZonePlayer) Pause() error {
+	_, err := z.AVTransport().Pause(&avt.PauseArgs{})
+	return err
+}
+
 func (z *ZonePlayer) Stop() error {
-	_, err := z.AVTransport.Stop(&avt.StopArgs{})
+	_, err := z.AVTransport().Stop(&avt.StopArgs{})
+	return err
+}
+
+func (z *ZonePlayer) Next() error {
+	_, err := z.AVTransport().Next(&avt.NextArgs{})
+	return err
+}
+
+func (z *ZonePlayer) Previous() error {
+	_, err := z.AVTransport().Previous(&avt.PreviousArgs{})
+	return err
+}
+
+// SetPlayMode sets the repeat and shuffle mode of the queue.
+func (z *ZonePlayer) SetPlayMode(mode PlayMode) error {
+	_, err := z.AVTransport().SetPlayMode(&avt.SetPlayModeArgs{
+		NewPlayMode: string(mode),
+	})
 	return err
 }
 
 func (z *ZonePlayer) SetAVTransportURI(url string) error {
-	_, err := z.AVTransport.SetAVTransportURI(&avt.SetAVTransportURIArgs{
+	_, err := z.AVTransport().SetAVTransportURI(&avt.SetAVTransportURIArgs{
 		CurrentURI: url,
 	})
 	return err
@@ -349,12 +543,83 @@ func (zp *ZonePlayer) Event(evt interface{}) {
 		var levt AVTransportLastChange
 		err := xml.Unmarshal([]byte(e), &levt)
 		if err != nil {
-			fmt.Printf("Unmarshal failure: %s", err)
+			zp.eventError(err)
+			return
+		}
+		zp.GetSleepTimer().handleGeneration(levt.InstanceID.SleepTimerGeneration.Value)
+		zp.GetState().handleAVTransport(&levt)
+
+	case ren.LastChange:
+		var levt RenderingControlLastChange
+		err := xml.Unmarshal([]byte(e), &levt)
+		if err != nil {
+			zp.eventError(err)
 			return
 		}
-		fmt.Printf("AVTransport/LastChangeEvent: %s\n", levt.String())
+		zp.GetState().handleRenderingControl(&levt)
+
+	case rcg.GroupVolume:
+		zp.GetState().update(func(state *PlayerState) {
+			state.GroupVolume = int(e)
+		})
+
+	case rcg.GroupMute:
+		zp.GetState().update(func(state *PlayerState) {
+			state.GroupMute = bool(e)
+		})
+
+	case que.LastChange:
+		var levt QueueLastChange
+		err := xml.Unmarshal([]byte(e), &levt)
+		if err != nil {
+			zp.eventError(err)
+			return
+		}
+		zp.GetQueue().handleLastChange(&levt)
+
+	case ain.LineInConnected:
+		zp.GetState().update(func(state *PlayerState) {
+			state.LineInConnected = bool(e)
+			state.LineInKnown = true
+		})
+
+	case dev.SupportsAudioIn:
+		supports := bool(e)
+		zp.GetState().update(func(state *PlayerState) {
+			state.SupportsAudioIn = &supports
+		})
+
+	case dev.ConfigMode:
+		zp.GetState().update(func(state *PlayerState) {
+			state.ConfigMode = string(e)
+		})
+
+	case dev.ZoneName:
+		zp.GetState().update(func(state *PlayerState) {
+			state.RoomName = string(e)
+		})
+
+	case dev.Icon:
+		zp.GetState().update(func(state *PlayerState) {
+			state.Icon = string(e)
+		})
+
+	case zgt.ZoneGroupState:
+		zoneGroupState := zp.GetState().handleTopology(string(e))
+		if zoneGroupState == nil {
+			zp.eventError(errors.New("invalid ZoneGroupState"))
+			return
+		}
+		zp.checkBootSeq(zoneGroupState)
+
+	case clk.AlarmListVersion:
+		zp.alarms.handleVersion(string(e))
+	}
+}
 
-	default:
-		fmt.Printf("Unhandeld event %T: %q\n", e, e)
+// eventError reports an event that could not be decoded.
+func (z *ZonePlayer) eventError(err error) {
+	if z.errorHandler != nil {
+		z.errorHandler(fmt.Errorf("%s: event: %w", z.RoomName(), err))
 	}
 }